@@ -1,11 +1,18 @@
 package main
 
 import (
+	"eventflow/internal/botcheck"
 	"eventflow/internal/database"
 	"eventflow/internal/handlers"
+	"eventflow/internal/jobs"
 	"eventflow/internal/middleware"
+	"eventflow/internal/payments"
+	"eventflow/internal/tickettoken"
+	"eventflow/internal/wallet"
 	"fmt"
 	"log"
+	"os"
+	"strings"
 
 	"github.com/gin-contrib/cors"
 	"github.com/gin-gonic/gin"
@@ -34,11 +41,42 @@ import (
 // @name Authorization
 // @description Type "Bearer" followed by a space and JWT token.
 
+// trustedProxies читает список доверенных прокси из TRUSTED_PROXIES
+func trustedProxies() []string {
+	var proxies []string
+	for _, proxy := range strings.Split(os.Getenv("TRUSTED_PROXIES"), ",") {
+		if proxy = strings.TrimSpace(proxy); proxy != "" {
+			proxies = append(proxies, proxy)
+		}
+	}
+	return proxies
+}
+
 func main() {
 	database.Connect()
 
+	payments.Setup()
+	tickettoken.Setup()
+	wallet.Setup()
+	botcheck.Setup()
+
+	jobs.StartPublishScheduler()
+	jobs.StartOrderExpiry()
+	jobs.StartNoShowMarking()
+	handlers.StartImportProcessing()
+	handlers.StartSeriesSync()
+	handlers.StartOrderPaymentRecovery()
+	handlers.StartRefundRecovery()
+
 	router := gin.Default()
 
+	// Заголовку X-Forwarded-For верим только от прокси из TRUSTED_PROXIES (через запятую,
+	// адреса или CIDR); по умолчанию - ни от кого, и c.ClientIP() - адрес соединения.
+	// Иначе лимит запросов по IP и защита от ботов обходятся подменой заголовка
+	if err := router.SetTrustedProxies(trustedProxies()); err != nil {
+		log.Fatalf("Invalid TRUSTED_PROXIES: %v", err)
+	}
+
 	config := cors.Config{
 		AllowOriginFunc: func(origin string) bool {
 			return true
@@ -68,12 +106,62 @@ func main() {
 		v1.DELETE("/categories/:id", handlers.DeleteCategory)
 		v1.GET("/categories/:id", handlers.GetCategoryById)
 
+		v1.GET("/venues", handlers.GetVenues)
+		v1.POST("/venues", handlers.PostVenue)
+		v1.PUT("/venues/:id", handlers.UpdateVenue)
+		v1.DELETE("/venues/:id", handlers.DeleteVenue)
+		v1.GET("/venues/:id", handlers.GetVenueById)
+
 		v1.GET("/events", handlers.GetEvents)
 		v1.POST("/events", handlers.PostEvent)
 		v1.PUT("/events/:id", handlers.UpdateEvent)
 		v1.DELETE("/events/:id", handlers.DeleteEvent)
+		v1.GET("/events/:id/waitlist", handlers.GetEventWaitlist)
+		v1.GET("/events/:id/publications", handlers.GetEventPublications)
+		v1.GET("/events/:id/badges.pdf", handlers.GetEventBadgesPDF)
+		v1.GET("/events/:id/checkin-manifest", middleware.AuthMiddleware(), handlers.GetEventCheckInManifest)
+		v1.GET("/events/:id/checkins", handlers.GetEventCheckIns)
+		v1.GET("/events/:id/registration-form", handlers.GetEventRegistrationForm)
+		v1.PUT("/events/:id/registration-form", handlers.PutEventRegistrationForm)
+		v1.DELETE("/events/:id/registration-form", handlers.DeleteEventRegistrationForm)
+		v1.GET("/events/:id/registration-answers", handlers.GetEventRegistrationAnswers)
+		v1.GET("/events/:id/seats", handlers.GetEventSeats)
+		v1.POST("/events/:id/seat-holds", handlers.PostEventSeatHold)
+		v1.POST("/events/:id/checkins/sync", middleware.AuthMiddleware(), handlers.PostEventCheckInSync)
+		v1.POST("/events/:id/schedule", handlers.ScheduleEvent)
+		v1.POST("/events/:id/start", handlers.StartEvent)
+		v1.POST("/events/:id/complete", handlers.CompleteEvent)
+		v1.POST("/events/:id/cancel", handlers.CancelEvent)
+		v1.POST("/events/:id/postpone", handlers.PostponeEvent)
 		v1.GET("/events/:id", handlers.GetEventById)
 
+		v1.GET("/event_series", handlers.GetEventSeries)
+		v1.POST("/event_series", handlers.PostEventSeries)
+		v1.PUT("/event_series/:id", handlers.UpdateEventSeries)
+		v1.DELETE("/event_series/:id", handlers.DeleteEventSeries)
+		v1.GET("/event_series/:id", handlers.GetEventSeriesById)
+
+		v1.GET("/tracks", handlers.GetTracks)
+		v1.POST("/tracks", handlers.PostTrack)
+		v1.PUT("/tracks/:id", handlers.UpdateTrack)
+		v1.DELETE("/tracks/:id", handlers.DeleteTrack)
+		v1.GET("/tracks/:id", handlers.GetTrackById)
+
+		v1.GET("/speakers", handlers.GetSpeakers)
+		v1.POST("/speakers", handlers.PostSpeaker)
+		v1.PUT("/speakers/:id", handlers.UpdateSpeaker)
+		v1.DELETE("/speakers/:id", handlers.DeleteSpeaker)
+		v1.GET("/speakers/:id", handlers.GetSpeakerById)
+
+		v1.GET("/sessions", handlers.GetSessions)
+		v1.POST("/sessions", handlers.PostSession)
+		v1.PUT("/sessions/:id", handlers.UpdateSession)
+		v1.DELETE("/sessions/:id", handlers.DeleteSession)
+		v1.GET("/sessions/:id/registrations", handlers.GetSessionRegistrations)
+		v1.POST("/sessions/:id/registrations", handlers.PostSessionRegistration)
+		v1.DELETE("/sessions/:id/registrations/:participant_id", handlers.DeleteSessionRegistration)
+		v1.GET("/sessions/:id", handlers.GetSessionById)
+
 		v1.GET("/event_types", handlers.GetEventTypes)
 		v1.POST("/event_types", handlers.PostEventType)
 		v1.PUT("/event_types/:id", handlers.UpdateEventType)
@@ -87,8 +175,17 @@ func main() {
 		v1.GET("/participants/:id/statistics", handlers.GetParticipantStatistics)
 		v1.GET("/participants/:id", handlers.GetParticipantById)
 
+		// Импорты содержат персональные данные из загруженных файлов
+		v1.GET("/imports", middleware.AuthMiddleware(), handlers.GetImports)
+		v1.POST("/imports", middleware.AuthMiddleware(), handlers.PostImport)
+		v1.POST("/imports/:id/commit", middleware.AuthMiddleware(), handlers.PostImportCommit)
+		v1.GET("/imports/:id/errors", middleware.AuthMiddleware(), handlers.GetImportErrors)
+		v1.GET("/imports/:id", middleware.AuthMiddleware(), handlers.GetImportById)
+
 		v1.GET("/event_registrations", handlers.GetEventRegistrations)
 		v1.POST("/event_registrations", handlers.PostEventRegistration)
+		v1.POST("/event_registrations/approve", middleware.AuthMiddleware(), handlers.PostApproveRegistrations)
+		v1.POST("/event_registrations/reject", middleware.AuthMiddleware(), handlers.PostRejectRegistrations)
 		v1.PUT("/event_registrations/:id", handlers.UpdateEventRegistration)
 		v1.DELETE("/event_registrations/:id", handlers.DeleteEventRegistration)
 		v1.GET("/event_registrations/:id", handlers.GetEventRegistrationById)
@@ -99,19 +196,68 @@ func main() {
 		v1.DELETE("/organizers/:id", handlers.DeleteOrganizer)
 		v1.GET("/organizers/:id", handlers.GetOrganizerById)
 
+		v1.GET("/ticket_tiers", handlers.GetTicketTiers)
+		v1.POST("/ticket_tiers", handlers.PostTicketTier)
+		v1.PUT("/ticket_tiers/:id", handlers.UpdateTicketTier)
+		v1.DELETE("/ticket_tiers/:id", handlers.DeleteTicketTier)
+		v1.GET("/ticket_tiers/:id", handlers.GetTicketTierById)
+
+		v1.GET("/orders", handlers.GetOrders)
+		v1.POST("/orders", handlers.PostOrder)
+		v1.POST("/orders/:id/pay", handlers.PayOrder)
+		v1.GET("/orders/:id", handlers.GetOrderById)
+
+		v1.GET("/promo_codes", handlers.GetPromoCodes)
+		v1.POST("/promo_codes", handlers.PostPromoCode)
+		v1.PUT("/promo_codes/:id", handlers.UpdatePromoCode)
+		v1.DELETE("/promo_codes/:id", handlers.DeletePromoCode)
+		v1.GET("/promo_codes/:id/redemptions", handlers.GetPromoCodeRedemptions)
+		v1.GET("/promo_codes/:id", handlers.GetPromoCodeById)
+
+		v1.GET("/seat_maps", handlers.GetSeatMaps)
+		v1.POST("/seat_maps", handlers.PostSeatMap)
+		v1.PUT("/seat_maps/:id", handlers.UpdateSeatMap)
+		v1.DELETE("/seat_maps/:id", handlers.DeleteSeatMap)
+		v1.GET("/seat_maps/:id", handlers.GetSeatMapById)
+		v1.DELETE("/seat-holds/:token", handlers.DeleteSeatHold)
+
+		v1.GET("/print_templates", handlers.GetPrintTemplates)
+		v1.POST("/print_templates", handlers.PostPrintTemplate)
+		v1.PUT("/print_templates/:id", handlers.UpdatePrintTemplate)
+		v1.DELETE("/print_templates/:id", handlers.DeletePrintTemplate)
+		v1.GET("/print_templates/:id", handlers.GetPrintTemplateById)
+
+		v1.GET("/refunds", handlers.GetRefunds)
+
 		v1.GET("/tickets", handlers.GetTickets)
 		v1.POST("/tickets", handlers.PostTicket)
 		v1.PUT("/tickets/:id", handlers.UpdateTicket)
 		v1.DELETE("/tickets/:id", handlers.DeleteTicket)
+		v1.POST("/tickets/refunds", middleware.AuthMiddleware(), handlers.PostBulkRefunds)
+		v1.GET("/tickets/verification-keys", handlers.GetTicketVerificationKeys)
+		v1.GET("/tickets/:id/qr.png", handlers.GetTicketQRPNG)
+		v1.GET("/tickets/:id/qr.svg", handlers.GetTicketQRSVG)
+		v1.GET("/tickets/:id/pdf", handlers.GetTicketPDF)
+		v1.GET("/tickets/:id/token", handlers.GetTicketToken)
+		v1.GET("/tickets/:id/wallet/:provider", handlers.GetTicketWallet)
+		v1.POST("/tickets/:id/transfer", middleware.AuthMiddleware(), handlers.PostTicketTransfer)
+		v1.GET("/tickets/:id/transfers", handlers.GetTicketTransfers)
+		v1.GET("/tickets/:id/refunds", handlers.GetTicketRefunds)
+		v1.POST("/tickets/:id/refunds", middleware.AuthMiddleware(), handlers.PostTicketRefund)
 		v1.GET("/tickets/qr/:qrcode", handlers.GetTicketByQRCode)
-		v1.POST("/tickets/qr/:qrcode/use", handlers.MarkTicketAsUsed)
+		v1.POST("/tickets/qr/:qrcode/use", middleware.OptionalAuthMiddleware(), handlers.MarkTicketAsUsed)
 		v1.GET("/tickets/:id", handlers.GetTicketById)
 
 		v1.GET("/dashboard/statistics", handlers.GetDashboardStatistics)
 		v1.GET("/dashboard/popular-categories", handlers.GetPopularCategories)
+		v1.GET("/dashboard/promo-codes", handlers.GetPromoCodeReport)
 		v1.GET("/dashboard/events/:id/statistics", handlers.GetEventStatistics)
+		v1.GET("/dashboard/events/:id/sessions/statistics", handlers.GetEventSessionStatistics)
 	}
 
+	// Публичный API для участников: только опубликованные события и самостоятельная регистрация
+	handlers.RegisterPublicRoutes(router.Group("/api/v1/public"))
+
 	err := router.Run(":8080")
 	if err != nil {
 		log.Fatal(err)
@@ -0,0 +1,161 @@
+// Package botcheck описывает проверки, которыми публичный API отсекает автоматические
+// запросы: скрытое поле-ловушку и капчу провайдера (Cloudflare Turnstile, hCaptcha или
+// reCAPTCHA). Проверка выбирается при старте (Setup) и может быть заменена целиком.
+package botcheck
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+)
+
+var (
+	// ErrRejected возвращается, если запрос похож на автоматический
+	ErrRejected = errors.New("request was rejected by bot protection")
+	// ErrUnavailable возвращается, если провайдер капчи не ответил
+	ErrUnavailable = errors.New("bot protection provider unavailable")
+)
+
+// Request - данные запроса, по которым принимается решение
+type Request struct {
+	Token    string // ответ виджета капчи
+	Honeypot string // скрытое поле формы, которое человек не заполняет
+	RemoteIP string
+}
+
+// Verifier проверяет, что запрос отправлен человеком
+type Verifier interface {
+	Name() string
+	Verify(ctx context.Context, req Request) error
+}
+
+// Chain выполняет проверки по порядку до первой ошибки
+type Chain []Verifier
+
+func (ch Chain) Name() string {
+	names := make([]string, len(ch))
+	for i, v := range ch {
+		names[i] = v.Name()
+	}
+	return strings.Join(names, "+")
+}
+
+func (ch Chain) Verify(ctx context.Context, req Request) error {
+	for _, v := range ch {
+		if err := v.Verify(ctx, req); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Honeypot отклоняет запросы с заполненным скрытым полем
+type Honeypot struct{}
+
+func (Honeypot) Name() string { return "honeypot" }
+
+func (Honeypot) Verify(ctx context.Context, req Request) error {
+	if strings.TrimSpace(req.Honeypot) != "" {
+		return ErrRejected
+	}
+	return nil
+}
+
+// Адреса проверки ответа капчи. Все три провайдера принимают одинаковый запрос
+// (secret, response, remoteip) и возвращают JSON с полем success.
+const (
+	TurnstileVerifyURL = "https://challenges.cloudflare.com/turnstile/v0/siteverify"
+	HCaptchaVerifyURL  = "https://api.hcaptcha.com/siteverify"
+	RecaptchaVerifyURL = "https://www.google.com/recaptcha/api/siteverify"
+)
+
+// SiteVerify проверяет ответ капчи на сервере провайдера
+type SiteVerify struct {
+	Provider string
+	URL      string
+	Secret   string
+	Client   *http.Client
+}
+
+func (s *SiteVerify) Name() string { return s.Provider }
+
+func (s *SiteVerify) Verify(ctx context.Context, req Request) error {
+	if req.Token == "" {
+		return ErrRejected
+	}
+
+	form := url.Values{"secret": {s.Secret}, "response": {req.Token}}
+	if req.RemoteIP != "" {
+		form.Set("remoteip", req.RemoteIP)
+	}
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, s.URL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return err
+	}
+	httpReq.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	resp, err := s.Client.Do(httpReq)
+	if err != nil {
+		return ErrUnavailable
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return ErrUnavailable
+	}
+
+	var result struct {
+		Success bool `json:"success"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return ErrUnavailable
+	}
+	if !result.Success {
+		return ErrRejected
+	}
+	return nil
+}
+
+// Current - проверка, используемая публичным API
+var Current Verifier = Chain{Honeypot{}}
+
+// Setup выбирает капчу по переменной окружения BOT_PROTECTION ("none", "turnstile",
+// "hcaptcha" или "recaptcha"; по умолчанию "none") с секретом BOT_PROTECTION_SECRET.
+// Поле-ловушка проверяется всегда.
+func Setup() {
+	provider := os.Getenv("BOT_PROTECTION")
+
+	var verifyURL string
+	switch provider {
+	case "", "none":
+		Current = Chain{Honeypot{}}
+		log.Printf("🤖 Bot protection: %s", Current.Name())
+		return
+	case "turnstile":
+		verifyURL = TurnstileVerifyURL
+	case "hcaptcha":
+		verifyURL = HCaptchaVerifyURL
+	case "recaptcha":
+		verifyURL = RecaptchaVerifyURL
+	default:
+		log.Fatalf("unknown BOT_PROTECTION %q", provider)
+	}
+
+	secret := os.Getenv("BOT_PROTECTION_SECRET")
+	if secret == "" {
+		log.Fatalf("BOT_PROTECTION_SECRET is required for BOT_PROTECTION=%s", provider)
+	}
+
+	Current = Chain{Honeypot{}, &SiteVerify{
+		Provider: provider,
+		URL:      verifyURL,
+		Secret:   secret,
+		Client:   &http.Client{Timeout: 5 * time.Second},
+	}}
+	log.Printf("🤖 Bot protection: %s", Current.Name())
+}
@@ -24,6 +24,16 @@ func getJWTSecret() string {
 	return secret
 }
 
+// currentOrganizerID возвращает ID организатора, если запрос прошел через AuthMiddleware
+func currentOrganizerID(c *gin.Context) (uint, bool) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		return 0, false
+	}
+	organizerID, ok := userID.(uint)
+	return organizerID, ok
+}
+
 // @Summary Регистрация нового организатора
 // @Description Создание нового аккаунта организатора с email и паролем
 // @Tags Auth
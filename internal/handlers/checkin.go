@@ -0,0 +1,475 @@
+package handlers
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"eventflow/internal/database"
+	"eventflow/internal/models"
+	"eventflow/internal/tickettoken"
+	"fmt"
+	"log"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// maxScanClockSkew - насколько время сканирования может опережать часы сервера
+const maxScanClockSkew = 5 * time.Minute
+
+// manifestTicket - тикет в манифесте сканера. Tag - метка кода (hex первых 6 байт SHA-256
+// от QR-кода тикета): совпадает с меткой в подписанном токене, а для кодов старых
+// тикетов сканер вычисляет ее сам
+type manifestTicket struct {
+	TicketID  uint   `json:"id"`
+	Tag       string `json:"tag"`
+	Name      string `json:"name"`
+	Type      string `json:"type"`
+	CheckedIn bool   `json:"checked_in"`
+}
+
+// @Summary Манифест для офлайн-сканеров
+// @Description Возвращает компактный список действующих тикетов события и ключи проверки токенов. С параметром since возвращаются только тикеты, измененные позже, и отмененные тикеты в revoked
+// @Tags Check-in
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "ID события"
+// @Param since query string false "Время прошлой выгрузки манифеста (generated_at, RFC 3339)"
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /events/{id}/checkin-manifest [get]
+func GetEventCheckInManifest(c *gin.Context) {
+	id := c.Param("id")
+	generatedAt := time.Now().UTC()
+
+	var since *time.Time
+	if value := c.Query("since"); value != "" {
+		parsed, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			c.JSON(400, gin.H{"error": "since must be an RFC 3339 timestamp"})
+			return
+		}
+		since = &parsed
+	}
+
+	var event models.Event
+	if err := database.DB.First(&event, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(404, gin.H{"error": "Event not found"})
+		} else {
+			c.JSON(500, gin.H{"error": "Database error"})
+		}
+		return
+	}
+
+	var rows []struct {
+		ID         uint
+		QRCode     string
+		Status     string
+		TicketType string
+		FullName   string
+		CheckedIn  bool
+	}
+	query := database.DB.Table("tickets").
+		Select(`tickets.id, tickets.qr_code, tickets.status, tickets.ticket_type, participants.full_name,
+			(event_registrations.status = 'attended' OR EXISTS (
+				SELECT 1 FROM check_ins WHERE check_ins.ticket_id = tickets.id AND check_ins.status = 'accepted'
+			)) AS checked_in`).
+		Joins("JOIN participants ON participants.id = tickets.participant_id").
+		Joins("LEFT JOIN event_registrations ON event_registrations.event_id = tickets.event_id AND event_registrations.participant_id = tickets.participant_id").
+		Where("tickets.event_id = ?", event.ID)
+	if since != nil {
+		query = query.Where("tickets.updated_at > ?", *since)
+	} else {
+		query = query.Where("tickets.status = ?", "active")
+	}
+	if err := query.Order("tickets.id ASC").Scan(&rows).Error; err != nil {
+		log.Printf("Database Error (Check-in manifest): %v", err)
+		c.JSON(500, gin.H{"error": "Database error"})
+		return
+	}
+
+	tickets := make([]manifestTicket, 0, len(rows))
+	revoked := make([]uint, 0)
+	for _, row := range rows {
+		if row.Status != "active" {
+			revoked = append(revoked, row.ID)
+			continue
+		}
+		tickets = append(tickets, manifestTicket{
+			TicketID:  row.ID,
+			Tag:       hex.EncodeToString(tickettoken.CodeTag(row.QRCode)),
+			Name:      row.FullName,
+			Type:      row.TicketType,
+			CheckedIn: row.CheckedIn,
+		})
+	}
+
+	c.JSON(200, gin.H{
+		"event_id":     event.ID,
+		"generated_at": generatedAt,
+		"start_time":   event.StartTime,
+		"end_time":     event.EndTime,
+		"token_prefix": tickettoken.Prefix,
+		"keys":         tickettoken.JWKs(),
+		"tickets":      tickets,
+		"revoked":      revoked,
+	})
+}
+
+// checkInError - проход запрещен политикой события; Previous - сканирование, из-за которого
+// проход запрещен
+type checkInError struct {
+	Reason   string
+	Message  string
+	Previous *models.CheckIn
+}
+
+func (e *checkInError) Error() string {
+	return e.Message
+}
+
+// admitCheckIn проверяет сканирование по политике прохода события и записывает его:
+// принятым или конфликтом. Строка тикета должна быть заблокирована. Состояние тикета
+// (внутри или снаружи, число входов) считается по принятым сканированиям до момента
+// checkIn.ScannedAt без сканирований на входе в сессии, поэтому поздно выгруженные
+// офлайн-сканирования сверяются верно.
+// Запрет прохода возвращается как denied, а не как ошибка, чтобы конфликт сохранился
+// в транзакции. superseded - принятое ранее более позднее сканирование, которое при
+// однократном входе стало конфликтом.
+func admitCheckIn(tx *gorm.DB, event *models.Event, checkIn *models.CheckIn) (denied *checkInError, superseded *models.CheckIn, err error) {
+	if checkIn.Direction == "" {
+		checkIn.Direction = "in"
+	}
+
+	var history []models.CheckIn
+	err = tx.Where("ticket_id = ? AND status = ? AND session_id IS NULL AND scanned_at <= ?", checkIn.TicketID, "accepted", checkIn.ScannedAt).
+		Order("scanned_at ASC, id ASC").
+		Find(&history).Error
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var last, firstEntry *models.CheckIn
+	entries := 0
+	for i := range history {
+		last = &history[i]
+		if history[i].Direction == "in" {
+			entries++
+			if firstEntry == nil {
+				firstEntry = &history[i]
+			}
+		}
+	}
+	inside := last != nil && last.Direction == "in"
+
+	switch {
+	case checkIn.Direction == "out" && !inside:
+		denied = &checkInError{Reason: "not_inside", Message: "ticket has not entered the event", Previous: last}
+	case checkIn.Direction == "out":
+	case event.EntryPolicy == "reentry" || event.EntryPolicy == "limited":
+		if inside {
+			denied = &checkInError{Reason: "already_inside", Message: "ticket is already inside, scan it out first", Previous: last}
+		} else if event.EntryPolicy == "limited" && event.MaxEntries != nil && entries >= *event.MaxEntries {
+			denied = &checkInError{Reason: "entry_limit_reached", Message: "ticket has used all allowed entries", Previous: last}
+		}
+	default:
+		if firstEntry != nil {
+			denied = &checkInError{Reason: "already_checked_in", Message: "ticket has already been used", Previous: firstEntry}
+		}
+	}
+
+	if denied != nil {
+		checkIn.Status = "conflict"
+		if denied.Previous != nil {
+			checkIn.ConflictWithID = &denied.Previous.ID
+		}
+		if err := tx.Create(checkIn).Error; err != nil {
+			return nil, nil, err
+		}
+		return denied, nil, nil
+	}
+
+	checkIn.Status = "accepted"
+	if err := tx.Create(checkIn).Error; err != nil {
+		return nil, nil, err
+	}
+
+	if checkIn.Direction != "in" {
+		return nil, nil, nil
+	}
+
+	// При однократном входе поздно выгруженное более раннее сканирование вытесняет
+	// принятый позже вход с другого устройства
+	if event.EntryPolicy == "" || event.EntryPolicy == "single" {
+		var later models.CheckIn
+		err := tx.Where("ticket_id = ? AND status = ? AND direction = ? AND session_id IS NULL AND scanned_at > ?",
+			checkIn.TicketID, "accepted", "in", checkIn.ScannedAt).
+			Order("scanned_at ASC").
+			First(&later).Error
+		if err == nil {
+			later.Status = "conflict"
+			later.ConflictWithID = &checkIn.ID
+			if err := tx.Model(&later).Select("status", "conflict_with_id").Updates(&later).Error; err != nil {
+				return nil, nil, err
+			}
+			superseded = &later
+		} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil, err
+		}
+	}
+
+	err = tx.Model(&models.EventRegistration{}).
+		Where("event_id = ? AND participant_id = ?", checkIn.EventID, checkIn.ParticipantID).
+		Update("status", "attended").Error
+	return nil, superseded, err
+}
+
+// syncOfflineScan сверяет одно офлайн-сканирование. Строка тикета блокируется, поэтому
+// выгрузки с разных устройств обрабатываются по очереди.
+func syncOfflineScan(tx *gorm.DB, event *models.Event, deviceID string, operatorID *uint, scan models.OfflineScan, now time.Time) (models.CheckInSyncResult, error) {
+	result := models.CheckInSyncResult{ScanID: scan.ScanID}
+
+	// Повторная выгрузка: возвращается сохраненный итог
+	var existing models.CheckIn
+	err := tx.Where("device_id = ? AND scan_id = ?", deviceID, scan.ScanID).First(&existing).Error
+	if err == nil {
+		result.TicketID = existing.TicketID
+		result.Status = existing.Status
+		result.CheckIn = &existing
+		if existing.ConflictWithID != nil {
+			var first models.CheckIn
+			if err := tx.First(&first, *existing.ConflictWithID).Error; err == nil {
+				result.FirstScan = &first
+			}
+		}
+		return result, nil
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return result, err
+	}
+
+	reject := func(reason string) (models.CheckInSyncResult, error) {
+		result.Status = "rejected"
+		result.Reason = reason
+		return result, nil
+	}
+
+	if scan.ScannedAt.After(now.Add(maxScanClockSkew)) {
+		return reject("scanned_at_in_future")
+	}
+
+	ticket, err := findTicketByCode(tx, scan.Code, scan.ScannedAt)
+	if err != nil {
+		if reason := ticketCodeErrorReason(err); reason != "" {
+			return reject(reason)
+		}
+		return result, err
+	}
+	result.TicketID = ticket.ID
+
+	if ticket.EventID != event.ID {
+		return reject("other_event")
+	}
+
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&ticket, ticket.ID).Error; err != nil {
+		return result, err
+	}
+	if ticket.Status == "canceled" {
+		return reject("ticket_canceled")
+	}
+	// Скан, сделанный до истечения тикета, но синхронизированный позже, принимается
+	if ticket.Status == "expired" && !scan.ScannedAt.Before(ticket.UpdatedAt) {
+		return reject("ticket_expired")
+	}
+
+	checkIn := models.CheckIn{
+		EventID:       ticket.EventID,
+		TicketID:      ticket.ID,
+		ParticipantID: ticket.ParticipantID,
+		DeviceID:      deviceID,
+		Gate:          scan.Gate,
+		OperatorID:    operatorID,
+		Direction:     scan.Direction,
+		ScanID:        scan.ScanID,
+		ScannedAt:     scan.ScannedAt,
+		Source:        "offline",
+	}
+
+	denied, superseded, err := admitCheckIn(tx, event, &checkIn)
+	if err != nil {
+		return result, err
+	}
+
+	result.Status = checkIn.Status
+	result.CheckIn = &checkIn
+	result.Superseded = superseded
+	if denied != nil {
+		result.Reason = denied.Reason
+		result.FirstScan = denied.Previous
+	}
+	return result, nil
+}
+
+// @Summary Синхронизация офлайн-сканирований
+// @Description Принимает пакет сканирований с устройства, сверяет его с другими устройствами по политике прохода события и возвращает конфликты (например, тикет уже прошел на другом входе). Повторная выгрузка тех же scan_id безопасна
+// @Tags Check-in
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "ID события"
+// @Param sync body models.CheckInSyncRequest true "Сканирования устройства"
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /events/{id}/checkins/sync [post]
+func PostEventCheckInSync(c *gin.Context) {
+	id := c.Param("id")
+
+	var input models.CheckInSyncRequest
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+
+	var event models.Event
+	if err := database.DB.First(&event, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(404, gin.H{"error": "Event not found"})
+		} else {
+			c.JSON(500, gin.H{"error": "Database error"})
+		}
+		return
+	}
+
+	// Сканирования сверяются в порядке времени, итоги возвращаются в порядке запроса
+	order := make([]int, len(input.Scans))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(a, b int) bool {
+		return input.Scans[order[a]].ScannedAt.Before(input.Scans[order[b]].ScannedAt)
+	})
+
+	var operatorID *uint
+	if organizerID, ok := currentOrganizerID(c); ok {
+		operatorID = &organizerID
+	}
+
+	now := time.Now()
+	results := make([]models.CheckInSyncResult, len(input.Scans))
+	counts := map[string]int{"accepted": 0, "conflict": 0, "rejected": 0}
+	conflicts := make([]models.CheckInSyncResult, 0)
+
+	for _, i := range order {
+		scan := input.Scans[i]
+		// Каждое сканирование - отдельная транзакция: при сбое уже сверенные сохраняются,
+		// а повторная выгрузка их не дублирует
+		err := database.DB.Transaction(func(tx *gorm.DB) error {
+			var err error
+			results[i], err = syncOfflineScan(tx, &event, input.DeviceID, operatorID, scan, now)
+			return err
+		})
+		if err != nil {
+			log.Printf("Database Error (Check-in sync): %v", err)
+			c.JSON(500, gin.H{"error": "Failed to sync check-ins. Database error."})
+			return
+		}
+
+		counts[results[i].Status]++
+		if results[i].Status == "conflict" || results[i].Superseded != nil {
+			conflicts = append(conflicts, results[i])
+		}
+	}
+
+	c.JSON(200, gin.H{
+		"event_id":  event.ID,
+		"device_id": input.DeviceID,
+		"accepted":  counts["accepted"],
+		"conflict":  counts["conflict"],
+		"rejected":  counts["rejected"],
+		"conflicts": conflicts,
+		"results":   results,
+	})
+}
+
+// @Summary Журнал проходов события
+// @Description Возвращает сканирования тикетов события (вход, выход, конфликты) с пагинацией и фильтрами
+// @Tags Check-in
+// @Produce json
+// @Param id path int true "ID события"
+// @Param range query string false "Пагинация [start, end]"
+// @Param sort query string false "Сортировка [field, order]"
+// @Param ticket_id query int false "Фильтр по ID тикета"
+// @Param status query string false "Фильтр по статусу (accepted, conflict)"
+// @Param direction query string false "Фильтр по направлению (in, out)"
+// @Param session_id query int false "Фильтр по сессии"
+// @Param gate query string false "Фильтр по входу"
+// @Param device_id query string false "Фильтр по устройству"
+// @Success 200 {array} models.CheckIn
+// @Header 200 {string} X-Total-Count "Общее количество записей"
+// @Header 200 {string} Content-Range "Диапазон записей"
+// @Router /events/{id}/checkins [get]
+func GetEventCheckIns(c *gin.Context) {
+	eventID := c.Param("id")
+
+	var checkIns []models.CheckIn
+	var total int64
+
+	rangeParam := c.Query("range")
+	var start, end int = 0, 25
+	if rangeParam != "" {
+		var rangeArray []int
+		if err := json.Unmarshal([]byte(rangeParam), &rangeArray); err == nil && len(rangeArray) == 2 {
+			start = rangeArray[0]
+			end = rangeArray[1]
+		}
+	}
+
+	sortParam := c.Query("sort")
+	var sortField, sortOrder string = "scanned_at", "DESC"
+	if sortParam != "" {
+		var sortArray []string
+		if err := json.Unmarshal([]byte(sortParam), &sortArray); err == nil && len(sortArray) == 2 {
+			sortField = sortArray[0]
+			sortOrder = sortArray[1]
+		}
+	}
+
+	limit := end - start + 1
+	offset := start
+
+	query := database.DB.Model(&models.CheckIn{}).Where("event_id = ?", eventID)
+	for _, filter := range []string{"ticket_id", "session_id", "status", "direction", "gate", "device_id"} {
+		if value := c.Query(filter); value != "" {
+			query = query.Where(filter+" = ?", value)
+		}
+	}
+
+	countResult := query.Count(&total)
+	if countResult.Error != nil {
+		c.JSON(500, gin.H{"error": "Failed to retrieve total record count"})
+		return
+	}
+
+	contentRange := fmt.Sprintf("checkins %d-%d/%d", start, end, total)
+
+	result := query.
+		Limit(limit).
+		Offset(offset).
+		Order(sortField + " " + sortOrder).
+		Find(&checkIns)
+	if result.Error != nil {
+		c.JSON(500, gin.H{"error": "Database error"})
+		return
+	}
+
+	c.Header("Content-Range", contentRange)
+	c.Header("X-Total-Count", strconv.Itoa(int(total)))
+	c.JSON(200, checkIns)
+}
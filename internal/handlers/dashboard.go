@@ -5,6 +5,7 @@ import (
 	"eventflow/internal/models"
 
 	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
 )
 
 // @Summary Общая статистика для dashboard
@@ -12,26 +13,54 @@ import (
 // @Tags Dashboard
 // @Accept json
 // @Produce json
+// @Param start_date query string false "Учитывать события, начинающиеся не раньше (RFC 3339 или YYYY-MM-DD)"
+// @Param end_date query string false "Учитывать события, заканчивающиеся не позже (RFC 3339 или YYYY-MM-DD)"
+// @Param tz query string false "Часовой пояс IANA для дат без смещения" default(UTC)
 // @Success 200 {object} map[string]interface{} "Статистика dashboard"
 // @Router /dashboard/statistics [get]
 func GetDashboardStatistics(c *gin.Context) {
+	from, to, ok := timeRangeFilter(c)
+	if !ok {
+		return
+	}
+
+	// Статистика по событиям, регистрациям и тикетам ограничивается диапазоном дат событий
+	events := func() *gorm.DB {
+		query := database.DB.Model(&models.Event{})
+		if from != nil {
+			query = query.Where("start_time >= ?", *from)
+		}
+		if to != nil {
+			query = query.Where("end_time < ?", *to)
+		}
+		return query
+	}
+	byEvents := func(model interface{}) *gorm.DB {
+		query := database.DB.Model(model)
+		if from != nil || to != nil {
+			query = query.Where("event_id IN (?)", events().Select("id"))
+		}
+		return query
+	}
+
 	var totalEvents, totalParticipants, totalOrganizers, totalTickets int64
 	var publishedEvents, draftEvents int64
 
-	database.DB.Model(&models.Event{}).Count(&totalEvents)
-	database.DB.Model(&models.Event{}).Where("publish_status = ?", "published").Count(&publishedEvents)
-	database.DB.Model(&models.Event{}).Where("publish_status = ?", "draft").Count(&draftEvents)
+	events().Count(&totalEvents)
+	events().Where("publish_status = ?", "published").Count(&publishedEvents)
+	events().Where("publish_status = ?", "draft").Count(&draftEvents)
 
 	database.DB.Model(&models.Participant{}).Count(&totalParticipants)
 
 	database.DB.Model(&models.Organizer{}).Count(&totalOrganizers)
 
-	database.DB.Model(&models.Ticket{}).Count(&totalTickets)
+	byEvents(&models.Ticket{}).Count(&totalTickets)
 
-	var registeredCount, attendedCount, noShowCount int64
-	database.DB.Model(&models.EventRegistration{}).Where("status = ?", "registered").Count(&registeredCount)
-	database.DB.Model(&models.EventRegistration{}).Where("status = ?", "attended").Count(&attendedCount)
-	database.DB.Model(&models.EventRegistration{}).Where("status = ?", "no-show").Count(&noShowCount)
+	var registeredCount, attendedCount, noShowCount, pendingCount int64
+	byEvents(&models.EventRegistration{}).Where("status = ?", "registered").Count(&registeredCount)
+	byEvents(&models.EventRegistration{}).Where("status = ?", "attended").Count(&attendedCount)
+	byEvents(&models.EventRegistration{}).Where("status = ?", "no-show").Count(&noShowCount)
+	byEvents(&models.EventRegistration{}).Where("status = ?", "pending").Count(&pendingCount)
 
 	totalRegistrations := registeredCount + attendedCount + noShowCount
 	var attendanceRate float64 = 0
@@ -50,6 +79,7 @@ func GetDashboardStatistics(c *gin.Context) {
 		"registered_count":   registeredCount,
 		"attended_count":     attendedCount,
 		"no_show_count":      noShowCount,
+		"pending_count":      pendingCount,
 		"attendance_rate":    attendanceRate,
 	}
 
@@ -79,12 +109,13 @@ func GetPopularCategories(c *gin.Context) {
 func GetEventStatistics(c *gin.Context) {
 	eventID := c.Param("id")
 
-	var totalTickets, activeTickets, canceledTickets int64
+	var totalTickets, activeTickets, canceledTickets, expiredTickets int64
 	var totalRegistrations, attendedCount int64
 
 	database.DB.Model(&models.Ticket{}).Where("event_id = ?", eventID).Count(&totalTickets)
 	database.DB.Model(&models.Ticket{}).Where("event_id = ? AND status = ?", eventID, "active").Count(&activeTickets)
 	database.DB.Model(&models.Ticket{}).Where("event_id = ? AND status = ?", eventID, "canceled").Count(&canceledTickets)
+	database.DB.Model(&models.Ticket{}).Where("event_id = ? AND status = ?", eventID, "expired").Count(&expiredTickets)
 
 	database.DB.Model(&models.EventRegistration{}).Where("event_id = ?", eventID).Count(&totalRegistrations)
 	database.DB.Model(&models.EventRegistration{}).Where("event_id = ? AND status = ?", eventID, "attended").Count(&attendedCount)
@@ -99,6 +130,7 @@ func GetEventStatistics(c *gin.Context) {
 		"total_tickets":      totalTickets,
 		"active_tickets":     activeTickets,
 		"canceled_tickets":   canceledTickets,
+		"expired_tickets":    expiredTickets,
 		"total_registrations": totalRegistrations,
 		"attended_count":     attendedCount,
 		"attendance_rate":    attendanceRate,
@@ -106,3 +138,98 @@ func GetEventStatistics(c *gin.Context) {
 
 	c.JSON(200, statistics)
 }
+
+// SessionStatistics - статистика по одной сессии события
+type SessionStatistics struct {
+	SessionID       uint    `json:"session_id"`
+	Title           string  `json:"title"`
+	TrackID         *uint   `json:"track_id"`
+	Capacity        *int    `json:"capacity"`
+	RegisteredCount int64   `json:"registered_count"`
+	AttendedCount   int64   `json:"attended_count"`
+	FillRate        float64 `json:"fill_rate"`
+	AttendanceRate  float64 `json:"attendance_rate"`
+}
+
+// @Summary Статистика по сессиям события
+// @Description Возвращает число регистраций и посещений по каждой сессии события
+// @Tags Dashboard
+// @Produce json
+// @Param id path int true "ID события"
+// @Success 200 {array} SessionStatistics
+// @Router /dashboard/events/{id}/sessions/statistics [get]
+func GetEventSessionStatistics(c *gin.Context) {
+	eventID := c.Param("id")
+
+	var statistics []SessionStatistics
+
+	result := database.DB.Table("sessions").
+		Select(`sessions.id AS session_id, sessions.title, sessions.track_id, sessions.capacity,
+			(SELECT COUNT(*) FROM session_registrations sr
+				WHERE sr.session_id = sessions.id AND sr.status = 'registered') AS registered_count,
+			(SELECT COUNT(*) FROM session_attendances sa
+				WHERE sa.session_id = sessions.id) AS attended_count`).
+		Where("sessions.event_id = ?", eventID).
+		Order("sessions.start_time ASC, sessions.id ASC").
+		Scan(&statistics)
+	if result.Error != nil {
+		c.JSON(500, gin.H{"error": "Database error"})
+		return
+	}
+
+	for i := range statistics {
+		s := &statistics[i]
+		if s.Capacity != nil && *s.Capacity > 0 {
+			s.FillRate = (float64(s.RegisteredCount) / float64(*s.Capacity)) * 100
+		}
+		if s.RegisteredCount > 0 {
+			s.AttendanceRate = (float64(s.AttendedCount) / float64(s.RegisteredCount)) * 100
+		}
+	}
+
+	c.JSON(200, statistics)
+}
+
+// PromoCodeReport - сводка погашений по одному промокоду
+type PromoCodeReport struct {
+	PromoCodeID        uint   `json:"promo_code_id"`
+	Code               string `json:"code"`
+	MaxRedemptions     *int   `json:"max_redemptions"`
+	Redemptions        int64  `json:"redemptions"`
+	UniqueParticipants int64  `json:"unique_participants"`
+	TotalDiscount      int64  `json:"total_discount"`
+}
+
+// @Summary Отчет по промокодам
+// @Description Возвращает число погашений, уникальных участников и сумму скидок по каждому промокоду
+// @Tags Dashboard
+// @Produce json
+// @Param event_id query int false "Только погашения на событии"
+// @Success 200 {array} PromoCodeReport
+// @Router /dashboard/promo-codes [get]
+func GetPromoCodeReport(c *gin.Context) {
+	var report []PromoCodeReport
+
+	redemptionsJoin := "LEFT JOIN promo_redemptions ON promo_redemptions.promo_code_id = promo_codes.id"
+	var joinArgs []interface{}
+	if eventID := c.Query("event_id"); eventID != "" {
+		redemptionsJoin += " AND promo_redemptions.event_id = ?"
+		joinArgs = append(joinArgs, eventID)
+	}
+
+	result := database.DB.Table("promo_codes").
+		Select(`promo_codes.id AS promo_code_id, promo_codes.code, promo_codes.max_redemptions,
+			COUNT(promo_redemptions.id) AS redemptions,
+			COUNT(DISTINCT promo_redemptions.participant_id) AS unique_participants,
+			COALESCE(SUM(promo_redemptions.discount_amount), 0) AS total_discount`).
+		Joins(redemptionsJoin, joinArgs...).
+		Group("promo_codes.id, promo_codes.code, promo_codes.max_redemptions").
+		Order("redemptions DESC, promo_codes.id ASC").
+		Scan(&report)
+	if result.Error != nil {
+		c.JSON(500, gin.H{"error": "Database error"})
+		return
+	}
+
+	c.JSON(200, report)
+}
@@ -11,6 +11,7 @@ import (
 
 	"github.com/gin-gonic/gin"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 func GetEventById(c *gin.Context) {
@@ -46,9 +47,15 @@ func GetEventById(c *gin.Context) {
 // @Param sort query string false "Сортировка [field, order]" example(["id", "ASC"])
 // @Param filter query string false "Фильтрация {field: value}" example({"category_id": 1})
 // @Param category_id query int false "Фильтр по категории"
-// @Param start_date query string false "Фильтр по дате начала (>= start_date)"
-// @Param end_date query string false "Фильтр по дате окончания (<= end_date)"
+// @Param start_date query string false "Фильтр по дате начала (>= start_date), RFC 3339 или YYYY-MM-DD"
+// @Param end_date query string false "Фильтр по дате окончания (<= end_date), RFC 3339 или YYYY-MM-DD"
+// @Param tz query string false "Часовой пояс IANA для дат без смещения" default(UTC)
 // @Param publish_status query string false "Фильтр по статусу публикации (published/draft)"
+// @Param series_id query int false "Фильтр по серии повторяющихся событий"
+// @Param venue_id query int false "Фильтр по площадке"
+// @Param near_lat query number false "Широта точки для поиска событий поблизости"
+// @Param near_lng query number false "Долгота точки для поиска событий поблизости"
+// @Param radius_km query number false "Радиус поиска в километрах" default(10)
 // @Success 200 {array} models.Event "Список событий"
 // @Header 200 {string} X-Total-Count "Общее количество записей"
 // @Header 200 {string} Content-Range "Диапазон записей"
@@ -87,14 +94,37 @@ func GetEvents(c *gin.Context) {
 		query = query.Where("category_id = ?", categoryID)
 	}
 
-	startDate := c.Query("start_date")
-	if startDate != "" {
-		query = query.Where("start_time >= ?", startDate)
+	from, to, ok := timeRangeFilter(c)
+	if !ok {
+		return
+	}
+	if from != nil {
+		query = query.Where("start_time >= ?", *from)
+	}
+	if to != nil {
+		query = query.Where("end_time < ?", *to)
+	}
+
+	venueID := c.Query("venue_id")
+	if venueID != "" {
+		query = query.Where("venue_id = ?", venueID)
+	}
+
+	nearLat, nearLng := c.Query("near_lat"), c.Query("near_lng")
+	if nearLat != "" || nearLng != "" {
+		lat, latErr := strconv.ParseFloat(nearLat, 64)
+		lng, lngErr := strconv.ParseFloat(nearLng, 64)
+		radius, radiusErr := strconv.ParseFloat(c.DefaultQuery("radius_km", "10"), 64)
+		if latErr != nil || lngErr != nil || radiusErr != nil || radius <= 0 {
+			c.JSON(400, gin.H{"error": "near_lat, near_lng and radius_km must be valid numbers"})
+			return
+		}
+		query = query.Where("venue_id IN (?)", venuesWithinRadius(lat, lng, radius))
 	}
 
-	endDate := c.Query("end_date")
-	if endDate != "" {
-		query = query.Where("end_time <= ?", endDate)
+	seriesID := c.Query("series_id")
+	if seriesID != "" {
+		query = query.Where("series_id = ?", seriesID)
 	}
 
 	publishStatus := c.Query("publish_status")
@@ -125,9 +155,28 @@ func GetEvents(c *gin.Context) {
 	c.JSON(200, events)
 }
 
+// @Summary Обновить событие
+// @Description Обновляет событие. Для вхождений серии scope задает область изменения: this - только это вхождение, following - это и последующие, series - вся серия
+// @Tags Events
+// @Accept json
+// @Produce json
+// @Param id path int true "ID события"
+// @Param scope query string false "Область изменения (this/following/series)" default(this)
+// @Param event body models.CreateEventRequest true "Данные события"
+// @Success 200 {object} models.Event
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /events/{id} [put]
 func UpdateEvent(c *gin.Context) {
 	id := c.Param("id")
 
+	scope := c.DefaultQuery("scope", "this")
+	if scope != "this" && scope != "following" && scope != "series" {
+		c.JSON(400, gin.H{"error": "Scope must be one of 'this', 'following' or 'series'"})
+		return
+	}
+
 	var input models.CreateEventRequest
 	if err := c.ShouldBindJSON(&input); err != nil {
 		c.JSON(400, gin.H{"error": err.Error()})
@@ -139,27 +188,156 @@ func UpdateEvent(c *gin.Context) {
 		return
 	}
 
+	if input.EndTime.Before(input.StartTime) {
+		c.JSON(400, gin.H{"error": "End time must not be before start time"})
+		return
+	}
+
+	if input.PublishAt != nil && input.UnpublishAt != nil && !input.UnpublishAt.After(*input.PublishAt) {
+		c.JSON(400, gin.H{"error": "Unpublish time must be after publish time"})
+		return
+	}
+
+	if input.EntryPolicy == "limited" && input.MaxEntries == nil {
+		c.JSON(400, gin.H{"error": "max_entries is required for the 'limited' entry policy"})
+		return
+	}
+
+	capacity, timezone, err := venueDefaults(database.DB, input.VenueID, input.Capacity, input.Timezone)
+	if err != nil {
+		respondVenueDefaultsError(c, err)
+		return
+	}
+	input.Capacity = capacity
+	input.Timezone = timezone
+
 	var event models.Event
 
-	result := database.DB.Model(&event).Where("id = ?", id).Updates(models.Event{
-		Title:         input.Title,
-		Description:   input.Description,
-		StartTime:     input.StartTime,
-		EndTime:       input.EndTime,
-		EventType:     input.EventType,
-		Status:        input.Status,
-		PublishStatus: input.PublishStatus,
-		CategoryID:    input.CategoryID,
+	if scope != "this" {
+		updateEventSeriesScope(c, id, scope, input)
+		return
+	}
+
+	err = database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&event, id).Error; err != nil {
+			return err
+		}
+
+		previousStatus := event.Status
+		previousPublishStatus := event.PublishStatus
+		capacityChanged := !sameCapacity(event.Capacity, input.Capacity)
+		if input.Status != "" && input.Status != previousStatus {
+			if err := checkEventTransition(previousStatus, input.Status); err != nil {
+				return err
+			}
+		}
+
+		err := tx.Model(&event).Updates(models.Event{
+			Title:            input.Title,
+			Description:      input.Description,
+			StartTime:        input.StartTime.UTC(),
+			EndTime:          input.EndTime.UTC(),
+			Timezone:         input.Timezone,
+			EventType:        input.EventType,
+			PublishStatus:    input.PublishStatus,
+			CategoryID:       input.CategoryID,
+			RefundPolicy:     input.RefundPolicy,
+			EntryPolicy:      input.EntryPolicy,
+			TransferPolicy:   input.TransferPolicy,
+			RegistrationMode: input.RegistrationMode,
+		}).Error
+		if err != nil {
+			return err
+		}
+
+		// Плановые даты публикации, сроки, лимиты, вместимость и площадку можно и сбросить,
+		// поэтому они обновляются явно
+		err = tx.Model(&event).Updates(map[string]interface{}{
+			"capacity":              input.Capacity,
+			"venue_id":              input.VenueID,
+			"publish_at":            utcOrNil(input.PublishAt),
+			"unpublish_at":          utcOrNil(input.UnpublishAt),
+			"refund_cutoff_hours":   input.RefundCutoffHours,
+			"max_entries":           input.MaxEntries,
+			"transfer_cutoff_hours": input.TransferCutoffHours,
+		}).Error
+		if err != nil {
+			return err
+		}
+
+		if input.PublishStatus != previousPublishStatus {
+			if err := recordPublication(tx, c, event.ID, previousPublishStatus, input.PublishStatus); err != nil {
+				return err
+			}
+		}
+
+		// Вхождение серии, измененное отдельно, больше не перезаписывается серией
+		if event.SeriesID != nil {
+			if err := tx.Model(&event).Update("is_exception", true).Error; err != nil {
+				return err
+			}
+		}
+
+		// Смена статуса проходит через жизненный цикл вместе с побочными эффектами
+		if input.Status != "" && input.Status != previousStatus {
+			event.Status = previousStatus
+			if err := transitionEvent(tx, &event, input.Status, models.EventTransitionRequest{}); err != nil {
+				return err
+			}
+		}
+
+		// Увеличение или снятие вместимости может освободить места для листа ожидания
+		if capacityChanged {
+			_, err = promoteWaitlist(tx, event.ID)
+		}
+		return err
 	})
 
-	if result.Error != nil {
-		log.Printf("Database Error (Update): %v", result.Error)
+	if err != nil {
+		if respondTransitionError(c, err) {
+			return
+		}
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(404, gin.H{"error": "Event not found."})
+			return
+		}
+		log.Printf("Database Error (Update): %v", err)
 		c.JSON(500, gin.H{"error": "Failed to update Event. Database error."})
 		return
 	}
 
-	if result.RowsAffected == 0 {
-		c.JSON(404, gin.H{"error": "Event not found."})
+	database.DB.First(&event, id)
+
+	c.JSON(200, event)
+}
+
+func updateEventSeriesScope(c *gin.Context, id string, scope string, input models.CreateEventRequest) {
+	var event models.Event
+
+	result := database.DB.First(&event, id)
+	if result.Error != nil {
+		if errors.Is(result.Error, gorm.ErrRecordNotFound) {
+			c.JSON(404, gin.H{"error": "Event not found."})
+		} else {
+			c.JSON(500, gin.H{"error": "Database error"})
+		}
+		return
+	}
+
+	if event.SeriesID == nil {
+		c.JSON(400, gin.H{"error": "Event is not part of a series, use scope 'this'"})
+		return
+	}
+
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		if scope == "following" {
+			return updateFollowingOccurrences(tx, &event, input)
+		}
+		return updateWholeSeries(tx, &event, input)
+	})
+	if err != nil {
+		log.Printf("Database Error (Update): %v", err)
+		c.JSON(500, gin.H{"error": "Failed to update event series. Database error."})
 		return
 	}
 
@@ -171,10 +349,29 @@ func UpdateEvent(c *gin.Context) {
 func DeleteEvent(c *gin.Context) {
 	id := c.Param("id")
 
-	result := database.DB.Delete(&models.Event{}, id)
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		var event models.Event
+		if err := tx.First(&event, id).Error; err != nil {
+			return err
+		}
 
-	if result.Error != nil {
-		log.Printf("Database Error (Delete): %v", result.Error)
+		// Удаленное вхождение исключается из серии, чтобы не появиться снова при синхронизации
+		if event.SeriesID != nil && event.RecurrenceID != nil {
+			var series models.EventSeries
+			if err := tx.First(&series, *event.SeriesID).Error; err != nil {
+				return err
+			}
+			series.ExDates = append(series.ExDates, *event.RecurrenceID)
+			if err := tx.Save(&series).Error; err != nil {
+				return err
+			}
+		}
+
+		return tx.Delete(&event).Error
+	})
+
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		log.Printf("Database Error (Delete): %v", err)
 		c.JSON(500, gin.H{"error": "Failed to delete event. Database error."})
 		return
 	}
@@ -205,20 +402,60 @@ func PostEvent(c *gin.Context) {
 		return
 	}
 
+	if newPostEvent.EndTime.Before(newPostEvent.StartTime) {
+		c.JSON(400, gin.H{"error": "End time must not be before start time"})
+		return
+	}
+
+	if newPostEvent.PublishAt != nil && newPostEvent.UnpublishAt != nil && !newPostEvent.UnpublishAt.After(*newPostEvent.PublishAt) {
+		c.JSON(400, gin.H{"error": "Unpublish time must be after publish time"})
+		return
+	}
+
+	if newPostEvent.EntryPolicy == "limited" && newPostEvent.MaxEntries == nil {
+		c.JSON(400, gin.H{"error": "max_entries is required for the 'limited' entry policy"})
+		return
+	}
+
+	capacity, timezone, err := venueDefaults(database.DB, newPostEvent.VenueID, newPostEvent.Capacity, newPostEvent.Timezone)
+	if err != nil {
+		respondVenueDefaultsError(c, err)
+		return
+	}
+	newPostEvent.Capacity = capacity
+	newPostEvent.Timezone = timezone
+
 	status := newPostEvent.Status
 	if status == "" {
 		status = "scheduled"
 	}
 
+	if !isInitialEventStatus(status) {
+		c.JSON(400, gin.H{"error": "Status of a new event must be either 'draft' or 'scheduled'"})
+		return
+	}
+
 	Event := models.Event{
-		Title:         newPostEvent.Title,
-		Description:   newPostEvent.Description,
-		StartTime:     newPostEvent.StartTime,
-		EndTime:       newPostEvent.EndTime,
-		EventType:     newPostEvent.EventType,
-		Status:        status,
-		PublishStatus: newPostEvent.PublishStatus,
-		CategoryID:    newPostEvent.CategoryID,
+		Title:               newPostEvent.Title,
+		Description:         newPostEvent.Description,
+		StartTime:           newPostEvent.StartTime.UTC(),
+		EndTime:             newPostEvent.EndTime.UTC(),
+		Timezone:            newPostEvent.Timezone,
+		PublishAt:           utcOrNil(newPostEvent.PublishAt),
+		UnpublishAt:         utcOrNil(newPostEvent.UnpublishAt),
+		EventType:           newPostEvent.EventType,
+		Status:              status,
+		PublishStatus:       newPostEvent.PublishStatus,
+		CategoryID:          newPostEvent.CategoryID,
+		Capacity:            newPostEvent.Capacity,
+		VenueID:             newPostEvent.VenueID,
+		RefundPolicy:        newPostEvent.RefundPolicy,
+		RefundCutoffHours:   newPostEvent.RefundCutoffHours,
+		EntryPolicy:         newPostEvent.EntryPolicy,
+		MaxEntries:          newPostEvent.MaxEntries,
+		TransferPolicy:      newPostEvent.TransferPolicy,
+		TransferCutoffHours: newPostEvent.TransferCutoffHours,
+		RegistrationMode:    newPostEvent.RegistrationMode,
 	}
 
 	result := database.DB.Create(&Event)
@@ -0,0 +1,262 @@
+package handlers
+
+import (
+	"errors"
+	"eventflow/internal/database"
+	"eventflow/internal/models"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Допустимые переходы жизненного цикла события (Event.Status)
+var eventTransitions = map[string][]string{
+	"draft":     {"scheduled", "canceled"},
+	"scheduled": {"live", "postponed", "canceled"},
+	"postponed": {"scheduled", "canceled"},
+	"live":      {"completed", "canceled"},
+	"completed": {},
+	"canceled":  {},
+}
+
+// Статусы, с которых может начинаться жизненный цикл нового события
+var initialEventStatuses = []string{"draft", "scheduled"}
+
+// Статусы, при которых на событие нельзя зарегистрироваться
+var closedEventStatuses = []string{"completed", "canceled"}
+
+// transitionError описывает недопустимый переход; Reason - машиночитаемая причина
+type transitionError struct {
+	Reason string
+	From   string
+	To     string
+}
+
+func (e *transitionError) Error() string {
+	return fmt.Sprintf("cannot change event status from '%s' to '%s'", e.From, e.To)
+}
+
+func isValidEventStatus(status string) bool {
+	_, ok := eventTransitions[status]
+	return ok
+}
+
+func isInitialEventStatus(status string) bool {
+	return containsString(initialEventStatuses, status)
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+func checkEventTransition(from, to string) error {
+	if !isValidEventStatus(to) {
+		return &transitionError{Reason: "unknown_status", From: from, To: to}
+	}
+	if from == to {
+		return &transitionError{Reason: "already_in_status", From: from, To: to}
+	}
+	if !containsString(eventTransitions[from], to) {
+		return &transitionError{Reason: "invalid_transition", From: from, To: to}
+	}
+	return nil
+}
+
+// transitionEvent переводит событие в новый статус и выполняет побочные эффекты перехода.
+// Событие должно быть заблокировано в транзакции tx (lockEvent).
+func transitionEvent(tx *gorm.DB, event *models.Event, to string, input models.EventTransitionRequest) error {
+	if err := checkEventTransition(event.Status, to); err != nil {
+		return err
+	}
+
+	updates := map[string]interface{}{
+		"status":            to,
+		"status_reason":     input.Reason,
+		"status_changed_at": time.Now().UTC(),
+	}
+
+	// Новые даты можно указать при переносе или повторном назначении события
+	if input.StartTime != nil || input.EndTime != nil {
+		start, end := event.StartTime, event.EndTime
+		if input.StartTime != nil {
+			start = *input.StartTime
+		}
+		if input.EndTime != nil {
+			end = *input.EndTime
+		}
+		if end.Before(start) {
+			return &transitionError{Reason: "invalid_time_range", From: event.Status, To: to}
+		}
+		updates["start_time"] = start.UTC()
+		updates["end_time"] = end.UTC()
+		if event.SeriesID != nil {
+			updates["is_exception"] = true
+		}
+	}
+
+	if err := tx.Model(event).Updates(updates).Error; err != nil {
+		return err
+	}
+
+	switch to {
+	case "canceled":
+		return cancelEventRegistrations(tx, event.ID)
+	case "completed":
+		// Лист ожидания и нерассмотренные заявки закрываются: места на прошедшем событии
+		// уже не освободятся
+		return tx.Model(&models.EventRegistration{}).
+			Where("event_id = ? AND status IN ?", event.ID, []string{"waitlisted", "pending"}).
+			Update("status", "canceled").Error
+	}
+
+	return nil
+}
+
+// cancelEventRegistrations отменяет все активные тикеты, регистрации и заявки события
+func cancelEventRegistrations(tx *gorm.DB, eventID uint) error {
+	if err := cancelTickets(tx, "event_id = ?", eventID); err != nil {
+		return err
+	}
+
+	return tx.Model(&models.EventRegistration{}).
+		Where("event_id = ? AND status IN ?", eventID, []string{"registered", "waitlisted", "pending"}).
+		Update("status", "canceled").Error
+}
+
+func respondTransitionError(c *gin.Context, err error) bool {
+	var transitionErr *transitionError
+	if !errors.As(err, &transitionErr) {
+		return false
+	}
+
+	c.JSON(409, gin.H{
+		"error":   transitionErr.Error(),
+		"reason":  transitionErr.Reason,
+		"from":    transitionErr.From,
+		"to":      transitionErr.To,
+		"allowed": eventTransitions[transitionErr.From],
+	})
+	return true
+}
+
+func changeEventStatus(c *gin.Context, to string) {
+	id := c.Param("id")
+
+	var input models.EventTransitionRequest
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&input); err != nil {
+			c.JSON(400, gin.H{"error": err.Error()})
+			return
+		}
+	}
+
+	var event models.Event
+
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&event, id).Error; err != nil {
+			return err
+		}
+		return transitionEvent(tx, &event, to, input)
+	})
+
+	if err != nil {
+		if respondTransitionError(c, err) {
+			return
+		}
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(404, gin.H{"error": "Event not found"})
+			return
+		}
+		log.Printf("Database Error (Transition): %v", err)
+		c.JSON(500, gin.H{"error": "Failed to change event status. Database error."})
+		return
+	}
+
+	log.Printf("Event %d status changed to '%s'", event.ID, to)
+
+	database.DB.First(&event, id)
+
+	c.JSON(200, event)
+}
+
+// @Summary Назначить событие
+// @Description Переводит событие в статус scheduled (из draft или postponed). Можно указать новые даты
+// @Tags Events
+// @Accept json
+// @Produce json
+// @Param id path int true "ID события"
+// @Param transition body models.EventTransitionRequest false "Причина и новые даты"
+// @Success 200 {object} models.Event
+// @Failure 404 {object} map[string]string
+// @Failure 409 {object} map[string]interface{} "Недопустимый переход"
+// @Router /events/{id}/schedule [post]
+func ScheduleEvent(c *gin.Context) {
+	changeEventStatus(c, "scheduled")
+}
+
+// @Summary Начать событие
+// @Description Переводит событие в статус live
+// @Tags Events
+// @Accept json
+// @Produce json
+// @Param id path int true "ID события"
+// @Success 200 {object} models.Event
+// @Failure 404 {object} map[string]string
+// @Failure 409 {object} map[string]interface{} "Недопустимый переход"
+// @Router /events/{id}/start [post]
+func StartEvent(c *gin.Context) {
+	changeEventStatus(c, "live")
+}
+
+// @Summary Завершить событие
+// @Description Переводит событие в статус completed, закрывает лист ожидания и нерассмотренные заявки
+// @Tags Events
+// @Accept json
+// @Produce json
+// @Param id path int true "ID события"
+// @Success 200 {object} models.Event
+// @Failure 404 {object} map[string]string
+// @Failure 409 {object} map[string]interface{} "Недопустимый переход"
+// @Router /events/{id}/complete [post]
+func CompleteEvent(c *gin.Context) {
+	changeEventStatus(c, "completed")
+}
+
+// @Summary Отменить событие
+// @Description Переводит событие в статус canceled, отменяет все активные тикеты и регистрации
+// @Tags Events
+// @Accept json
+// @Produce json
+// @Param id path int true "ID события"
+// @Param transition body models.EventTransitionRequest false "Причина отмены"
+// @Success 200 {object} models.Event
+// @Failure 404 {object} map[string]string
+// @Failure 409 {object} map[string]interface{} "Недопустимый переход"
+// @Router /events/{id}/cancel [post]
+func CancelEvent(c *gin.Context) {
+	changeEventStatus(c, "canceled")
+}
+
+// @Summary Перенести событие
+// @Description Переводит событие в статус postponed. Тикеты и регистрации сохраняются
+// @Tags Events
+// @Accept json
+// @Produce json
+// @Param id path int true "ID события"
+// @Param transition body models.EventTransitionRequest false "Причина и новые даты"
+// @Success 200 {object} models.Event
+// @Failure 404 {object} map[string]string
+// @Failure 409 {object} map[string]interface{} "Недопустимый переход"
+// @Router /events/{id}/postpone [post]
+func PostponeEvent(c *gin.Context) {
+	changeEventStatus(c, "postponed")
+}
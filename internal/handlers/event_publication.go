@@ -0,0 +1,63 @@
+package handlers
+
+import (
+	"errors"
+	"eventflow/internal/database"
+	"eventflow/internal/models"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// recordPublication сохраняет ручное изменение статуса публикации события.
+// Организатор берется из контекста, если запрос прошел через AuthMiddleware.
+func recordPublication(tx *gorm.DB, c *gin.Context, eventID uint, from, to string) error {
+	publication := models.EventPublication{
+		EventID:    eventID,
+		FromStatus: from,
+		ToStatus:   to,
+		Source:     "manual",
+	}
+
+	if organizerID, ok := currentOrganizerID(c); ok {
+		publication.OrganizerID = &organizerID
+	}
+
+	return tx.Create(&publication).Error
+}
+
+// @Summary История публикации события
+// @Description Возвращает изменения статуса публикации события (вручную и планировщиком)
+// @Tags Events
+// @Accept json
+// @Produce json
+// @Param id path int true "ID события"
+// @Success 200 {array} models.EventPublication
+// @Failure 404 {object} map[string]string
+// @Router /events/{id}/publications [get]
+func GetEventPublications(c *gin.Context) {
+	id := c.Param("id")
+
+	var event models.Event
+	result := database.DB.First(&event, id)
+	if result.Error != nil {
+		if errors.Is(result.Error, gorm.ErrRecordNotFound) {
+			c.JSON(404, gin.H{"error": "Event not found"})
+		} else {
+			c.JSON(500, gin.H{"error": "Database error"})
+		}
+		return
+	}
+
+	var publications []models.EventPublication
+	result = database.DB.
+		Where("event_id = ?", event.ID).
+		Order("created_at ASC, id ASC").
+		Find(&publications)
+	if result.Error != nil {
+		c.JSON(500, gin.H{"error": "Database error"})
+		return
+	}
+
+	c.JSON(200, publications)
+}
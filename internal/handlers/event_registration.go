@@ -12,6 +12,7 @@ import (
 
 	"github.com/gin-gonic/gin"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 func GetEventRegistrationById(c *gin.Context) {
@@ -35,6 +36,11 @@ func GetEventRegistrationById(c *gin.Context) {
 		return
 	}
 
+	if err := fillWaitlistPosition(database.DB, &eventRegistration); err != nil {
+		c.JSON(500, gin.H{"error": "Database error"})
+		return
+	}
+
 	c.JSON(200, eventRegistration)
 }
 
@@ -45,6 +51,8 @@ func GetEventRegistrationById(c *gin.Context) {
 // @Produce json
 // @Param range query string false "Пагинация [start, end]"
 // @Param sort query string false "Сортировка [field, order]"
+// @Param event_id query int false "ID события"
+// @Param status query string false "Фильтр по статусу, например pending"
 // @Success 200 {array} models.EventRegistration
 // @Header 200 {string} X-Total-Count "Общее количество записей"
 // @Header 200 {string} Content-Range "Диапазон записей"
@@ -76,7 +84,16 @@ func GetEventRegistrations(c *gin.Context) {
 	limit := end - start + 1
 	offset := start
 
-	countResult := database.DB.Model(&models.EventRegistration{}).Count(&total)
+	query := database.DB.Model(&models.EventRegistration{})
+
+	if eventID := c.Query("event_id"); eventID != "" {
+		query = query.Where("event_id = ?", eventID)
+	}
+	if status := c.Query("status"); status != "" {
+		query = query.Where("status = ?", status)
+	}
+
+	countResult := query.Count(&total)
 	if countResult.Error != nil {
 		c.JSON(500, gin.H{"error": "Failed to retrieve total record count"})
 		return
@@ -84,7 +101,7 @@ func GetEventRegistrations(c *gin.Context) {
 
 	contentRange := fmt.Sprintf("event_registrations %d-%d/%d", start, end, total)
 
-	result := database.DB.
+	result := query.
 		Limit(limit).
 		Offset(offset).
 		Order(sortField + " " + sortOrder).
@@ -94,6 +111,13 @@ func GetEventRegistrations(c *gin.Context) {
 		return
 	}
 
+	for i := range eventRegistrations {
+		if err := fillWaitlistPosition(database.DB, &eventRegistrations[i]); err != nil {
+			c.JSON(500, gin.H{"error": "Database error"})
+			return
+		}
+	}
+
 	c.Header("Content-Range", contentRange)
 	c.Header("X-Total-Count", strconv.Itoa(int(total)))
 	c.JSON(200, eventRegistrations)
@@ -110,35 +134,96 @@ func UpdateEventRegistration(c *gin.Context) {
 
 	var eventRegistration models.EventRegistration
 
-	result := database.DB.Model(&eventRegistration).Where("id = ?", id).Updates(models.EventRegistration{
-		EventID:       input.EventID,
-		ParticipantID: input.ParticipantID,
-		Status:        input.Status,
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.First(&eventRegistration, id).Error; err != nil {
+			return err
+		}
+
+		var event models.Event
+		if err := lockEvent(tx, eventRegistration.EventID, &event); err != nil {
+			return err
+		}
+
+		// Регистрацию нельзя перенести на другое событие или участника: места и тикеты
+		// привязаны к паре событие-участник. Нужно отменить регистрацию и создать новую
+		if input.EventID != eventRegistration.EventID || input.ParticipantID != eventRegistration.ParticipantID {
+			return errRegistrationImmutable
+		}
+
+		previous := eventRegistration
+
+		// Смена статуса проходит через допустимые переходы вместе с побочными эффектами
+		if input.Status != previous.Status {
+			var reviewerID *uint
+			if organizerID, ok := currentOrganizerID(c); ok {
+				reviewerID = &organizerID
+			}
+			if _, err := transitionRegistration(tx, &event, &eventRegistration, input.Status, reviewerID, ""); err != nil {
+				return err
+			}
+		}
+
+		_, err := promoteWaitlist(tx, previous.EventID)
+		return err
 	})
 
-	if result.Error != nil {
-		log.Printf("Database Error (Update): %v", result.Error)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(404, gin.H{"error": "Event registration not found."})
+			return
+		}
+		if errors.Is(err, errRegistrationImmutable) {
+			c.JSON(400, gin.H{"error": err.Error()})
+			return
+		}
+		if code, body := registrationErrorResponse(err); code != 500 {
+			c.JSON(code, body)
+			return
+		}
 		c.JSON(500, gin.H{"error": "Failed to update event registration. Database error."})
 		return
 	}
 
-	if result.RowsAffected == 0 {
-		c.JSON(404, gin.H{"error": "Event registration not found."})
+	database.DB.First(&eventRegistration, id)
+
+	if err := fillWaitlistPosition(database.DB, &eventRegistration); err != nil {
+		c.JSON(500, gin.H{"error": "Database error"})
 		return
 	}
 
-	database.DB.First(&eventRegistration, id)
-
 	c.JSON(200, eventRegistration)
 }
 
 func DeleteEventRegistration(c *gin.Context) {
 	id := c.Param("id")
 
-	result := database.DB.Delete(&models.EventRegistration{}, id)
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		var eventRegistration models.EventRegistration
+		if err := tx.First(&eventRegistration, id).Error; err != nil {
+			return err
+		}
 
-	if result.Error != nil {
-		log.Printf("Database Error (Delete): %v", result.Error)
+		var event models.Event
+		if err := lockEvent(tx, eventRegistration.EventID, &event); err != nil {
+			return err
+		}
+
+		// Тикеты удаленной регистрации отменяются до передачи места листу ожидания,
+		// иначе по старому тикету можно пройти на занятое другим место
+		if err := cancelParticipantTickets(tx, eventRegistration.EventID, eventRegistration.ParticipantID); err != nil {
+			return err
+		}
+
+		if err := tx.Delete(&eventRegistration).Error; err != nil {
+			return err
+		}
+
+		_, err := promoteWaitlist(tx, eventRegistration.EventID)
+		return err
+	})
+
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		log.Printf("Database Error (Delete): %v", err)
 		c.JSON(500, gin.H{"error": "Failed to delete event registration. Database error."})
 		return
 	}
@@ -146,14 +231,28 @@ func DeleteEventRegistration(c *gin.Context) {
 	c.JSON(200, gin.H{})
 }
 
+var (
+	errEventClosed           = errors.New("event is closed for registration")
+	errAlreadyRegistered     = errors.New("participant is already registered for this event")
+	errParticipantNotFound   = errors.New("participant not found")
+	errRegistrationImmutable = errors.New("event_id and participant_id of a registration cannot be changed")
+)
+
+// cancelParticipantTickets отменяет активные тикеты участника на событие
+func cancelParticipantTickets(tx *gorm.DB, eventID, participantID uint) error {
+	return cancelTickets(tx, "event_id = ? AND participant_id = ?", eventID, participantID)
+}
+
 // @Summary Зарегистрировать участника на событие
-// @Description Создает регистрацию участника на событие и автоматически создает тикет
+// @Description Создает регистрацию участника на событие и тикет в одной транзакции; выданный тикет возвращается в поле ticket. Если мест нет, регистрация попадает в лист ожидания без тикета. На событие с одобрением создается заявка "pending", тикет выдается после одобрения. Ответы проверяются по анкете события. Промокод применяется к выданному тикету
 // @Tags EventRegistrations
 // @Accept json
 // @Produce json
 // @Param registration body models.CreateEventRegistrationRequest true "Данные регистрации"
 // @Success 201 {object} models.EventRegistration
 // @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 409 {object} map[string]interface{} "Событие завершено или отменено; участник уже зарегистрирован (registration - существующая регистрация); бесплатные тикеты закончились (sold_out) или тикеты только платные (payment_required)"
 // @Failure 500 {object} map[string]string
 // @Router /event_registrations [post]
 func PostEventRegistration(c *gin.Context) {
@@ -164,36 +263,143 @@ func PostEventRegistration(c *gin.Context) {
 		return
 	}
 
-	eventRegistration := models.EventRegistration{
-		EventID:       newEventRegistration.EventID,
-		ParticipantID: newEventRegistration.ParticipantID,
-		Status:        newEventRegistration.Status,
-		RegisteredAt:  time.Now(),
-	}
-
-	result := database.DB.Create(&eventRegistration)
-	if result.Error != nil {
-		log.Printf("Database Error (Create): %v", result.Error)
-		c.JSON(500, gin.H{"error": "Failed to create event registration. Database error."})
+	if !containsString(initialRegistrationStatuses, newEventRegistration.Status) {
+		c.JSON(400, gin.H{"error": "Status of a new registration must be either 'registered' or 'pending'"})
 		return
 	}
 
-	qrCode, err := generateQRCode()
-	if err != nil {
-		log.Printf("QR Code Generation Error: %v", err)
-	} else {
-		ticket := models.Ticket{
+	var eventRegistration, existing models.EventRegistration
+
+	// Регистрация, тикет, погашение промокода и списание остатка категории
+	// создаются в одной транзакции: при любой ошибке не остается ничего
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		var event models.Event
+		if err := lockEvent(tx, newEventRegistration.EventID, &event); err != nil {
+			return err
+		}
+
+		if containsString(closedEventStatuses, event.Status) {
+			return errEventClosed
+		}
+
+		var participant models.Participant
+		if err := tx.Select("id").First(&participant, newEventRegistration.ParticipantID).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return errParticipantNotFound
+			}
+			return err
+		}
+
+		err := tx.Where("event_id = ? AND participant_id = ?", event.ID, participant.ID).First(&existing).Error
+		if err == nil {
+			return errAlreadyRegistered
+		}
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			return err
+		}
+
+		answers, err := checkRegistrationAnswers(tx, event.ID, newEventRegistration.Answers)
+		if err != nil {
+			return err
+		}
+
+		// На событие с одобрением регистрация создается заявкой без тикета
+		status := newEventRegistration.Status
+		if event.RegistrationMode == "approval" {
+			status = "pending"
+		}
+
+		if status == "registered" {
+			free, err := hasSeatBeyondWaitlist(tx, &event)
+			if err != nil {
+				return err
+			}
+			if !free {
+				status = "waitlisted"
+			}
+		}
+
+		eventRegistration = models.EventRegistration{
 			EventID:       newEventRegistration.EventID,
 			ParticipantID: newEventRegistration.ParticipantID,
-			TicketType:    "free", // По умолчанию бесплатный
-			Status:        "active",
-			QRCode:        qrCode,
+			Status:        status,
+			RegisteredAt:  time.Now(),
+			Answers:       answers,
+		}
+		// Промокод заявки применяется при одобрении
+		if status == "pending" {
+			eventRegistration.PromoCode = newEventRegistration.PromoCode
+		}
+
+		// Событие заблокировано, но уникальность пары проверяет и база
+		result := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&eventRegistration)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			if err := tx.Where("event_id = ? AND participant_id = ?", event.ID, participant.ID).First(&existing).Error; err != nil {
+				return err
+			}
+			return errAlreadyRegistered
+		}
+
+		if status == "pending" {
+			return nil
 		}
 
-		ticketResult := database.DB.Create(&ticket)
-		if ticketResult.Error != nil {
-			log.Printf("Auto-ticket creation failed: %v", ticketResult.Error)
+		// Участник в листе ожидания получит тикет при переводе в "registered"
+		if status == "waitlisted" {
+			return fillWaitlistPosition(tx, &eventRegistration)
 		}
+
+		ticket, err := issueRegistrationTicket(tx, newEventRegistration.EventID, newEventRegistration.ParticipantID)
+		if err != nil {
+			return err
+		}
+
+		if newEventRegistration.PromoCode != "" {
+			if err := redeemPromoCode(tx, newEventRegistration.PromoCode, ticket); err != nil {
+				return err
+			}
+		}
+		eventRegistration.Ticket = ticket
+		return nil
+	})
+
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(404, gin.H{"error": "Event not found"})
+			return
+		}
+		if errors.Is(err, errParticipantNotFound) {
+			c.JSON(404, gin.H{"error": "Participant not found"})
+			return
+		}
+		if errors.Is(err, errAlreadyRegistered) {
+			if err := fillWaitlistPosition(database.DB, &existing); err != nil {
+				c.JSON(500, gin.H{"error": "Database error"})
+				return
+			}
+			c.JSON(409, gin.H{
+				"error":        "Participant is already registered for this event",
+				"reason":       "already_registered",
+				"registration": existing,
+			})
+			return
+		}
+		if errors.Is(err, errEventClosed) {
+			c.JSON(409, gin.H{"error": "Event is closed for registration", "reason": "event_closed"})
+			return
+		}
+		if respondAnswersError(c, err) {
+			return
+		}
+		if respondTierError(c, err) || respondPromoError(c, err) {
+			return
+		}
+		log.Printf("Database Error (Create): %v", err)
+		c.JSON(500, gin.H{"error": "Failed to create event registration. Database error."})
+		return
 	}
 
 	c.JSON(201, eventRegistration)
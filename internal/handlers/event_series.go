@@ -0,0 +1,730 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"eventflow/internal/database"
+	"eventflow/internal/jobs"
+	"eventflow/internal/models"
+	"eventflow/internal/recurrence"
+	"fmt"
+	"log"
+	"os"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const (
+	// Максимальное число вхождений, создаваемых для одной серии
+	maxSeriesOccurrences = 500
+	// Максимальное число серий, продлеваемых за один проход синхронизации
+	seriesSyncBatchSize = 20
+)
+
+// seriesHorizon возвращает границу, до которой разворачиваются бесконечные серии.
+// Глубина задается переменной окружения SERIES_HORIZON_DAYS (по умолчанию 365 дней).
+func seriesHorizon(start time.Time) time.Time {
+	days := 365
+	if value, err := strconv.Atoi(os.Getenv("SERIES_HORIZON_DAYS")); err == nil && value > 0 {
+		days = value
+	}
+
+	from := time.Now()
+	if start.After(from) {
+		from = start
+	}
+	return from.AddDate(0, 0, days)
+}
+
+// seriesStart возвращает DTSTART серии в ее часовом поясе: правило разворачивается
+// в местном времени, поэтому вхождения сохраняют время суток при переходе на летнее время.
+func seriesStart(series *models.EventSeries) time.Time {
+	return series.StartTime.In(series.Location())
+}
+
+// seriesSlots возвращает все слоты правила серии до горизонта без учета EXDATE.
+// Слоты считаются от DTSTART без ограничения числа: по их порядковым номерам
+// вхождения переносятся при изменении правила
+func seriesSlots(series *models.EventSeries, rule *recurrence.Rule) []time.Time {
+	return rule.Expand(seriesStart(series), seriesHorizon(series.StartTime), 0, nil)
+}
+
+func seriesOccurrence(series *models.EventSeries, start time.Time) models.Event {
+	recurrenceID := start.UTC()
+	return models.Event{
+		Title:         series.Title,
+		Description:   series.Description,
+		StartTime:     start.UTC(),
+		EndTime:       start.Add(series.EndTime.Sub(series.StartTime)).UTC(),
+		Timezone:      series.Timezone,
+		EventType:     series.EventType,
+		Status:        series.Status,
+		PublishStatus: series.PublishStatus,
+		CategoryID:    series.CategoryID,
+		Capacity:      series.Capacity,
+		VenueID:       series.VenueID,
+		SeriesID:      &series.ID,
+		RecurrenceID:  &recurrenceID,
+	}
+}
+
+// syncSeriesOccurrences приводит вхождения серии в соответствие с правилом:
+// создает недостающие, обновляет неизмененные вручную и убирает лишние.
+// Вхождения сопоставляются по RecurrenceID, поэтому регистрации и тикеты
+// остаются привязанными к своим вхождениям.
+func syncSeriesOccurrences(tx *gorm.DB, series *models.EventSeries) error {
+	rule, err := recurrence.Parse(series.RRule)
+	if err != nil {
+		return err
+	}
+
+	horizon := seriesHorizon(series.StartTime)
+	dates := rule.Expand(seriesStart(series), horizon, 0, series.ExDates)
+
+	// Разворачивается не больше maxSeriesOccurrences вхождений: ближайшие будущие и, если
+	// остается место, последние прошедшие. Вхождения вне окна не создаются и не удаляются
+	now := time.Now()
+	first := sort.Search(len(dates), func(i int) bool { return !dates[i].Before(now) })
+	from := max(0, min(first, len(dates)-maxSeriesOccurrences))
+	var windowStart time.Time
+	if from > 0 {
+		windowStart = dates[from]
+	}
+	dates = dates[from:]
+	if len(dates) >= maxSeriesOccurrences {
+		dates = dates[:maxSeriesOccurrences]
+		horizon = dates[len(dates)-1]
+	}
+
+	var existing []models.Event
+	if err := tx.Where("series_id = ?", series.ID).Find(&existing).Error; err != nil {
+		return err
+	}
+
+	byRecurrence := make(map[int64]*models.Event, len(existing))
+	for i := range existing {
+		if existing[i].RecurrenceID != nil {
+			byRecurrence[existing[i].RecurrenceID.Unix()] = &existing[i]
+		}
+	}
+
+	wanted := make(map[int64]bool, len(dates))
+	for _, start := range dates {
+		wanted[start.Unix()] = true
+		occurrence := seriesOccurrence(series, start)
+
+		current, ok := byRecurrence[start.Unix()]
+		if !ok {
+			if err := tx.Create(&occurrence).Error; err != nil {
+				return err
+			}
+			continue
+		}
+
+		if current.IsException {
+			continue
+		}
+
+		capacityChanged := !sameCapacity(current.Capacity, occurrence.Capacity)
+
+		err := tx.Model(current).
+			Select("title", "description", "start_time", "end_time", "timezone", "event_type", "publish_status", "category_id", "capacity", "venue_id").
+			Updates(occurrence).Error
+		if err != nil {
+			return err
+		}
+
+		if capacityChanged {
+			if _, err := promoteWaitlist(tx, current.ID); err != nil {
+				return err
+			}
+		}
+	}
+
+	for i := range existing {
+		current := &existing[i]
+		if current.RecurrenceID != nil && (wanted[current.RecurrenceID.Unix()] ||
+			current.RecurrenceID.After(horizon) || current.RecurrenceID.Before(windowStart)) {
+			continue
+		}
+		if err := removeOccurrence(tx, current); err != nil {
+			return err
+		}
+	}
+
+	// Бесконечная серия запоминает горизонт: задача синхронизации сдвигает его со временем
+	var syncedUntil *time.Time
+	if rule.Count == 0 && rule.Until.IsZero() {
+		syncedUntil = &horizon
+	}
+	series.SyncedUntil = syncedUntil
+	return tx.Model(&models.EventSeries{}).Where("id = ?", series.ID).Update("synced_until", syncedUntil).Error
+}
+
+// StartSeriesSync запускает продление бесконечных серий: без него вхождения
+// заканчивались бы на горизонте, рассчитанном при создании или изменении серии.
+// Интервал задается переменной окружения SERIES_SYNC_INTERVAL (по умолчанию 1h).
+func StartSeriesSync() {
+	jobs.Every("series-sync", jobs.IntervalFromEnv("SERIES_SYNC_INTERVAL", time.Hour), SyncSeriesHorizons)
+}
+
+// SyncSeriesHorizons разворачивает бесконечные серии, горизонт которых отстал от
+// текущего больше чем на сутки. Серии захватываются с SKIP LOCKED, поэтому несколько
+// реплик не синхронизируют одну серию одновременно.
+func SyncSeriesHorizons() error {
+	due := seriesHorizon(time.Now()).AddDate(0, 0, -1).UTC()
+
+	var synced int
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		var series []models.EventSeries
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+			Where("synced_until IS NOT NULL AND synced_until < ?", due).
+			Order("synced_until").
+			Limit(seriesSyncBatchSize).
+			Find(&series).Error
+		if err != nil {
+			return err
+		}
+
+		for i := range series {
+			if err := syncSeriesOccurrences(tx, &series[i]); err != nil {
+				return fmt.Errorf("series %d: %w", series[i].ID, err)
+			}
+		}
+		synced = len(series)
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	if synced > 0 {
+		log.Printf("Series sync: %d series extended", synced)
+	}
+	return nil
+}
+
+func sameCapacity(a, b *int) bool {
+	if a == nil || b == nil {
+		return a == nil && b == nil
+	}
+	return *a == *b
+}
+
+// occurrenceInUseSQL - условие для events: у вхождения есть регистрации, тикеты
+// или заказы. Такое вхождение нельзя удалять: каскад по event_id удалил бы их вместе с ним.
+const occurrenceInUseSQL = "EXISTS (SELECT 1 FROM event_registrations WHERE event_registrations.event_id = events.id)" +
+	" OR EXISTS (SELECT 1 FROM tickets WHERE tickets.event_id = events.id)" +
+	" OR EXISTS (SELECT 1 FROM orders WHERE orders.event_id = events.id)"
+
+// removeOccurrence удаляет вхождение, выпавшее из правила. Если на него уже есть
+// регистрации, тикеты или заказы, вхождение отменяется и отвязывается от серии, а не удаляется.
+func removeOccurrence(tx *gorm.DB, event *models.Event) error {
+	var inUse int64
+	if err := tx.Model(&models.Event{}).Where("id = ?", event.ID).Where(occurrenceInUseSQL).Count(&inUse).Error; err != nil {
+		return err
+	}
+
+	if inUse == 0 {
+		return tx.Delete(&models.Event{}, event.ID).Error
+	}
+
+	log.Printf("Series %d: occurrence %d has registrations or tickets, detaching instead of deleting", *event.SeriesID, event.ID)
+	err := tx.Model(&models.Event{}).Where("id = ?", event.ID).Updates(map[string]interface{}{
+		"series_id":     nil,
+		"recurrence_id": nil,
+	}).Error
+	if err != nil {
+		return err
+	}
+
+	if event.Status == "canceled" || event.Status == "completed" {
+		return nil
+	}
+
+	return transitionEvent(tx, event, "canceled", models.EventTransitionRequest{
+		Reason: "Removed from the series schedule",
+	})
+}
+
+// remapRecurrenceIDs переносит вхождения серии на новые слоты правила по порядковому
+// номеру: i-е вхождение старого правила становится i-м вхождением нового.
+// Вхождения без пары получают пустой RecurrenceID и убираются при синхронизации.
+func remapRecurrenceIDs(tx *gorm.DB, seriesID uint, oldSlots, newSlots []time.Time) error {
+	mapping := make(map[int64]time.Time, len(oldSlots))
+	for i := 0; i < len(oldSlots) && i < len(newSlots); i++ {
+		mapping[oldSlots[i].Unix()] = newSlots[i]
+	}
+
+	var events []models.Event
+	if err := tx.Where("series_id = ? AND recurrence_id IS NOT NULL", seriesID).Find(&events).Error; err != nil {
+		return err
+	}
+
+	// Сначала освобождаем слоты, чтобы не нарушить уникальный индекс (series_id, recurrence_id)
+	if err := tx.Model(&models.Event{}).Where("series_id = ?", seriesID).Update("recurrence_id", nil).Error; err != nil {
+		return err
+	}
+
+	for _, event := range events {
+		newID, ok := mapping[event.RecurrenceID.Unix()]
+		if !ok {
+			continue
+		}
+		if err := tx.Model(&models.Event{}).Where("id = ?", event.ID).Update("recurrence_id", newID).Error; err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func shiftTimes(times []time.Time, delta time.Duration) []time.Time {
+	shifted := make([]time.Time, 0, len(times))
+	for _, t := range times {
+		shifted = append(shifted, t.Add(delta))
+	}
+	return shifted
+}
+
+// applyEventInputToSeries переносит поля события (кроме времени) в шаблон серии
+func applyEventInputToSeries(series *models.EventSeries, input models.CreateEventRequest) {
+	series.Title = input.Title
+	series.Description = input.Description
+	series.EventType = input.EventType
+	series.PublishStatus = input.PublishStatus
+	series.CategoryID = input.CategoryID
+	series.Capacity = input.Capacity
+	series.VenueID = input.VenueID
+	series.Timezone = input.Timezone
+	if isInitialEventStatus(input.Status) {
+		series.Status = input.Status
+	}
+}
+
+// updateWholeSeries применяет изменение вхождения ко всей серии. Сдвиг времени
+// вхождения переносится на начало серии, отдельно измененные вхождения сохраняются.
+func updateWholeSeries(tx *gorm.DB, occurrence *models.Event, input models.CreateEventRequest) error {
+	var series models.EventSeries
+	if err := tx.First(&series, *occurrence.SeriesID).Error; err != nil {
+		return err
+	}
+
+	rule, err := recurrence.Parse(series.RRule)
+	if err != nil {
+		return err
+	}
+
+	oldSlots := seriesSlots(&series, rule)
+
+	delta := input.StartTime.Sub(occurrence.StartTime)
+	series.StartTime = series.StartTime.Add(delta)
+	series.EndTime = series.StartTime.Add(input.EndTime.Sub(input.StartTime))
+	series.ExDates = shiftTimes(series.ExDates, delta)
+	applyEventInputToSeries(&series, input)
+
+	if err := tx.Save(&series).Error; err != nil {
+		return err
+	}
+
+	if delta != 0 {
+		if err := remapRecurrenceIDs(tx, series.ID, oldSlots, seriesSlots(&series, rule)); err != nil {
+			return err
+		}
+	}
+
+	// Редактируемое вхождение снова следует серии
+	if err := tx.Model(&models.Event{}).Where("id = ?", occurrence.ID).Update("is_exception", false).Error; err != nil {
+		return err
+	}
+
+	return syncSeriesOccurrences(tx, &series)
+}
+
+// updateFollowingOccurrences разделяет серию: вхождения до редактируемого остаются
+// в исходной серии, а редактируемое и последующие переходят в новую серию с изменениями.
+func updateFollowingOccurrences(tx *gorm.DB, occurrence *models.Event, input models.CreateEventRequest) error {
+	var series models.EventSeries
+	if err := tx.First(&series, *occurrence.SeriesID).Error; err != nil {
+		return err
+	}
+
+	if occurrence.RecurrenceID == nil || !occurrence.RecurrenceID.After(series.StartTime) {
+		return updateWholeSeries(tx, occurrence, input)
+	}
+
+	rule, err := recurrence.Parse(series.RRule)
+	if err != nil {
+		return err
+	}
+
+	splitAt := *occurrence.RecurrenceID
+	oldSlots := seriesSlots(&series, rule)
+	before := rule.Expand(seriesStart(&series), splitAt.Add(-time.Second), 0, nil)
+
+	headRule, tailRule := *rule, *rule
+	if rule.Count > 0 {
+		headRule.Count = len(before)
+		tailRule.Count = rule.Count - len(before)
+	} else {
+		headRule.Until = splitAt.Add(-time.Second)
+		headRule.UntilLocal = false
+	}
+
+	delta := input.StartTime.Sub(occurrence.StartTime)
+
+	tail := models.EventSeries{
+		StartTime: splitAt.Add(delta),
+		RRule:     tailRule.String(),
+		Status:    series.Status,
+	}
+	tail.EndTime = tail.StartTime.Add(input.EndTime.Sub(input.StartTime))
+	applyEventInputToSeries(&tail, input)
+
+	var headExDates, tailExDates []time.Time
+	for _, exdate := range series.ExDates {
+		if exdate.Before(splitAt) {
+			headExDates = append(headExDates, exdate)
+		} else {
+			tailExDates = append(tailExDates, exdate.Add(delta))
+		}
+	}
+	tail.ExDates = tailExDates
+
+	if err := tx.Create(&tail).Error; err != nil {
+		return err
+	}
+
+	series.RRule = headRule.String()
+	series.ExDates = headExDates
+	if err := tx.Save(&series).Error; err != nil {
+		return err
+	}
+
+	err = tx.Model(&models.Event{}).
+		Where("series_id = ? AND recurrence_id >= ?", series.ID, splitAt).
+		Update("series_id", tail.ID).Error
+	if err != nil {
+		return err
+	}
+
+	var oldTailSlots []time.Time
+	for _, slot := range oldSlots {
+		if !slot.Before(splitAt) {
+			oldTailSlots = append(oldTailSlots, slot)
+		}
+	}
+
+	if err := remapRecurrenceIDs(tx, tail.ID, oldTailSlots, seriesSlots(&tail, &tailRule)); err != nil {
+		return err
+	}
+
+	if err := tx.Model(&models.Event{}).Where("id = ?", occurrence.ID).Update("is_exception", false).Error; err != nil {
+		return err
+	}
+
+	if err := syncSeriesOccurrences(tx, &series); err != nil {
+		return err
+	}
+
+	return syncSeriesOccurrences(tx, &tail)
+}
+
+func GetEventSeriesById(c *gin.Context) {
+	id := c.Param("id")
+
+	if id == "" {
+		c.JSON(400, gin.H{"error": "ID parameter is required"})
+		return
+	}
+
+	var series models.EventSeries
+
+	result := database.DB.First(&series, id)
+
+	if result.Error != nil {
+		if errors.Is(result.Error, gorm.ErrRecordNotFound) {
+			c.JSON(404, gin.H{"error": "Event series not found"})
+		} else {
+			c.JSON(500, gin.H{"error": "Database error"})
+		}
+		return
+	}
+
+	c.JSON(200, series)
+}
+
+// @Summary Получить список серий событий
+// @Description Возвращает список повторяющихся серий событий с пагинацией
+// @Tags EventSeries
+// @Accept json
+// @Produce json
+// @Param range query string false "Пагинация [start, end]"
+// @Param sort query string false "Сортировка [field, order]"
+// @Success 200 {array} models.EventSeries
+// @Header 200 {string} X-Total-Count "Общее количество записей"
+// @Header 200 {string} Content-Range "Диапазон записей"
+// @Router /event_series [get]
+func GetEventSeries(c *gin.Context) {
+	var series []models.EventSeries
+	var total int64
+
+	rangeParam := c.Query("range")
+	var start, end int = 0, 25
+	if rangeParam != "" {
+		var rangeArray []int
+		if err := json.Unmarshal([]byte(rangeParam), &rangeArray); err == nil && len(rangeArray) == 2 {
+			start = rangeArray[0]
+			end = rangeArray[1]
+		}
+	}
+
+	sortParam := c.Query("sort")
+	var sortField, sortOrder string = "id", "ASC"
+	if sortParam != "" {
+		var sortArray []string
+		if err := json.Unmarshal([]byte(sortParam), &sortArray); err == nil && len(sortArray) == 2 {
+			sortField = sortArray[0]
+			sortOrder = sortArray[1]
+		}
+	}
+
+	limit := end - start + 1
+	offset := start
+
+	countResult := database.DB.Model(&models.EventSeries{}).Count(&total)
+	if countResult.Error != nil {
+		c.JSON(500, gin.H{"error": "Failed to retrieve total record count"})
+		return
+	}
+
+	contentRange := fmt.Sprintf("event_series %d-%d/%d", start, end, total)
+
+	result := database.DB.
+		Limit(limit).
+		Offset(offset).
+		Order(sortField + " " + sortOrder).
+		Find(&series)
+	if result.Error != nil {
+		c.JSON(500, gin.H{"error": "Database error"})
+		return
+	}
+
+	c.Header("Content-Range", contentRange)
+	c.Header("X-Total-Count", strconv.Itoa(int(total)))
+	c.JSON(200, series)
+}
+
+// @Summary Создать серию событий
+// @Description Создает повторяющуюся серию по правилу RRULE (с EXDATE) и разворачивает ее в отдельные события
+// @Tags EventSeries
+// @Accept json
+// @Produce json
+// @Param series body models.CreateEventSeriesRequest true "Данные серии"
+// @Success 201 {object} models.EventSeries
+// @Failure 400 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /event_series [post]
+func PostEventSeries(c *gin.Context) {
+	var input models.CreateEventSeriesRequest
+
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+
+	if input.PublishStatus != "draft" && input.PublishStatus != "published" {
+		c.JSON(400, gin.H{"error": "Publish status must be either 'draft' or 'published'"})
+		return
+	}
+
+	if input.EndTime.Before(input.StartTime) {
+		c.JSON(400, gin.H{"error": "End time must not be before start time"})
+		return
+	}
+
+	capacity, timezone, err := venueDefaults(database.DB, input.VenueID, input.Capacity, input.Timezone)
+	if err != nil {
+		respondVenueDefaultsError(c, err)
+		return
+	}
+	input.Capacity = capacity
+	input.Timezone = timezone
+
+	rule, err := recurrence.Parse(input.RRule)
+	if err != nil {
+		c.JSON(400, gin.H{"error": "Invalid rrule: " + err.Error()})
+		return
+	}
+
+	status := input.Status
+	if status == "" {
+		status = "scheduled"
+	}
+
+	if !isInitialEventStatus(status) {
+		c.JSON(400, gin.H{"error": "Status of a series must be either 'draft' or 'scheduled'"})
+		return
+	}
+
+	series := models.EventSeries{
+		Title:         input.Title,
+		Description:   input.Description,
+		StartTime:     input.StartTime.UTC(),
+		EndTime:       input.EndTime.UTC(),
+		Timezone:      input.Timezone,
+		RRule:         rule.String(),
+		ExDates:       input.ExDates,
+		EventType:     input.EventType,
+		Status:        status,
+		PublishStatus: input.PublishStatus,
+		CategoryID:    input.CategoryID,
+		Capacity:      input.Capacity,
+		VenueID:       input.VenueID,
+	}
+
+	err = database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&series).Error; err != nil {
+			return err
+		}
+		return syncSeriesOccurrences(tx, &series)
+	})
+	if err != nil {
+		log.Printf("Database Error (Create): %v", err)
+		c.JSON(500, gin.H{"error": "Failed to create event series. Database error."})
+		return
+	}
+
+	c.JSON(201, series)
+}
+
+// @Summary Обновить серию событий
+// @Description Изменяет всю серию, включая правило повторения и исключенные даты. Отдельно измененные вхождения сохраняются
+// @Tags EventSeries
+// @Accept json
+// @Produce json
+// @Param id path int true "ID серии"
+// @Param series body models.CreateEventSeriesRequest true "Данные серии"
+// @Success 200 {object} models.EventSeries
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /event_series/{id} [put]
+func UpdateEventSeries(c *gin.Context) {
+	id := c.Param("id")
+
+	var input models.CreateEventSeriesRequest
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+
+	if input.PublishStatus != "draft" && input.PublishStatus != "published" {
+		c.JSON(400, gin.H{"error": "Publish status must be either 'draft' or 'published'"})
+		return
+	}
+
+	if input.EndTime.Before(input.StartTime) {
+		c.JSON(400, gin.H{"error": "End time must not be before start time"})
+		return
+	}
+
+	capacity, timezone, err := venueDefaults(database.DB, input.VenueID, input.Capacity, input.Timezone)
+	if err != nil {
+		respondVenueDefaultsError(c, err)
+		return
+	}
+	input.Capacity = capacity
+	input.Timezone = timezone
+
+	rule, err := recurrence.Parse(input.RRule)
+	if err != nil {
+		c.JSON(400, gin.H{"error": "Invalid rrule: " + err.Error()})
+		return
+	}
+
+	var series models.EventSeries
+
+	err = database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.First(&series, id).Error; err != nil {
+			return err
+		}
+
+		oldRule, err := recurrence.Parse(series.RRule)
+		if err != nil {
+			return err
+		}
+		oldSlots := seriesSlots(&series, oldRule)
+		startMoved := !series.StartTime.Equal(input.StartTime)
+
+		series.Title = input.Title
+		series.Description = input.Description
+		series.StartTime = input.StartTime.UTC()
+		series.EndTime = input.EndTime.UTC()
+		series.Timezone = input.Timezone
+		series.RRule = rule.String()
+		series.ExDates = input.ExDates
+		series.EventType = input.EventType
+		series.PublishStatus = input.PublishStatus
+		series.CategoryID = input.CategoryID
+		series.Capacity = input.Capacity
+		series.VenueID = input.VenueID
+		if isInitialEventStatus(input.Status) {
+			series.Status = input.Status
+		}
+
+		if err := tx.Save(&series).Error; err != nil {
+			return err
+		}
+
+		// Если изменилось только начало серии, вхождения сдвигаются вместе с ней.
+		// При смене правила вхождения сопоставляются по датам.
+		if startMoved && oldRule.String() == rule.String() {
+			if err := remapRecurrenceIDs(tx, series.ID, oldSlots, seriesSlots(&series, rule)); err != nil {
+				return err
+			}
+		}
+
+		return syncSeriesOccurrences(tx, &series)
+	})
+
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(404, gin.H{"error": "Event series not found."})
+			return
+		}
+		log.Printf("Database Error (Update): %v", err)
+		c.JSON(500, gin.H{"error": "Failed to update event series. Database error."})
+		return
+	}
+
+	c.JSON(200, series)
+}
+
+func DeleteEventSeries(c *gin.Context) {
+	id := c.Param("id")
+
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		// Вхождения с регистрациями, тикетами или заказами остаются отдельными
+		// событиями (series_id обнуляется по FK)
+		err := tx.Where("series_id = ? AND NOT ("+occurrenceInUseSQL+")", id).
+			Delete(&models.Event{}).Error
+		if err != nil {
+			return err
+		}
+		return tx.Delete(&models.EventSeries{}, id).Error
+	})
+
+	if err != nil {
+		log.Printf("Database Error (Delete): %v", err)
+		c.JSON(500, gin.H{"error": "Failed to delete event series. Database error."})
+		return
+	}
+
+	c.JSON(200, gin.H{})
+}
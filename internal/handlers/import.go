@@ -0,0 +1,1028 @@
+package handlers
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"eventflow/internal/database"
+	"eventflow/internal/jobs"
+	"eventflow/internal/models"
+	"eventflow/internal/spreadsheet"
+	"fmt"
+	"io"
+	"log"
+	"net/mail"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const (
+	importMaxFileSize   = 10 << 20
+	importMaxRows       = 10000
+	importProgressBatch = 100
+	// Импорт в статусе "running" без обновления дольше этого срока считается
+	// брошенным (реплика упала) и подхватывается заново с последней сохраненной строки
+	importStaleAfter = 10 * time.Minute
+	// Ответы на вопросы анкеты события задаются в сопоставлении как "answers.<ключ>"
+	importAnswerPrefix = "answers."
+)
+
+// Поля участника, которые можно сопоставить со столбцами файла
+var importParticipantFields = []string{"full_name", "email", "phone"}
+
+var errImportNotValidated = errors.New("import is not validated")
+
+// importMappingError - сопоставление столбцов не подходит к файлу или событию
+type importMappingError struct {
+	message string
+}
+
+func (e *importMappingError) Error() string {
+	return e.message
+}
+
+func mappingErrorf(format string, args ...interface{}) error {
+	return &importMappingError{message: fmt.Sprintf(format, args...)}
+}
+
+// importColumns проверяет сопоставление "поле -> заголовок столбца" и возвращает номера
+// столбцов по полям. Заголовки сравниваются без учета регистра и пробелов по краям.
+// Обязательные вопросы анкеты должны быть сопоставлены: иначе ошибочной будет каждая строка
+func importColumns(mapping map[string]string, header []string, fields []models.FormField, withEvent bool) (map[string]int, error) {
+	positions := make(map[string]int, len(header))
+	for i, name := range header {
+		key := strings.ToLower(strings.TrimSpace(name))
+		if _, ok := positions[key]; !ok && key != "" {
+			positions[key] = i
+		}
+	}
+
+	columns := make(map[string]int, len(mapping))
+	for target, source := range mapping {
+		if key := strings.TrimPrefix(target, importAnswerPrefix); key != target {
+			if !withEvent {
+				return nil, mappingErrorf("%s requires event_id", target)
+			}
+			if !formHasField(fields, key) {
+				return nil, mappingErrorf("%s is not a question of the registration form", target)
+			}
+		} else if !containsString(importParticipantFields, target) {
+			return nil, mappingErrorf("unknown field %q, expected one of: %s or %s<key>",
+				target, strings.Join(importParticipantFields, ", "), importAnswerPrefix)
+		}
+
+		i, ok := positions[strings.ToLower(strings.TrimSpace(source))]
+		if !ok {
+			return nil, mappingErrorf("column %q for %s is not in the file header", source, target)
+		}
+		columns[target] = i
+	}
+
+	for _, required := range []string{"email", "full_name"} {
+		if _, ok := columns[required]; !ok {
+			return nil, mappingErrorf("%s must be mapped to a column", required)
+		}
+	}
+	for _, field := range fields {
+		if _, ok := columns[importAnswerPrefix+field.Key]; field.Required && !ok {
+			return nil, mappingErrorf("required question %q must be mapped to a column", field.Key)
+		}
+	}
+	return columns, nil
+}
+
+func formHasField(fields []models.FormField, key string) bool {
+	for _, field := range fields {
+		if field.Key == key {
+			return true
+		}
+	}
+	return false
+}
+
+// autoImportMapping сопоставляет столбцы, заголовок которых совпадает с именем поля
+// участника или с ключом вопроса анкеты
+func autoImportMapping(header []string, fields []models.FormField) map[string]string {
+	mapping := make(map[string]string)
+	for _, name := range header {
+		key := strings.ToLower(strings.TrimSpace(name))
+		key = strings.TrimPrefix(key, importAnswerPrefix)
+		switch {
+		case containsString(importParticipantFields, key):
+			mapping[key] = name
+		case formHasField(fields, key):
+			mapping[importAnswerPrefix+key] = name
+		}
+	}
+	return mapping
+}
+
+// importRecord - данные участника из одной строки файла
+type importRecord struct {
+	Email    string
+	FullName string
+	Phone    string
+	Answers  map[string]interface{}
+}
+
+// importOutcome - результат обработки строки. При проверке - ожидаемый результат
+type importOutcome struct {
+	created           bool
+	registration      string // Статус новой регистрации: "registered", "pending" или "waitlisted"
+	alreadyRegistered bool
+}
+
+// importRun - состояние обработки одного импорта
+type importRun struct {
+	imp         *models.Import
+	event       *models.Event
+	columns     map[string]int
+	fields      []models.FormField
+	seen        map[string]int // email в нижнем регистре -> первая строка с ним
+	freeSeats   *int64         // При проверке - свободные места с учетом уже проверенных строк; nil - без ограничения
+	savedErrors int            // Ошибок в сохраненном отчете: отчет перезаписывается, только если появились новые
+}
+
+// parseRow читает и проверяет строку файла. line - номер строки в таблице
+func (run *importRun) parseRow(row []string, line int) (importRecord, []models.ImportRowError) {
+	cell := func(target string) string {
+		i, ok := run.columns[target]
+		if !ok || i >= len(row) {
+			return ""
+		}
+		return strings.TrimSpace(row[i])
+	}
+
+	var problems []models.ImportRowError
+	problem := func(field, value, message string) {
+		problems = append(problems, models.ImportRowError{Row: line, Field: field, Value: value, Message: message})
+	}
+
+	record := importRecord{Email: cell("email"), FullName: cell("full_name"), Phone: cell("phone")}
+	switch {
+	case record.Email == "":
+		problem("email", "", "is required")
+	case !validEmail(record.Email) || utf8.RuneCountInString(record.Email) > 255:
+		problem("email", record.Email, "is not a valid email address")
+	default:
+		email := strings.ToLower(record.Email)
+		if first, ok := run.seen[email]; ok {
+			problem("email", record.Email, fmt.Sprintf("duplicates row %d", first))
+		} else {
+			run.seen[email] = line
+		}
+	}
+	if record.FullName == "" {
+		problem("full_name", "", "is required")
+	} else if utf8.RuneCountInString(record.FullName) > 255 {
+		problem("full_name", record.FullName, "must be at most 255 characters")
+	}
+	if utf8.RuneCountInString(record.Phone) > 50 {
+		problem("phone", record.Phone, "must be at most 50 characters")
+	}
+
+	if run.imp.EventID != nil {
+		answers := make(map[string]interface{})
+		for _, field := range run.fields {
+			if value := cellAnswer(field, cell(importAnswerPrefix+field.Key)); value != nil {
+				answers[field.Key] = value
+			}
+		}
+		normalized, err := validateAnswers(run.fields, answers)
+		var answersErr *answersError
+		if errors.As(err, &answersErr) {
+			keys := make([]string, 0, len(answersErr.Fields))
+			for key := range answersErr.Fields {
+				keys = append(keys, key)
+			}
+			sort.Strings(keys)
+			for _, key := range keys {
+				problem(importAnswerPrefix+key, cell(importAnswerPrefix+key), answersErr.Fields[key])
+			}
+		}
+		record.Answers = normalized
+	}
+
+	return record, problems
+}
+
+func validEmail(s string) bool {
+	address, err := mail.ParseAddress(s)
+	return err == nil && address.Address == s
+}
+
+// cellAnswer переводит текст ячейки в ответ того вида, который ожидает validateAnswers.
+// Несколько вариантов multi_choice перечисляются через ";". Нераспознанное значение
+// возвращается строкой, чтобы validateAnswers сообщил об ошибке
+func cellAnswer(field models.FormField, s string) interface{} {
+	if s == "" {
+		return nil
+	}
+
+	switch field.Type {
+	case "multi_choice":
+		items := make([]interface{}, 0)
+		for _, item := range strings.Split(s, ";") {
+			if item = strings.TrimSpace(item); item != "" {
+				items = append(items, item)
+			}
+		}
+		return items
+	case "number":
+		if n, err := strconv.ParseFloat(strings.Replace(s, ",", ".", 1), 64); err == nil {
+			return n
+		}
+	case "date":
+		// XLSX хранит даты порядковым днем от 30.12.1899
+		if serial, err := strconv.ParseFloat(s, 64); err == nil && serial > 0 {
+			return time.Date(1899, 12, 30, 0, 0, 0, 0, time.UTC).AddDate(0, 0, int(serial)).Format("2006-01-02")
+		}
+	case "consent":
+		switch strings.ToLower(s) {
+		case "true", "yes", "y", "1", "да", "+":
+			return true
+		case "false", "no", "n", "0", "нет", "-":
+			return false
+		}
+	}
+	return s
+}
+
+// checkRecord определяет без изменений, что сделает с записью применение импорта
+func (run *importRun) checkRecord(record importRecord) (importOutcome, error) {
+	var outcome importOutcome
+
+	var participant models.Participant
+	err := database.DB.Select("id").Where("LOWER(email) = ?", strings.ToLower(record.Email)).First(&participant).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		outcome.created = true
+		if run.event != nil {
+			outcome.registration = run.expectedRegistration()
+		}
+		return outcome, nil
+	}
+	if err != nil || run.event == nil {
+		return outcome, err
+	}
+
+	var registration models.EventRegistration
+	err = database.DB.Select("status").
+		Where("event_id = ? AND participant_id = ?", run.event.ID, participant.ID).
+		First(&registration).Error
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound), err == nil && registration.Status == "canceled":
+		outcome.registration = run.expectedRegistration()
+	case err != nil:
+		return outcome, err
+	case registration.Status == "rejected":
+		return outcome, errRegistrationRejected
+	default:
+		outcome.alreadyRegistered = true
+	}
+	return outcome, nil
+}
+
+// expectedRegistration - статус, который получит новая регистрация при применении:
+// как в registerImportedParticipant, места считаются с учетом уже проверенных строк
+func (run *importRun) expectedRegistration() string {
+	if run.event.RegistrationMode == "approval" {
+		return "pending"
+	}
+	if run.freeSeats == nil {
+		return "registered"
+	}
+	if *run.freeSeats <= 0 {
+		return "waitlisted"
+	}
+	*run.freeSeats--
+	return "registered"
+}
+
+// applyRecord создает или обновляет участника и регистрирует его на событие импорта
+// в транзакции tx: при ошибке строки не остается ни участника, ни регистрации
+func (run *importRun) applyRecord(tx *gorm.DB, record importRecord) (importOutcome, error) {
+	var outcome importOutcome
+
+	var event models.Event
+	if run.imp.EventID != nil {
+		if err := lockEvent(tx, *run.imp.EventID, &event); err != nil {
+			return outcome, err
+		}
+		if containsString(closedEventStatuses, event.Status) {
+			return outcome, errEventClosed
+		}
+	}
+
+	participant, created, err := importParticipant(tx, record)
+	if err != nil {
+		return outcome, err
+	}
+	outcome.created = created
+
+	if run.imp.EventID == nil {
+		return outcome, nil
+	}
+	status, err := registerImportedParticipant(tx, &event, participant.ID, record.Answers)
+	if errors.Is(err, errAlreadyRegistered) {
+		outcome.alreadyRegistered = true
+		return outcome, nil
+	}
+	outcome.registration = status
+	return outcome, err
+}
+
+// importParticipant находит участника по email без учета регистра и обновляет его имя
+// и телефон (пустые ячейки данные не затирают) или создает нового
+func importParticipant(tx *gorm.DB, record importRecord) (*models.Participant, bool, error) {
+	var participant models.Participant
+	err := tx.Where("LOWER(email) = ?", strings.ToLower(record.Email)).First(&participant).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		created, ok, err := insertParticipant(tx, record.Email, record.FullName, record.Phone)
+		if err != nil || ok {
+			return created, ok, err
+		}
+		participant = *created
+	} else if err != nil {
+		return nil, false, err
+	}
+
+	updates := map[string]interface{}{"full_name": record.FullName}
+	if record.Phone != "" {
+		updates["phone"] = record.Phone
+	}
+	if err := tx.Model(&participant).Updates(updates).Error; err != nil {
+		return nil, false, err
+	}
+	return &participant, false, nil
+}
+
+// registerImportedParticipant регистрирует участника на заблокированное в tx событие так же,
+// как регистрация организатором: заявка на событие с одобрением, лист ожидания при
+// нехватке мест, иначе регистрация с тикетом. Отмененная регистрация возобновляется.
+// Возвращает статус регистрации
+func registerImportedParticipant(tx *gorm.DB, event *models.Event, participantID uint, answers map[string]interface{}) (string, error) {
+	var registration models.EventRegistration
+	err := tx.Where("event_id = ? AND participant_id = ?", event.ID, participantID).First(&registration).Error
+	exists := err == nil
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+	case err != nil:
+		return "", err
+	case registration.Status == "rejected":
+		return "", errRegistrationRejected
+	case registration.Status != "canceled":
+		return "", errAlreadyRegistered
+	}
+
+	status := "registered"
+	if event.RegistrationMode == "approval" {
+		status = "pending"
+	} else {
+		free, err := hasSeatBeyondWaitlist(tx, event)
+		if err != nil {
+			return "", err
+		}
+		if !free {
+			status = "waitlisted"
+		}
+	}
+
+	if exists {
+		registration.Status = status
+		registration.RegisteredAt = time.Now()
+		registration.Answers = answers
+		err = tx.Model(&registration).Select("status", "registered_at", "answers").Updates(&registration).Error
+	} else {
+		registration = models.EventRegistration{
+			EventID:       event.ID,
+			ParticipantID: participantID,
+			Status:        status,
+			RegisteredAt:  time.Now(),
+			Answers:       answers,
+		}
+		err = tx.Create(&registration).Error
+	}
+	if err != nil || status != "registered" {
+		return status, err
+	}
+
+	_, err = issueRegistrationTicket(tx, event.ID, participantID)
+	return status, err
+}
+
+// StartImportProcessing запускает фоновую обработку импортов.
+// Интервал опроса очереди задается переменной окружения IMPORT_POLL_INTERVAL (по умолчанию 5s).
+func StartImportProcessing() {
+	jobs.Every("imports", jobs.IntervalFromEnv("IMPORT_POLL_INTERVAL", 5*time.Second), ProcessImports)
+}
+
+// ProcessImports обрабатывает импорты из очереди по одному, пока очередь не опустеет
+func ProcessImports() error {
+	for {
+		imp, err := claimImport()
+		if err != nil {
+			return err
+		}
+		if imp == nil {
+			return nil
+		}
+
+		if err := runImport(imp); err != nil {
+			log.Printf("Import %d failed: %v", imp.ID, err)
+			reason := "Internal error"
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				reason = "Event not found"
+			}
+			if err := finishImport(imp, "failed", reason); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// claimImport захватывает импорт из очереди или брошенный упавшей репликой.
+// Захват с SKIP LOCKED: несколько реплик не возьмут один импорт
+func claimImport() (*models.Import, error) {
+	var imp models.Import
+	now := time.Now().UTC()
+
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+			Where("status = ? OR (status = ? AND heartbeat_at < ?)", "queued", "running", now.Add(-importStaleAfter)).
+			Order("id").
+			Limit(1).
+			Find(&imp).Error
+		if err != nil || imp.ID == 0 {
+			return err
+		}
+
+		if imp.Status == "queued" {
+			imp.StartedAt = &now
+		} else {
+			log.Printf("Import %d: resuming abandoned import from row %d", imp.ID, imp.ProcessedRows+2)
+		}
+		imp.Status = "running"
+		imp.HeartbeatAt = &now
+		return tx.Model(&imp).Select("status", "started_at", "heartbeat_at").Updates(&imp).Error
+	})
+	if err != nil || imp.ID == 0 {
+		return nil, err
+	}
+	return &imp, nil
+}
+
+// runImport обрабатывает строки импорта, начиная с первой необработанной.
+// Ошибки в строках собираются в отчет; возвращаются только ошибки базы данных
+func runImport(imp *models.Import) error {
+	run := &importRun{imp: imp, seen: make(map[string]int), savedErrors: len(imp.Errors)}
+
+	if imp.EventID != nil {
+		var event models.Event
+		if err := database.DB.First(&event, *imp.EventID).Error; err != nil {
+			return err
+		}
+		fields, err := eventFormFields(database.DB, event.ID)
+		if err != nil {
+			return err
+		}
+		run.event = &event
+		run.fields = fields
+
+		if imp.DryRun && event.Capacity != nil {
+			var occupied int64
+			err := database.DB.Model(&models.EventRegistration{}).
+				Where("event_id = ? AND status IN ?", event.ID, occupyingStatuses).
+				Count(&occupied).Error
+			if err != nil {
+				return err
+			}
+			// Места, которые займут строки, проверенные до перезапуска
+			free := int64(*event.Capacity) - occupied - int64(imp.RegisteredCount)
+			run.freeSeats = &free
+		}
+	}
+
+	columns, err := importColumns(imp.Mapping, imp.Header, run.fields, imp.EventID != nil)
+	var mappingErr *importMappingError
+	if errors.As(err, &mappingErr) {
+		// Анкета события изменилась после загрузки файла
+		return finishImport(imp, "failed", "Invalid mapping: "+mappingErr.message)
+	}
+	if err != nil {
+		return err
+	}
+	run.columns = columns
+
+	// Повторы email ищутся и среди строк, обработанных до перезапуска
+	for i, row := range imp.Rows[:imp.ProcessedRows] {
+		if column := columns["email"]; column < len(row) {
+			email := strings.ToLower(strings.TrimSpace(row[column]))
+			if _, ok := run.seen[email]; !ok && email != "" {
+				run.seen[email] = i + 2
+			}
+		}
+	}
+
+	for i := imp.ProcessedRows; i < len(imp.Rows); i++ {
+		if err := run.processRow(imp.Rows[i], i+2); err != nil {
+			return err
+		}
+		imp.ProcessedRows = i + 1
+
+		if imp.ProcessedRows%importProgressBatch == 0 {
+			if err := run.saveProgress(database.DB); err != nil {
+				return err
+			}
+		}
+	}
+
+	status := "completed"
+	if imp.DryRun {
+		status = "validated"
+	}
+	return finishImport(imp, status, "")
+}
+
+// processRow обрабатывает одну строку и учитывает результат в счетчиках импорта.
+// При применении ход обработки сохраняется в одной транзакции со строкой: импорт,
+// подхваченный после падения реплики, не применит ее повторно
+func (run *importRun) processRow(row []string, line int) error {
+	imp := run.imp
+	if spreadsheet.IsEmptyRow(row) {
+		imp.SkippedCount++
+		return nil
+	}
+
+	record, problems := run.parseRow(row, line)
+	if len(problems) > 0 {
+		imp.Errors = append(imp.Errors, problems...)
+		imp.ErrorCount++
+		return nil
+	}
+
+	var err error
+	if imp.DryRun {
+		var outcome importOutcome
+		if outcome, err = run.checkRecord(record); err == nil {
+			run.count(outcome)
+		}
+	} else {
+		before := *imp
+		err = database.DB.Transaction(func(tx *gorm.DB) error {
+			outcome, err := run.applyRecord(tx, record)
+			if err != nil {
+				return err
+			}
+			run.count(outcome)
+			imp.ProcessedRows = line - 1
+			return run.saveProgress(tx)
+		})
+		if err != nil {
+			// Транзакция откатилась вместе со строкой
+			*imp = before
+		}
+	}
+
+	if errors.Is(err, errEventClosed) || errors.Is(err, errRegistrationRejected) ||
+		errors.Is(err, errTierSoldOut) || errors.Is(err, errPaymentRequired) {
+		imp.Errors = append(imp.Errors, models.ImportRowError{Row: line, Message: err.Error()})
+		imp.ErrorCount++
+		return nil
+	}
+	return err
+}
+
+// count учитывает результат строки в счетчиках импорта
+func (run *importRun) count(outcome importOutcome) {
+	imp := run.imp
+	if outcome.created {
+		imp.CreatedCount++
+	} else {
+		imp.UpdatedCount++
+	}
+	switch outcome.registration {
+	case "registered":
+		imp.RegisteredCount++
+	case "pending":
+		imp.PendingCount++
+	case "waitlisted":
+		imp.WaitlistedCount++
+	}
+	if outcome.alreadyRegistered {
+		imp.SkippedCount++
+	}
+}
+
+var importProgressColumns = []string{
+	"processed_rows", "created_count", "updated_count", "registered_count",
+	"pending_count", "waitlisted_count", "skipped_count", "error_count", "errors", "heartbeat_at",
+}
+
+// saveProgress сохраняет ход обработки импорта через db
+func (run *importRun) saveProgress(db *gorm.DB) error {
+	imp := run.imp
+	now := time.Now().UTC()
+	imp.HeartbeatAt = &now
+
+	columns := importProgressColumns
+	if len(imp.Errors) == run.savedErrors {
+		columns = make([]string, 0, len(importProgressColumns))
+		for _, column := range importProgressColumns {
+			if column != "errors" {
+				columns = append(columns, column)
+			}
+		}
+	}
+	if err := db.Model(imp).Select(columns).Updates(imp).Error; err != nil {
+		return err
+	}
+	run.savedErrors = len(imp.Errors)
+	return nil
+}
+
+func finishImport(imp *models.Import, status, reason string) error {
+	now := time.Now().UTC()
+	imp.Status = status
+	imp.FailureReason = reason
+	imp.HeartbeatAt = &now
+	imp.FinishedAt = &now
+
+	columns := append([]string{"status", "failure_reason", "finished_at"}, importProgressColumns...)
+	if err := database.DB.Model(imp).Select(columns).Updates(imp).Error; err != nil {
+		return err
+	}
+
+	log.Printf("Import %d %s: %d/%d rows, %d created, %d updated, %d registered, %d pending, %d waitlisted, %d skipped, %d with errors",
+		imp.ID, status, imp.ProcessedRows, imp.TotalRows, imp.CreatedCount, imp.UpdatedCount,
+		imp.RegisteredCount, imp.PendingCount, imp.WaitlistedCount, imp.SkippedCount, imp.ErrorCount)
+	return nil
+}
+
+// Тяжелые столбцы со строками файла и ошибками не отдаются в списке и карточке импорта
+var importHeavyColumns = []string{"rows", "errors"}
+
+// @Summary Загрузить файл для импорта участников
+// @Description Загружает CSV или XLSX со списком участников и ставит импорт в очередь. Первая строка файла - заголовок. mapping сопоставляет поля full_name, email, phone и answers.<ключ вопроса анкеты> с заголовками столбцов; без mapping сопоставляются столбцы с такими же заголовками. По умолчанию выполняется проверка (dry_run) без изменений с отчетом об ошибках по строкам; применение создает или обновляет участников по email и, если задан event_id, регистрирует их. Ход обработки - в GET /imports/{id}
+// @Tags Imports
+// @Accept multipart/form-data
+// @Produce json
+// @Security BearerAuth
+// @Param file formData file true "Файл CSV или XLSX (до 10 МБ, до 10000 строк)"
+// @Param format formData string false "csv или xlsx; по умолчанию по расширению файла"
+// @Param mapping formData string false "JSON-объект: поле -> заголовок столбца"
+// @Param event_id formData int false "Событие, на которое регистрируются участники"
+// @Param dry_run formData bool false "Только проверка" default(true)
+// @Success 202 {object} models.Import
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 409 {object} map[string]string "Событие закрыто для регистрации"
+// @Router /imports [post]
+func PostImport(c *gin.Context) {
+	fileHeader, err := c.FormFile("file")
+	if err != nil {
+		c.JSON(400, gin.H{"error": "File is required"})
+		return
+	}
+	if fileHeader.Size > importMaxFileSize {
+		c.JSON(400, gin.H{"error": fmt.Sprintf("File must be at most %d MB", importMaxFileSize>>20)})
+		return
+	}
+
+	format := c.PostForm("format")
+	if format == "" {
+		format = spreadsheet.FormatFromName(fileHeader.Filename)
+	}
+	if format != "csv" && format != "xlsx" {
+		c.JSON(400, gin.H{"error": "Format must be either 'csv' or 'xlsx'"})
+		return
+	}
+
+	dryRun := true
+	if value := c.PostForm("dry_run"); value != "" {
+		if dryRun, err = strconv.ParseBool(value); err != nil {
+			c.JSON(400, gin.H{"error": "dry_run must be true or false"})
+			return
+		}
+	}
+
+	var eventID *uint
+	if value := c.PostForm("event_id"); value != "" {
+		id, err := strconv.ParseUint(value, 10, 32)
+		if err != nil {
+			c.JSON(400, gin.H{"error": "Invalid event_id"})
+			return
+		}
+		eventID = new(uint)
+		*eventID = uint(id)
+	}
+
+	var mapping map[string]string
+	if value := c.PostForm("mapping"); value != "" {
+		if err := json.Unmarshal([]byte(value), &mapping); err != nil {
+			c.JSON(400, gin.H{"error": "mapping must be a JSON object of field to column header"})
+			return
+		}
+	}
+
+	file, err := fileHeader.Open()
+	if err != nil {
+		c.JSON(400, gin.H{"error": "Failed to read the file"})
+		return
+	}
+	defer file.Close()
+	data, err := io.ReadAll(io.LimitReader(file, importMaxFileSize))
+	if err != nil {
+		c.JSON(400, gin.H{"error": "Failed to read the file"})
+		return
+	}
+
+	rows, err := spreadsheet.Read(format, data)
+	if err != nil {
+		c.JSON(400, gin.H{"error": "Failed to read the file: " + err.Error()})
+		return
+	}
+	if len(rows) < 2 {
+		c.JSON(400, gin.H{"error": "File must contain a header row and at least one data row"})
+		return
+	}
+	if len(rows)-1 > importMaxRows {
+		c.JSON(400, gin.H{"error": fmt.Sprintf("File must contain at most %d data rows", importMaxRows)})
+		return
+	}
+	header, rows := rows[0], rows[1:]
+
+	var fields []models.FormField
+	if eventID != nil {
+		var event models.Event
+		if err := database.DB.First(&event, *eventID).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				c.JSON(404, gin.H{"error": "Event not found"})
+			} else {
+				c.JSON(500, gin.H{"error": "Database error"})
+			}
+			return
+		}
+		if containsString(closedEventStatuses, event.Status) {
+			c.JSON(409, gin.H{"error": "Event is closed for registration", "reason": "event_closed"})
+			return
+		}
+		if fields, err = eventFormFields(database.DB, event.ID); err != nil {
+			c.JSON(500, gin.H{"error": "Database error"})
+			return
+		}
+	}
+
+	if len(mapping) == 0 {
+		mapping = autoImportMapping(header, fields)
+	}
+	if _, err := importColumns(mapping, header, fields, eventID != nil); err != nil {
+		c.JSON(400, gin.H{"error": "Invalid mapping: " + err.Error(), "reason": "invalid_mapping", "header": header})
+		return
+	}
+
+	imp := models.Import{
+		FileName:  fileHeader.Filename,
+		Format:    format,
+		EventID:   eventID,
+		Mapping:   mapping,
+		Header:    header,
+		Rows:      rows,
+		DryRun:    dryRun,
+		Status:    "queued",
+		TotalRows: len(rows),
+		Errors:    []models.ImportRowError{},
+	}
+	if organizerID, ok := currentOrganizerID(c); ok {
+		imp.CreatedBy = &organizerID
+	}
+
+	if err := database.DB.Create(&imp).Error; err != nil {
+		log.Printf("Database Error (Import): %v", err)
+		c.JSON(500, gin.H{"error": "Failed to create import. Database error."})
+		return
+	}
+
+	// Ответ - как карточка импорта, без тяжелых столбцов
+	var created models.Import
+	database.DB.Omit(importHeavyColumns...).First(&created, imp.ID)
+	c.JSON(202, created)
+}
+
+// @Summary Применить проверенный импорт
+// @Description Ставит в очередь применение импорта, проверка которого завершена: участники создаются или обновляются по email и регистрируются на событие импорта. Строки с ошибками пропускаются и снова попадают в отчет
+// @Tags Imports
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "ID импорта"
+// @Success 202 {object} models.Import
+// @Failure 404 {object} map[string]string
+// @Failure 409 {object} map[string]string "Импорт еще не проверен или уже применен"
+// @Router /imports/{id}/commit [post]
+func PostImportCommit(c *gin.Context) {
+	var imp models.Import
+
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Omit(importHeavyColumns...).First(&imp, c.Param("id")).Error; err != nil {
+			return err
+		}
+		if imp.Status != "validated" {
+			return errImportNotValidated
+		}
+
+		imp.DryRun = false
+		imp.Status = "queued"
+		imp.FailureReason = ""
+		imp.StartedAt, imp.HeartbeatAt, imp.FinishedAt = nil, nil, nil
+		imp.ProcessedRows, imp.ErrorCount = 0, 0
+		imp.CreatedCount, imp.UpdatedCount, imp.RegisteredCount, imp.SkippedCount = 0, 0, 0, 0
+		imp.PendingCount, imp.WaitlistedCount = 0, 0
+		imp.Errors = []models.ImportRowError{}
+		return tx.Model(&imp).
+			Select(append([]string{"dry_run", "status", "failure_reason", "started_at", "finished_at"}, importProgressColumns...)).
+			Updates(&imp).Error
+	})
+
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(404, gin.H{"error": "Import not found"})
+			return
+		}
+		if errors.Is(err, errImportNotValidated) {
+			c.JSON(409, gin.H{"error": "Only a validated dry-run import can be committed", "reason": "import_not_validated"})
+			return
+		}
+		log.Printf("Database Error (Import Commit): %v", err)
+		c.JSON(500, gin.H{"error": "Database error"})
+		return
+	}
+
+	c.JSON(202, imp)
+}
+
+// @Summary Список импортов
+// @Description Возвращает импорты участников с ходом обработки
+// @Tags Imports
+// @Produce json
+// @Security BearerAuth
+// @Param range query string false "Диапазон [start, end]"
+// @Param sort query string false "Сортировка [field, order]"
+// @Param status query string false "Статус импорта"
+// @Param event_id query int false "ID события"
+// @Success 200 {array} models.Import
+// @Router /imports [get]
+func GetImports(c *gin.Context) {
+	var imports []models.Import
+	var total int64
+
+	rangeParam := c.Query("range")
+	var start, end int = 0, 25
+	if rangeParam != "" {
+		var rangeArray []int
+		if err := json.Unmarshal([]byte(rangeParam), &rangeArray); err == nil && len(rangeArray) == 2 {
+			start = rangeArray[0]
+			end = rangeArray[1]
+		}
+	}
+
+	sortParam := c.Query("sort")
+	var sortField, sortOrder string = "id", "DESC"
+	if sortParam != "" {
+		var sortArray []string
+		if err := json.Unmarshal([]byte(sortParam), &sortArray); err == nil && len(sortArray) == 2 {
+			sortField = sortArray[0]
+			sortOrder = sortArray[1]
+		}
+	}
+
+	limit := end - start + 1
+	offset := start
+
+	query := database.DB.Model(&models.Import{})
+	if status := c.Query("status"); status != "" {
+		query = query.Where("status = ?", status)
+	}
+	if eventID := c.Query("event_id"); eventID != "" {
+		query = query.Where("event_id = ?", eventID)
+	}
+
+	countResult := query.Count(&total)
+	if countResult.Error != nil {
+		c.JSON(500, gin.H{"error": "Failed to retrieve total record count"})
+		return
+	}
+
+	contentRange := fmt.Sprintf("imports %d-%d/%d", start, end, total)
+
+	result := query.
+		Omit(importHeavyColumns...).
+		Limit(limit).
+		Offset(offset).
+		Order(sortField + " " + sortOrder).
+		Find(&imports)
+	if result.Error != nil {
+		c.JSON(500, gin.H{"error": "Database error"})
+		return
+	}
+
+	c.Header("Content-Range", contentRange)
+	c.Header("X-Total-Count", strconv.Itoa(int(total)))
+	c.JSON(200, imports)
+}
+
+// @Summary Импорт по ID
+// @Description Возвращает импорт с ходом обработки: processed_rows из total_rows и счетчики результатов
+// @Tags Imports
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "ID импорта"
+// @Success 200 {object} models.Import
+// @Failure 404 {object} map[string]string
+// @Router /imports/{id} [get]
+func GetImportById(c *gin.Context) {
+	var imp models.Import
+	if err := database.DB.Omit(importHeavyColumns...).First(&imp, c.Param("id")).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(404, gin.H{"error": "Import not found"})
+		} else {
+			c.JSON(500, gin.H{"error": "Database error"})
+		}
+		return
+	}
+
+	c.JSON(200, imp)
+}
+
+// @Summary Отчет об ошибках импорта
+// @Description Возвращает ошибки по строкам файла. В CSV на каждую ошибочную строку одна строка отчета: номер строки, ошибки и исходные ячейки, так что исправленный отчет можно загрузить снова
+// @Tags Imports
+// @Produce json
+// @Produce text/csv
+// @Security BearerAuth
+// @Param id path int true "ID импорта"
+// @Param format query string false "json или csv" default(csv)
+// @Success 200 {array} models.ImportRowError
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /imports/{id}/errors [get]
+func GetImportErrors(c *gin.Context) {
+	format := c.DefaultQuery("format", "csv")
+	if format != "json" && format != "csv" {
+		c.JSON(400, gin.H{"error": "Format must be either 'json' or 'csv'"})
+		return
+	}
+
+	var imp models.Import
+	if err := database.DB.First(&imp, c.Param("id")).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(404, gin.H{"error": "Import not found"})
+		} else {
+			c.JSON(500, gin.H{"error": "Database error"})
+		}
+		return
+	}
+
+	if format == "json" {
+		c.JSON(200, imp.Errors)
+		return
+	}
+
+	// Ошибки одной строки объединяются; порядок строк - как в файле
+	var lines []int
+	messages := make(map[int][]string)
+	for _, rowErr := range imp.Errors {
+		if _, ok := messages[rowErr.Row]; !ok {
+			lines = append(lines, rowErr.Row)
+		}
+		message := rowErr.Message
+		if rowErr.Field != "" {
+			message = rowErr.Field + ": " + message
+		}
+		messages[rowErr.Row] = append(messages[rowErr.Row], message)
+	}
+
+	c.Header("Content-Type", "text/csv; charset=utf-8")
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="import-%d-errors.csv"`, imp.ID))
+
+	// Заголовок и ячейки файла выводятся как есть: экранирование апострофом попало бы
+	// в данные при повторной загрузке исправленного отчета (телефон "+7..." стал бы "'+7...").
+	// Это те же ячейки, что были в загруженном файле
+	writer := csv.NewWriter(c.Writer)
+	record := append([]string{"row", "errors"}, imp.Header...)
+	writer.Write(record)
+
+	for _, line := range lines {
+		record = []string{strconv.Itoa(line), csvText(strings.Join(messages[line], "; "))}
+		if i := line - 2; i >= 0 && i < len(imp.Rows) {
+			record = append(record, imp.Rows[i]...)
+		}
+		writer.Write(record)
+	}
+	writer.Flush()
+}
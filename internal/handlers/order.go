@@ -0,0 +1,776 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"eventflow/internal/database"
+	"eventflow/internal/jobs"
+	"eventflow/internal/models"
+	"eventflow/internal/payments"
+	"fmt"
+	"log"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var (
+	errOrderCurrencyMismatch  = errors.New("all ticket tiers in an order must use the same currency")
+	errOrderNotPending        = errors.New("order is not pending payment")
+	errRegistrationWaitlisted = errors.New("participant is on the waitlist for this event")
+	errRegistrationPending    = errors.New("registration for this event is awaiting approval")
+	errApprovalRequired       = errors.New("event requires an approved registration before buying tickets")
+	errOrderSeatLost          = errors.New("order no longer fits the event, the payment is refunded")
+)
+
+// Заказ в статусе "processing" дольше этого срока считается брошенным (процесс упал
+// во время списания), и оплату можно повторить: ключ идемпотентности не даст списать дважды.
+// Брошенные заказы завершает задача RecoverOrderPayments по статусу списания у провайдера
+const orderPaymentTimeout = 2 * time.Minute
+
+func orderIdempotencyKey(orderID uint) string {
+	return fmt.Sprintf("order-%d", orderID)
+}
+
+// orderHoldDuration - сколько билеты удерживаются за неоплаченным заказом.
+// Задается переменной окружения ORDER_HOLD_DURATION (по умолчанию 15m).
+func orderHoldDuration() time.Duration {
+	return jobs.IntervalFromEnv("ORDER_HOLD_DURATION", 15*time.Minute)
+}
+
+// mergeOrderItems объединяет строки с одной категорией, чтобы лимит на заказ
+// нельзя было обойти, разбив количество на несколько строк.
+func mergeOrderItems(items []models.CreateOrderItemRequest) []models.CreateOrderItemRequest {
+	merged := make([]models.CreateOrderItemRequest, 0, len(items))
+	index := make(map[uint]int, len(items))
+	for _, item := range items {
+		if i, ok := index[item.TierID]; ok {
+			merged[i].Quantity += item.Quantity
+			continue
+		}
+		index[item.TierID] = len(merged)
+		merged = append(merged, item)
+	}
+	return merged
+}
+
+// releaseOrderInventory возвращает в продажу билеты, удерживаемые заказом
+func releaseOrderInventory(tx *gorm.DB, order *models.Order) error {
+	for _, item := range order.Items {
+		if err := releaseTierInventory(tx, item.TierID, item.Quantity); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// checkBuyerRegistration проверяет, что участник может купить тикеты на событие,
+// заблокированное в tx. Покупка не обходит порядок регистрации: без регистрации нужно
+// свободное место сверх листа ожидания, на событие с одобрением - одобренная заявка,
+// участник из листа ожидания покупает, только когда до него дошла очередь, а прочие
+// статусы проверяются по registrationTransitions (отмененная регистрация покупкой
+// не возобновляется)
+func checkBuyerRegistration(tx *gorm.DB, event *models.Event, participantID uint) error {
+	var registration models.EventRegistration
+	err := tx.Where("event_id = ? AND participant_id = ?", event.ID, participantID).First(&registration).Error
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		if event.RegistrationMode == "approval" {
+			return errApprovalRequired
+		}
+		free, err := hasSeatBeyondWaitlist(tx, event)
+		if err != nil {
+			return err
+		}
+		if !free {
+			return errEventFull
+		}
+		return nil
+	case err != nil:
+		return err
+	}
+
+	switch registration.Status {
+	case "registered", "attended":
+		return nil
+	case "pending":
+		return errRegistrationPending
+	case "rejected":
+		return errRegistrationRejected
+	case "waitlisted":
+		turn, err := waitlistTurnReached(tx, event, &registration)
+		if err != nil {
+			return err
+		}
+		if !turn {
+			return errRegistrationWaitlisted
+		}
+		return nil
+	}
+	return checkRegistrationTransition(registration.Status, "registered")
+}
+
+func respondBuyerRegistrationError(c *gin.Context, err error) bool {
+	var transitionErr *registrationTransitionError
+	switch {
+	case errors.Is(err, errEventFull):
+		c.JSON(409, gin.H{"error": "Event has no free seats", "reason": "event_full"})
+	case errors.Is(err, errRegistrationWaitlisted):
+		c.JSON(409, gin.H{"error": err.Error(), "reason": "registration_waitlisted"})
+	case errors.Is(err, errRegistrationPending):
+		c.JSON(409, gin.H{"error": err.Error(), "reason": "registration_pending"})
+	case errors.Is(err, errApprovalRequired):
+		c.JSON(409, gin.H{"error": err.Error(), "reason": "approval_required"})
+	case errors.Is(err, errRegistrationRejected):
+		c.JSON(409, gin.H{"error": "Registration for this event was rejected", "reason": "registration_rejected"})
+	case errors.As(err, &transitionErr):
+		c.JSON(409, gin.H{"error": transitionErr.Error(), "reason": transitionErr.Reason, "from": transitionErr.From, "to": transitionErr.To})
+	default:
+		return false
+	}
+	return true
+}
+
+// buyerRegistrationReason возвращает машиночитаемую причину отказа checkBuyerRegistration
+// (false - ошибка не связана с регистрацией покупателя)
+func buyerRegistrationReason(err error) (string, bool) {
+	var transitionErr *registrationTransitionError
+	switch {
+	case errors.Is(err, errEventFull):
+		return "event_full", true
+	case errors.Is(err, errRegistrationWaitlisted):
+		return "registration_waitlisted", true
+	case errors.Is(err, errRegistrationPending):
+		return "registration_pending", true
+	case errors.Is(err, errApprovalRequired):
+		return "approval_required", true
+	case errors.Is(err, errRegistrationRejected):
+		return "registration_rejected", true
+	case errors.As(err, &transitionErr):
+		return transitionErr.Reason, true
+	}
+	return "", false
+}
+
+// ensureRegistration регистрирует покупателя после оплаты: создает регистрацию или
+// переводит в "registered" регистрацию из листа ожидания, до которой дошла очередь.
+// Право на покупку проверено checkBuyerRegistration до списания; регистрации
+// в остальных статусах не меняются
+func ensureRegistration(tx *gorm.DB, eventID, participantID uint) error {
+	err := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&models.EventRegistration{
+		EventID:       eventID,
+		ParticipantID: participantID,
+		Status:        "registered",
+		RegisteredAt:  time.Now(),
+	}).Error
+	if err != nil {
+		return err
+	}
+
+	return tx.Model(&models.EventRegistration{}).
+		Where("event_id = ? AND participant_id = ? AND status = ?", eventID, participantID, "waitlisted").
+		Update("status", "registered").Error
+}
+
+// lockOrderPromo блокирует промокод заказа и проверяет, что участник еще может погасить
+// его по каждому билету со скидкой (nil - у заказа нет промокода)
+func lockOrderPromo(tx *gorm.DB, order *models.Order) (*models.PromoCode, error) {
+	if order.PromoCodeID == nil {
+		return nil, nil
+	}
+
+	promo, err := lockPromoCode(tx, "id = ?", *order.PromoCodeID)
+	if err != nil {
+		return nil, err
+	}
+
+	discounted := 0
+	for _, item := range order.Items {
+		if item.Discount > 0 {
+			discounted += item.Quantity
+		}
+	}
+	return promo, checkPromoLimits(tx, promo, order.ParticipantID, discounted)
+}
+
+// fulfillOrder отмечает заказ оплаченным и выдает по тикету на каждый купленный билет.
+// Тикеты выдаются только здесь: до оплаты заказ лишь удерживает остаток категорий.
+// Погашения промокода записываются здесь же под блокировкой промокода; если лимит
+// погашений исчерпан, возвращается promoError, и заказ не меняется
+func fulfillOrder(tx *gorm.DB, order *models.Order, provider, paymentID string) error {
+	promo, err := lockOrderPromo(tx, order)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now().UTC()
+	err = tx.Model(order).Updates(map[string]interface{}{
+		"status":     "paid",
+		"paid_at":    now,
+		"provider":   provider,
+		"payment_id": paymentID,
+	}).Error
+	if err != nil {
+		return err
+	}
+
+	for _, item := range order.Items {
+		var tier models.TicketTier
+		if err := tx.First(&tier, item.TierID).Error; err != nil {
+			return err
+		}
+		// Тикет получает цену, по которой билет был куплен
+		tier.Price = item.UnitPrice
+
+		for i := 0; i < item.Quantity; i++ {
+			ticket, err := issueTicket(tx, order.EventID, order.ParticipantID, &tier)
+			if err != nil {
+				return err
+			}
+			if err := tx.Model(ticket).Update("order_id", order.ID).Error; err != nil {
+				return err
+			}
+			if promo != nil {
+				if err := recordPromoRedemption(tx, promo, ticket, item.Discount); err != nil {
+					return err
+				}
+			}
+		}
+	}
+
+	return ensureRegistration(tx, order.EventID, order.ParticipantID)
+}
+
+func loadOrder(db *gorm.DB, id interface{}, order *models.Order) error {
+	return db.Preload("Items").Preload("Tickets").First(order, id).Error
+}
+
+func GetOrderById(c *gin.Context) {
+	id := c.Param("id")
+
+	if id == "" {
+		c.JSON(400, gin.H{"error": "ID parameter is required"})
+		return
+	}
+
+	var order models.Order
+
+	if err := loadOrder(database.DB, id, &order); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(404, gin.H{"error": "Order not found"})
+		} else {
+			c.JSON(500, gin.H{"error": "Database error"})
+		}
+		return
+	}
+
+	c.JSON(200, order)
+}
+
+// @Summary Получить список заказов
+// @Description Возвращает заказы с позициями, пагинацией и фильтрами
+// @Tags Orders
+// @Accept json
+// @Produce json
+// @Param range query string false "Пагинация [start, end]"
+// @Param sort query string false "Сортировка [field, order]"
+// @Param event_id query int false "Фильтр по ID события"
+// @Param participant_id query int false "Фильтр по ID участника"
+// @Param status query string false "Фильтр по статусу"
+// @Success 200 {array} models.Order
+// @Header 200 {string} X-Total-Count "Общее количество записей"
+// @Header 200 {string} Content-Range "Диапазон записей"
+// @Router /orders [get]
+func GetOrders(c *gin.Context) {
+	var orders []models.Order
+	var total int64
+
+	rangeParam := c.Query("range")
+	var start, end int = 0, 25
+	if rangeParam != "" {
+		var rangeArray []int
+		if err := json.Unmarshal([]byte(rangeParam), &rangeArray); err == nil && len(rangeArray) == 2 {
+			start = rangeArray[0]
+			end = rangeArray[1]
+		}
+	}
+
+	sortParam := c.Query("sort")
+	var sortField, sortOrder string = "id", "DESC"
+	if sortParam != "" {
+		var sortArray []string
+		if err := json.Unmarshal([]byte(sortParam), &sortArray); err == nil && len(sortArray) == 2 {
+			sortField = sortArray[0]
+			sortOrder = sortArray[1]
+		}
+	}
+
+	limit := end - start + 1
+	offset := start
+
+	query := database.DB.Model(&models.Order{})
+	if eventID := c.Query("event_id"); eventID != "" {
+		query = query.Where("event_id = ?", eventID)
+	}
+	if participantID := c.Query("participant_id"); participantID != "" {
+		query = query.Where("participant_id = ?", participantID)
+	}
+	if status := c.Query("status"); status != "" {
+		query = query.Where("status = ?", status)
+	}
+
+	countResult := query.Count(&total)
+	if countResult.Error != nil {
+		c.JSON(500, gin.H{"error": "Failed to retrieve total record count"})
+		return
+	}
+
+	contentRange := fmt.Sprintf("orders %d-%d/%d", start, end, total)
+
+	result := query.
+		Preload("Items").
+		Limit(limit).
+		Offset(offset).
+		Order(sortField + " " + sortOrder).
+		Find(&orders)
+	if result.Error != nil {
+		c.JSON(500, gin.H{"error": "Database error"})
+		return
+	}
+
+	c.Header("Content-Range", contentRange)
+	c.Header("X-Total-Count", strconv.Itoa(int(total)))
+	c.JSON(200, orders)
+}
+
+// @Summary Создать заказ
+// @Description Создает заказ в статусе pending и удерживает билеты категорий на время ORDER_HOLD_DURATION. Промокод уменьшает цену билетов, к которым применим, погашения записываются при оплате. Бесплатный заказ сразу считается оплаченным
+// @Tags Orders
+// @Accept json
+// @Produce json
+// @Param order body models.CreateOrderRequest true "Событие, участник и позиции заказа"
+// @Success 201 {object} models.Order
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 409 {object} map[string]string "Билеты распроданы, вне окна продаж, событие закрыто, нет мест; регистрация участника не позволяет покупку (лист ожидания, заявка, отклонена, отменена)"
+// @Failure 500 {object} map[string]string
+// @Router /orders [post]
+func PostOrder(c *gin.Context) {
+	var newOrder models.CreateOrderRequest
+
+	if err := c.ShouldBindJSON(&newOrder); err != nil {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+
+	var order models.Order
+
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		var event models.Event
+		if err := lockEvent(tx, newOrder.EventID, &event); err != nil {
+			return err
+		}
+
+		if containsString(closedEventStatuses, event.Status) {
+			return errEventClosed
+		}
+		if err := checkBuyerRegistration(tx, &event, newOrder.ParticipantID); err != nil {
+			return err
+		}
+
+		order = models.Order{
+			EventID:       event.ID,
+			ParticipantID: newOrder.ParticipantID,
+			Status:        "pending",
+			ExpiresAt:     time.Now().UTC().Add(orderHoldDuration()),
+		}
+
+		var promo *models.PromoCode
+		if newOrder.PromoCode != "" {
+			var err error
+			promo, err = lockPromoCode(tx, "code = ?", normalizePromoCode(newOrder.PromoCode))
+			if err != nil {
+				return err
+			}
+		}
+
+		// Промокод дает скидку на билеты тех категорий, к которым применим
+		var promoErr error
+		discounted := 0
+		for _, item := range mergeOrderItems(newOrder.Items) {
+			tier, err := reserveTier(tx, item.TierID, event.ID, item.Quantity)
+			if err != nil {
+				return err
+			}
+
+			if order.Currency == "" {
+				order.Currency = tier.Currency
+			} else if order.Currency != tier.Currency {
+				return errOrderCurrencyMismatch
+			}
+
+			var discount int64
+			if promo != nil {
+				if err := checkPromoApplicable(promo, event.ID, tier.Name, tier.Price, tier.Currency); err != nil {
+					promoErr = err
+				} else {
+					discount = promoDiscount(promo, tier.Price)
+				}
+			}
+			if discount > 0 {
+				discounted += item.Quantity
+			}
+
+			order.Discount += discount * int64(item.Quantity)
+			order.TotalAmount += (tier.Price - discount) * int64(item.Quantity)
+			order.Items = append(order.Items, models.OrderItem{
+				TierID:    tier.ID,
+				Quantity:  item.Quantity,
+				UnitPrice: tier.Price - discount,
+				Discount:  discount,
+			})
+		}
+
+		if promo != nil {
+			if discounted == 0 {
+				if promoErr != nil {
+					return promoErr
+				}
+				return &promoError{Reason: "promo_not_applicable", Message: "promo code gives no discount on this order"}
+			}
+			if err := checkPromoLimits(tx, promo, order.ParticipantID, discounted); err != nil {
+				return err
+			}
+			order.PromoCodeID = &promo.ID
+		}
+
+		if err := tx.Create(&order).Error; err != nil {
+			return err
+		}
+
+		if order.TotalAmount == 0 {
+			return fulfillOrder(tx, &order, "", "")
+		}
+		return nil
+	})
+
+	if err != nil {
+		if respondTierError(c, err) || respondBuyerRegistrationError(c, err) || respondPromoError(c, err) {
+			return
+		}
+		switch {
+		case errors.Is(err, gorm.ErrRecordNotFound):
+			c.JSON(404, gin.H{"error": "Event not found"})
+		case errors.Is(err, errEventClosed):
+			c.JSON(409, gin.H{"error": "Event is closed for registration", "reason": "event_closed"})
+		case errors.Is(err, errOrderCurrencyMismatch):
+			c.JSON(400, gin.H{"error": err.Error()})
+		default:
+			log.Printf("Database Error (Create): %v", err)
+			c.JSON(500, gin.H{"error": "Failed to create order. Database error."})
+		}
+		return
+	}
+
+	loadOrder(database.DB, order.ID, &order)
+
+	c.JSON(201, order)
+}
+
+// @Summary Оплатить заказ
+// @Description Списывает оплату через платежный провайдер. При успехе заказ становится paid и выдаются тикеты, при отказе - failed и билеты возвращаются в продажу. На время списания заказ находится в статусе processing
+// @Tags Orders
+// @Accept json
+// @Produce json
+// @Param id path int true "ID заказа"
+// @Param payment body models.PayOrderRequest false "Токен платежного средства"
+// @Success 200 {object} models.Order
+// @Failure 402 {object} map[string]interface{} "Платеж отклонен"
+// @Failure 404 {object} map[string]string
+// @Failure 409 {object} map[string]interface{} "Заказ не ожидает оплаты, уже оплачивается или истек; нет мест или регистрация участника не позволяет покупку; места заняли во время списания, и оплата возвращается"
+// @Failure 502 {object} map[string]string "Платежный провайдер недоступен"
+// @Router /orders/{id}/pay [post]
+func PayOrder(c *gin.Context) {
+	id := c.Param("id")
+
+	var input models.PayOrderRequest
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&input); err != nil {
+			c.JSON(400, gin.H{"error": err.Error()})
+			return
+		}
+	}
+
+	var order models.Order
+
+	// Оплата в три шага, чтобы обращение к провайдеру не держало блокировку заказа:
+	// заказ переводится в "processing" и транзакция фиксируется, затем списание,
+	// затем результат фиксируется во второй транзакции. Задача истечения заказы
+	// в "processing" не трогает, брошенные заказы завершает RecoverOrderPayments.
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Preload("Items").First(&order, id).Error
+		if err != nil {
+			return err
+		}
+
+		stale := order.Status == "processing" && time.Since(order.UpdatedAt) > orderPaymentTimeout
+		if order.Status != "pending" && !stale {
+			return errOrderNotPending
+		}
+
+		if order.Status == "pending" && !time.Now().UTC().Before(order.ExpiresAt) {
+			return jobs.ExpireOrder(tx, &order)
+		}
+
+		var event models.Event
+		if err := lockEvent(tx, order.EventID, &event); err != nil {
+			return err
+		}
+		if containsString(closedEventStatuses, event.Status) {
+			return errEventClosed
+		}
+		if err := checkBuyerRegistration(tx, &event, order.ParticipantID); err != nil {
+			return err
+		}
+		if _, err := lockOrderPromo(tx, &order); err != nil {
+			return err
+		}
+
+		order.Status = "processing"
+		return tx.Model(&order).Update("status", "processing").Error
+	})
+
+	if err == nil && order.Status == "processing" {
+		var result *payments.ChargeResult
+		result, err = payments.Provider.Charge(c.Request.Context(), payments.ChargeRequest{
+			OrderID:        order.ID,
+			Amount:         order.TotalAmount,
+			Currency:       order.Currency,
+			PaymentToken:   input.PaymentToken,
+			IdempotencyKey: orderIdempotencyKey(order.ID),
+			Description:    fmt.Sprintf("Order #%d for event #%d", order.ID, order.EventID),
+		})
+
+		if finishErr := finishOrderPayment(c.Request.Context(), &order, result, err); finishErr != nil {
+			err = finishErr
+		}
+	}
+
+	if err != nil {
+		if respondBuyerRegistrationError(c, err) || respondPromoError(c, err) {
+			return
+		}
+		switch {
+		case errors.Is(err, gorm.ErrRecordNotFound):
+			c.JSON(404, gin.H{"error": "Order not found"})
+		case errors.Is(err, errOrderNotPending):
+			c.JSON(409, gin.H{"error": err.Error(), "status": order.Status})
+		case errors.Is(err, errEventClosed):
+			c.JSON(409, gin.H{"error": "Event is closed for registration", "reason": "event_closed"})
+		case errors.Is(err, errOrderSeatLost):
+			loadOrder(database.DB, order.ID, &order)
+			c.JSON(409, gin.H{"error": err.Error(), "reason": order.FailureReason, "order": order})
+		case errors.Is(err, payments.ErrProviderUnavailable):
+			c.JSON(502, gin.H{"error": "Payment provider unavailable, try again later"})
+		default:
+			log.Printf("Payment Error: %v", err)
+			c.JSON(500, gin.H{"error": "Failed to pay order."})
+		}
+		return
+	}
+
+	loadOrder(database.DB, order.ID, &order)
+
+	switch order.Status {
+	case "expired":
+		c.JSON(409, gin.H{"error": "Order has expired", "reason": "order_expired", "order": order})
+	case "failed":
+		c.JSON(402, gin.H{"error": "Payment was declined", "reason": order.FailureReason, "order": order})
+	default:
+		log.Printf("Order %d paid, %d tickets issued", order.ID, len(order.Tickets))
+		c.JSON(200, order)
+	}
+}
+
+// finishOrderPayment фиксирует результат списания по заказу в "processing".
+// Если провайдер не ответил (chargeErr), заказ возвращается в "pending": повтор
+// оплаты с тем же ключом идемпотентности не спишет деньги дважды. Если оплаченный
+// заказ больше не помещается на событие, деньги возвращаются после фиксации
+// транзакции и возвращается errOrderSeatLost
+func finishOrderPayment(ctx context.Context, order *models.Order, result *payments.ChargeResult, chargeErr error) error {
+	var refund *models.Refund
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Preload("Items").First(order, order.ID).Error
+		if err != nil {
+			return err
+		}
+		if order.Status != "processing" {
+			return errOrderNotPending
+		}
+
+		refund, err = applyChargeResult(tx, order, result, chargeErr)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+
+	if refund != nil {
+		sendOrderRefund(ctx, refund, order.PaymentID)
+		return errOrderSeatLost
+	}
+	return nil
+}
+
+// failOrder возвращает билеты заказа в продажу и отмечает заказ неоплаченным
+func failOrder(tx *gorm.DB, order *models.Order, paymentID, reason string) error {
+	if err := releaseOrderInventory(tx, order); err != nil {
+		return err
+	}
+	order.Status = "failed"
+	order.Provider = payments.Provider.Name()
+	order.PaymentID = paymentID
+	order.FailureReason = reason
+	return tx.Model(order).Updates(map[string]interface{}{
+		"status":         order.Status,
+		"provider":       order.Provider,
+		"payment_id":     order.PaymentID,
+		"failure_reason": order.FailureReason,
+	}).Error
+}
+
+// applyChargeResult фиксирует результат списания по заблокированному заказу в "processing".
+// Списание идет вне блокировки события, поэтому места перепроверяются под lockEvent:
+// если за это время места заняли, событие закрылось или исчерпан лимит промокода
+// заказа, заказ не выполняется, а возврат
+// оплаты записывается в "processing" и возвращается для отправки после фиксации транзакции
+func applyChargeResult(tx *gorm.DB, order *models.Order, result *payments.ChargeResult, chargeErr error) (*models.Refund, error) {
+	if chargeErr != nil {
+		order.Status = "pending"
+		return nil, tx.Model(order).Update("status", "pending").Error
+	}
+
+	if result.Status != payments.StatusSucceeded {
+		return nil, failOrder(tx, order, result.PaymentID, result.FailureReason)
+	}
+
+	var event models.Event
+	if err := lockEvent(tx, order.EventID, &event); err != nil {
+		return nil, err
+	}
+
+	reason := ""
+	if containsString(closedEventStatuses, event.Status) {
+		reason = "event_closed"
+	} else if err := checkBuyerRegistration(tx, &event, order.ParticipantID); err != nil {
+		var ok bool
+		if reason, ok = buyerRegistrationReason(err); !ok {
+			return nil, err
+		}
+	}
+	if reason == "" {
+		err := fulfillOrder(tx, order, payments.Provider.Name(), result.PaymentID)
+		var promoErr *promoError
+		if !errors.As(err, &promoErr) {
+			return nil, err
+		}
+		reason = promoErr.Reason
+	}
+
+	if err := failOrder(tx, order, result.PaymentID, reason); err != nil {
+		return nil, err
+	}
+	refund := models.Refund{
+		OrderID:  &order.ID,
+		EventID:  order.EventID,
+		Amount:   order.TotalAmount,
+		Currency: order.Currency,
+		Reason:   reason,
+		Status:   "processing",
+		Provider: payments.Refunds.Name(),
+		// Возврат по невыполненному заказу один на заказ
+		IdempotencyKey: orderIdempotencyKey(order.ID) + "-refund",
+	}
+	if err := tx.Create(&refund).Error; err != nil {
+		return nil, err
+	}
+	log.Printf("Order %d no longer fits event %d (%s), refunding payment", order.ID, order.EventID, reason)
+	return &refund, nil
+}
+
+// sendOrderRefund отправляет обработчику возврат оплаты по невыполненному заказу.
+// Если обработчик не ответил, возврат остается в "processing" до повторной отправки;
+// после успешного возврата finishRefund отмечает заказ "refunded"
+func sendOrderRefund(ctx context.Context, refund *models.Refund, paymentID string) {
+	result, err := payments.Refunds.Refund(ctx, payments.RefundRequest{
+		PaymentID:      paymentID,
+		Amount:         refund.Amount,
+		Currency:       refund.Currency,
+		Reason:         refund.Reason,
+		IdempotencyKey: refund.IdempotencyKey,
+	})
+	if err != nil {
+		log.Printf("Order %d: refund %d not sent: %v", *refund.OrderID, refund.ID, err)
+		return
+	}
+	if err := finishRefund(refund, result, nil); err != nil {
+		log.Printf("Order %d: failed to save refund %d: %v", *refund.OrderID, refund.ID, err)
+	}
+}
+
+// StartOrderPaymentRecovery запускает задачу, завершающую брошенные заказы в "processing".
+// Интервал задается переменной окружения ORDER_PAYMENT_RECOVERY_INTERVAL (по умолчанию 1m).
+func StartOrderPaymentRecovery() {
+	jobs.Every("order-payment-recovery", jobs.IntervalFromEnv("ORDER_PAYMENT_RECOVERY_INTERVAL", time.Minute), RecoverOrderPayments)
+}
+
+// RecoverOrderPayments завершает заказы, которые дольше orderPaymentTimeout остаются в
+// "processing" (процесс упал во время списания), по статусу списания у провайдера:
+// списания не было - заказ возвращается в "pending" и дальше истекает как обычно,
+// иначе результат фиксируется как при оплате. Заказы захватываются по одному с
+// SKIP LOCKED; блокировка держится на время запроса статуса, чтобы повтор оплаты
+// не начал новое списание, пока результат прежнего не зафиксирован
+func RecoverOrderPayments() error {
+	ctx := context.Background()
+	for {
+		var order models.Order
+		var refund *models.Refund
+		err := database.DB.Transaction(func(tx *gorm.DB) error {
+			err := tx.Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+				Preload("Items").
+				Where("status = ? AND updated_at < ?", "processing", time.Now().UTC().Add(-orderPaymentTimeout)).
+				Order("id").
+				Limit(1).
+				Find(&order).Error
+			if err != nil || order.ID == 0 {
+				return err
+			}
+
+			result, err := payments.Provider.ChargeStatus(ctx, orderIdempotencyKey(order.ID))
+			if errors.Is(err, payments.ErrChargeNotFound) {
+				refund, err = applyChargeResult(tx, &order, nil, err)
+				return err
+			}
+			if err != nil {
+				return fmt.Errorf("order %d: %w", order.ID, err)
+			}
+			refund, err = applyChargeResult(tx, &order, result, nil)
+			return err
+		})
+		if err != nil {
+			return err
+		}
+		if order.ID == 0 {
+			return nil
+		}
+
+		log.Printf("Order %d: abandoned payment recovered as %s", order.ID, order.Status)
+		if refund != nil {
+			sendOrderRefund(ctx, refund, order.PaymentID)
+		}
+	}
+}
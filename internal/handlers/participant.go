@@ -8,11 +8,32 @@ import (
 	"fmt"
 	"log"
 	"strconv"
+	"strings"
 
 	"github.com/gin-gonic/gin"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
+// insertParticipant создает участника. Если участник с таким email уже создан
+// параллельным запросом, возвращается он, а created равно false
+func insertParticipant(tx *gorm.DB, email, fullName, phone string) (participant *models.Participant, created bool, err error) {
+	participant = &models.Participant{FullName: fullName, Email: email, Phone: phone}
+	result := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(participant)
+	if result.Error != nil {
+		return nil, false, result.Error
+	}
+	if result.RowsAffected > 0 {
+		return participant, true, nil
+	}
+
+	participant = &models.Participant{}
+	if err := tx.Where("LOWER(email) = ?", strings.ToLower(email)).First(participant).Error; err != nil {
+		return nil, false, err
+	}
+	return participant, false, nil
+}
+
 func GetParticipantById(c *gin.Context) {
 	id := c.Param("id")
 
@@ -199,11 +220,13 @@ func GetParticipantStatistics(c *gin.Context) {
 	var registeredCount int64
 	var attendedCount int64
 	var noShowCount int64
+	var pendingCount int64
 
 	database.DB.Model(&models.EventRegistration{}).Where("participant_id = ?", id).Count(&totalRegistrations)
 	database.DB.Model(&models.EventRegistration{}).Where("participant_id = ? AND status = ?", id, "registered").Count(&registeredCount)
 	database.DB.Model(&models.EventRegistration{}).Where("participant_id = ? AND status = ?", id, "attended").Count(&attendedCount)
 	database.DB.Model(&models.EventRegistration{}).Where("participant_id = ? AND status = ?", id, "no-show").Count(&noShowCount)
+	database.DB.Model(&models.EventRegistration{}).Where("participant_id = ? AND status = ?", id, "pending").Count(&pendingCount)
 
 	var attendanceRate float64 = 0
 	if totalRegistrations > 0 {
@@ -217,6 +240,7 @@ func GetParticipantStatistics(c *gin.Context) {
 		"registered_count":    registeredCount,
 		"attended_count":      attendedCount,
 		"no_show_count":       noShowCount,
+		"pending_count":       pendingCount,
 		"attendance_rate":     attendanceRate,
 	}
 
@@ -0,0 +1,393 @@
+package handlers
+
+import (
+	"bytes"
+	"errors"
+	"eventflow/internal/database"
+	"eventflow/internal/models"
+	"eventflow/internal/pdf"
+	"eventflow/internal/qrcode"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+	"sync"
+	"text/template"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+const (
+	defaultPrintFontPath  = "/usr/share/fonts/truetype/dejavu/DejaVuSans.ttf"
+	defaultPrintFontSize  = 12.0
+	defaultPrintLineWidth = 0.5
+	printLineSpacing      = 1.2
+
+	// Поля листа A4 и промежуток между бейджами, мм
+	badgeSheetMargin = 10.0
+	badgeSheetGap    = 5.0
+)
+
+var (
+	printFontOnce sync.Once
+	printFont     *pdf.Font
+)
+
+// loadPrintFont загружает шрифт для PDF из PDF_FONT_PATH. Без шрифта документы
+// формируются шрифтом Helvetica, и нелатинские символы не отображаются.
+func loadPrintFont() *pdf.Font {
+	printFontOnce.Do(func() {
+		path := os.Getenv("PDF_FONT_PATH")
+		if path == "" {
+			path = defaultPrintFontPath
+		}
+		font, err := pdf.LoadFont(path)
+		if err != nil {
+			log.Printf("PDF font %s is not available, falling back to Helvetica: %v", path, err)
+			return
+		}
+		printFont = font
+	})
+	return printFont
+}
+
+// printData - данные, доступные в текстовых элементах макета
+type printData struct {
+	Event       models.Event
+	Participant models.Participant
+	Ticket      models.Ticket
+	QRPayload   string // содержимое QR-кода: подписанный токен тикета
+	StartTime   string // местное время начала события, "02.01.2006 15:04"
+	EndTime     string
+	Date        string // местная дата начала события, "02.01.2006"
+}
+
+func newPrintData(event models.Event, participant models.Participant, ticket models.Ticket) printData {
+	loc := event.Location()
+	var payload string
+	if ticket.ID != 0 {
+		payload, _ = signTicketToken(ticket, event)
+	}
+	return printData{
+		Event:       event,
+		Participant: participant,
+		Ticket:      ticket,
+		QRPayload:   payload,
+		StartTime:   event.StartTime.In(loc).Format("02.01.2006 15:04"),
+		EndTime:     event.EndTime.In(loc).Format("02.01.2006 15:04"),
+		Date:        event.StartTime.In(loc).Format("02.01.2006"),
+	}
+}
+
+// defaultPrintTemplates - встроенные макеты, если для события и по умолчанию ничего не задано
+var defaultPrintTemplates = map[string]models.PrintTemplate{
+	"ticket": {
+		Kind:   "ticket",
+		Width:  210,
+		Height: 297,
+		Elements: []models.PrintElement{
+			{Type: "box", X: 15, Y: 15, Width: 180, Height: 110, LineWidth: 1},
+			{Type: "text", X: 25, Y: 25, Width: 105, Text: "{{.Event.Title}}", FontSize: 20, Bold: true},
+			{Type: "text", X: 25, Y: 60, Width: 105, Text: "{{.StartTime}} – {{.EndTime}}\n{{.Event.Timezone}}", FontSize: 12},
+			{Type: "line", X: 25, Y: 80, Width: 105},
+			{Type: "text", X: 25, Y: 86, Width: 105, Text: "{{.Participant.FullName}}", FontSize: 16, Bold: true},
+			{Type: "text", X: 25, Y: 100, Width: 105, Text: "{{.Ticket.TicketType}} · № {{.Ticket.ID}}", FontSize: 11},
+			{Type: "qr", X: 140, Y: 25, Width: 45, Height: 45},
+			{Type: "text", X: 140, Y: 72, Width: 45, Text: "{{.Ticket.QRCode}}", FontSize: 7, Align: "center"},
+		},
+	},
+	"badge": {
+		Kind:   "badge",
+		Width:  90,
+		Height: 55,
+		Elements: []models.PrintElement{
+			{Type: "box", X: 0, Y: 0, Width: 90, Height: 55, LineWidth: 0.25},
+			{Type: "text", X: 5, Y: 4, Width: 80, Text: "{{.Event.Title}}", FontSize: 9, Align: "center"},
+			{Type: "line", X: 5, Y: 12, Width: 80},
+			{Type: "text", X: 5, Y: 17, Width: 54, Text: "{{.Participant.FullName}}", FontSize: 16, Bold: true},
+			{Type: "text", X: 5, Y: 44, Width: 54, Text: "{{.Ticket.TicketType}}", FontSize: 9},
+			{Type: "qr", X: 62, Y: 20, Width: 24, Height: 24},
+		},
+	},
+}
+
+// findPrintTemplate возвращает макет события, макет по умолчанию или встроенный макет
+func findPrintTemplate(eventID uint, kind string) (models.PrintTemplate, error) {
+	var tpl models.PrintTemplate
+	err := database.DB.
+		Where("kind = ? AND (event_id = ? OR event_id IS NULL)", kind, eventID).
+		Order("event_id IS NULL, id").
+		First(&tpl).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return defaultPrintTemplates[kind], nil
+	}
+	return tpl, err
+}
+
+// parsePrintText разбирает текст элемента как text/template
+func parsePrintText(text string) (*template.Template, error) {
+	return template.New("element").Option("missingkey=error").Parse(text)
+}
+
+// renderPrintTemplate рисует макет на текущей странице со смещением (originX, originY) в мм
+func renderPrintTemplate(doc *pdf.Document, tpl models.PrintTemplate, originX, originY float64, data printData) error {
+	for _, el := range tpl.Elements {
+		x := pdf.MM(originX + el.X)
+		y := pdf.MM(originY + el.Y)
+		lineWidth := el.LineWidth
+		if lineWidth == 0 {
+			lineWidth = defaultPrintLineWidth
+		}
+
+		switch el.Type {
+		case "text":
+			t, err := parsePrintText(el.Text)
+			if err != nil {
+				return err
+			}
+			var buf bytes.Buffer
+			if err := t.Execute(&buf, data); err != nil {
+				return err
+			}
+			drawPrintText(doc, el, x, y, buf.String())
+		case "qr":
+			if data.QRPayload == "" {
+				continue
+			}
+			code, err := qrcode.Encode([]byte(data.QRPayload), qrcode.Medium)
+			if err != nil {
+				return err
+			}
+			side := el.Width
+			if el.Height > 0 && el.Height < side {
+				side = el.Height
+			}
+			drawPrintQR(doc, code, x, y, pdf.MM(side))
+		case "box":
+			doc.Rect(x, y, pdf.MM(el.Width), pdf.MM(el.Height), el.Fill, lineWidth)
+		case "line":
+			doc.Line(x, y, x+pdf.MM(el.Width), y+pdf.MM(el.Height), lineWidth)
+		}
+	}
+	return nil
+}
+
+// drawPrintText выводит текст с переносом строк по ширине элемента и выравниванием
+func drawPrintText(doc *pdf.Document, el models.PrintElement, x, y float64, text string) {
+	size := el.FontSize
+	if size == 0 {
+		size = defaultPrintFontSize
+	}
+	width := pdf.MM(el.Width)
+
+	var lines []string
+	for _, paragraph := range strings.Split(text, "\n") {
+		lines = append(lines, wrapPrintText(doc, paragraph, size, el.Bold, width)...)
+	}
+
+	for i, line := range lines {
+		lineX := x
+		if width > 0 {
+			switch el.Align {
+			case "center":
+				lineX += (width - doc.TextWidth(line, size, el.Bold)) / 2
+			case "right":
+				lineX += width - doc.TextWidth(line, size, el.Bold)
+			}
+		}
+		doc.Text(lineX, y+float64(i)*size*printLineSpacing, size, el.Bold, line)
+	}
+}
+
+// wrapPrintText разбивает абзац на строки не шире width (0 - без переноса).
+// Слово длиннее строки переносится по символам.
+func wrapPrintText(doc *pdf.Document, paragraph string, size float64, bold bool, width float64) []string {
+	words := strings.Fields(paragraph)
+	if width <= 0 || len(words) == 0 {
+		return []string{strings.TrimSpace(paragraph)}
+	}
+
+	var lines []string
+	line := ""
+	for _, word := range words {
+		candidate := word
+		if line != "" {
+			candidate = line + " " + word
+		}
+		if doc.TextWidth(candidate, size, bold) <= width {
+			line = candidate
+			continue
+		}
+		if line != "" {
+			lines = append(lines, line)
+		}
+		line = ""
+		for _, r := range word {
+			if line != "" && doc.TextWidth(line+string(r), size, bold) > width {
+				lines = append(lines, line)
+				line = ""
+			}
+			line += string(r)
+		}
+	}
+	return append(lines, line)
+}
+
+// drawPrintQR рисует QR-код квадратом side (в пунктах) с полем QuietZone; темные модули
+// одной строки объединяются в прямоугольники
+func drawPrintQR(doc *pdf.Document, code *qrcode.Code, x, y, side float64) {
+	module := side / float64(code.Size+2*qrcode.QuietZone)
+	x += module * qrcode.QuietZone
+	y += module * qrcode.QuietZone
+
+	for row := 0; row < code.Size; row++ {
+		for col := 0; col < code.Size; {
+			if !code.Dark(col, row) {
+				col++
+				continue
+			}
+			start := col
+			for col < code.Size && code.Dark(col, row) {
+				col++
+			}
+			doc.Rect(x+float64(start)*module, y+float64(row)*module, float64(col-start)*module, module, true, 0)
+		}
+	}
+}
+
+// @Summary Печатный тикет в PDF
+// @Description Возвращает тикет в PDF по макету события (название, время, участник и QR-код)
+// @Tags Tickets
+// @Produce application/pdf
+// @Param id path int true "ID тикета"
+// @Success 200 {file} binary
+// @Failure 404 {object} map[string]string
+// @Failure 409 {object} map[string]string "Тикет отменен или истек"
+// @Router /tickets/{id}/pdf [get]
+func GetTicketPDF(c *gin.Context) {
+	id := c.Param("id")
+
+	var ticket models.Ticket
+	if err := database.DB.First(&ticket, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(404, gin.H{"error": "Ticket not found"})
+		} else {
+			c.JSON(500, gin.H{"error": "Database error"})
+		}
+		return
+	}
+
+	if respondTicketNotIssuable(c, &ticket) {
+		return
+	}
+
+	var event models.Event
+	var participant models.Participant
+	if err := database.DB.First(&event, ticket.EventID).Error; err != nil {
+		c.JSON(500, gin.H{"error": "Database error"})
+		return
+	}
+	if err := database.DB.First(&participant, ticket.ParticipantID).Error; err != nil {
+		c.JSON(500, gin.H{"error": "Database error"})
+		return
+	}
+
+	tpl, err := findPrintTemplate(event.ID, "ticket")
+	if err != nil {
+		c.JSON(500, gin.H{"error": "Database error"})
+		return
+	}
+
+	doc := pdf.New(loadPrintFont())
+	doc.AddPage(pdf.MM(tpl.Width), pdf.MM(tpl.Height))
+	if err := renderPrintTemplate(doc, tpl, 0, 0, newPrintData(event, participant, ticket)); err != nil {
+		log.Printf("Print Error (Ticket %d): %v", ticket.ID, err)
+		c.JSON(500, gin.H{"error": "Failed to render ticket template"})
+		return
+	}
+
+	c.Header("Content-Disposition", fmt.Sprintf(`inline; filename="ticket-%d.pdf"`, ticket.ID))
+	c.Data(200, "application/pdf", doc.Bytes())
+}
+
+// @Summary Бейджи участников события в PDF
+// @Description Раскладывает бейджи всех зарегистрированных участников события на листах A4 по макету события
+// @Tags Events
+// @Produce application/pdf
+// @Param id path int true "ID события"
+// @Success 200 {file} binary
+// @Failure 404 {object} map[string]string
+// @Router /events/{id}/badges.pdf [get]
+func GetEventBadgesPDF(c *gin.Context) {
+	id := c.Param("id")
+
+	var event models.Event
+	if err := database.DB.First(&event, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(404, gin.H{"error": "Event not found"})
+		} else {
+			c.JSON(500, gin.H{"error": "Database error"})
+		}
+		return
+	}
+
+	var participants []models.Participant
+	result := database.DB.
+		Joins("JOIN event_registrations ON event_registrations.participant_id = participants.id").
+		Where("event_registrations.event_id = ? AND event_registrations.status IN ?", event.ID, []string{"registered", "attended", "no-show"}).
+		Order("participants.full_name ASC, participants.id ASC").
+		Find(&participants)
+	if result.Error != nil {
+		c.JSON(500, gin.H{"error": "Database error"})
+		return
+	}
+
+	// Активный тикет участника нужен для QR-кода на бейдже
+	var tickets []models.Ticket
+	if err := database.DB.Where("event_id = ? AND status = ?", event.ID, "active").Order("id ASC").Find(&tickets).Error; err != nil {
+		c.JSON(500, gin.H{"error": "Database error"})
+		return
+	}
+	ticketByParticipant := make(map[uint]models.Ticket, len(tickets))
+	for _, ticket := range tickets {
+		if _, ok := ticketByParticipant[ticket.ParticipantID]; !ok {
+			ticketByParticipant[ticket.ParticipantID] = ticket
+		}
+	}
+
+	tpl, err := findPrintTemplate(event.ID, "badge")
+	if err != nil {
+		c.JSON(500, gin.H{"error": "Database error"})
+		return
+	}
+
+	// Сетка бейджей на листе A4; крупный макет занимает лист целиком
+	columns := max(1, int((210-2*badgeSheetMargin+badgeSheetGap)/(tpl.Width+badgeSheetGap)))
+	rows := max(1, int((297-2*badgeSheetMargin+badgeSheetGap)/(tpl.Height+badgeSheetGap)))
+	perPage := columns * rows
+
+	doc := pdf.New(loadPrintFont())
+	if len(participants) == 0 {
+		doc.AddPage(pdf.A4Width, pdf.A4Height)
+	}
+
+	for i, participant := range participants {
+		if i%perPage == 0 {
+			doc.AddPage(pdf.A4Width, pdf.A4Height)
+		}
+		cell := i % perPage
+		x := badgeSheetMargin + float64(cell%columns)*(tpl.Width+badgeSheetGap)
+		y := badgeSheetMargin + float64(cell/columns)*(tpl.Height+badgeSheetGap)
+
+		data := newPrintData(event, participant, ticketByParticipant[participant.ID])
+		if err := renderPrintTemplate(doc, tpl, x, y, data); err != nil {
+			log.Printf("Print Error (Badges for event %d): %v", event.ID, err)
+			c.JSON(500, gin.H{"error": "Failed to render badge template"})
+			return
+		}
+	}
+
+	c.Header("Content-Disposition", fmt.Sprintf(`inline; filename="event-%d-badges.pdf"`, event.ID))
+	c.Data(200, "application/pdf", doc.Bytes())
+}
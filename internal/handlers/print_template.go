@@ -0,0 +1,277 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"eventflow/internal/database"
+	"eventflow/internal/models"
+	"fmt"
+	"io"
+	"log"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+var errPrintTemplateExists = errors.New("print template already exists")
+
+// validatePrintTemplateInput проверяет, что текстовые элементы - корректные шаблоны,
+// которые выполняются на данных тикета, а событие макета существует
+func validatePrintTemplateInput(input *models.CreatePrintTemplateRequest) error {
+	for i, el := range input.Elements {
+		if el.Type != "text" {
+			continue
+		}
+		t, err := parsePrintText(el.Text)
+		if err != nil {
+			return fmt.Errorf("elements[%d].text: %v", i, err)
+		}
+		if err := t.Execute(io.Discard, printData{}); err != nil {
+			return fmt.Errorf("elements[%d].text: %v", i, err)
+		}
+	}
+
+	if input.EventID != nil {
+		var event models.Event
+		if err := database.DB.Select("id").First(&event, *input.EventID).Error; err != nil {
+			return errors.New("event not found")
+		}
+	}
+	return nil
+}
+
+// checkPrintTemplateUnique возвращает errPrintTemplateExists, если у события (или по умолчанию)
+// уже есть макет этого вида, кроме макета exceptID
+func checkPrintTemplateUnique(input *models.CreatePrintTemplateRequest, exceptID string) error {
+	query := database.DB.Model(&models.PrintTemplate{}).Where("kind = ?", input.Kind)
+	if input.EventID != nil {
+		query = query.Where("event_id = ?", *input.EventID)
+	} else {
+		query = query.Where("event_id IS NULL")
+	}
+	if exceptID != "" {
+		query = query.Where("id <> ?", exceptID)
+	}
+
+	var count int64
+	if err := query.Count(&count).Error; err != nil {
+		return err
+	}
+	if count > 0 {
+		return errPrintTemplateExists
+	}
+	return nil
+}
+
+func respondPrintTemplateUniqueError(c *gin.Context, err error) {
+	if errors.Is(err, errPrintTemplateExists) {
+		c.JSON(409, gin.H{"error": "A template of this kind already exists for the event", "reason": "template_exists"})
+		return
+	}
+	c.JSON(500, gin.H{"error": "Database error"})
+}
+
+func GetPrintTemplateById(c *gin.Context) {
+	id := c.Param("id")
+
+	if id == "" {
+		c.JSON(400, gin.H{"error": "ID parameter is required"})
+		return
+	}
+
+	var tpl models.PrintTemplate
+
+	result := database.DB.First(&tpl, id)
+
+	if result.Error != nil {
+		if errors.Is(result.Error, gorm.ErrRecordNotFound) {
+			c.JSON(404, gin.H{"error": "Print template not found"})
+		} else {
+			c.JSON(500, gin.H{"error": "Database error"})
+		}
+		return
+	}
+
+	c.JSON(200, tpl)
+}
+
+// @Summary Получить список макетов печати
+// @Description Возвращает макеты тикетов и бейджей с пагинацией и фильтрами по событию и виду
+// @Tags PrintTemplates
+// @Accept json
+// @Produce json
+// @Param range query string false "Пагинация [start, end]"
+// @Param sort query string false "Сортировка [field, order]"
+// @Param event_id query int false "Фильтр по ID события"
+// @Param kind query string false "Фильтр по виду (ticket, badge)"
+// @Success 200 {array} models.PrintTemplate
+// @Header 200 {string} X-Total-Count "Общее количество записей"
+// @Header 200 {string} Content-Range "Диапазон записей"
+// @Router /print_templates [get]
+func GetPrintTemplates(c *gin.Context) {
+	var templates []models.PrintTemplate
+	var total int64
+
+	rangeParam := c.Query("range")
+	var start, end int = 0, 25
+	if rangeParam != "" {
+		var rangeArray []int
+		if err := json.Unmarshal([]byte(rangeParam), &rangeArray); err == nil && len(rangeArray) == 2 {
+			start = rangeArray[0]
+			end = rangeArray[1]
+		}
+	}
+
+	sortParam := c.Query("sort")
+	var sortField, sortOrder string = "id", "ASC"
+	if sortParam != "" {
+		var sortArray []string
+		if err := json.Unmarshal([]byte(sortParam), &sortArray); err == nil && len(sortArray) == 2 {
+			sortField = sortArray[0]
+			sortOrder = sortArray[1]
+		}
+	}
+
+	limit := end - start + 1
+	offset := start
+
+	query := database.DB.Model(&models.PrintTemplate{})
+	if eventID := c.Query("event_id"); eventID != "" {
+		query = query.Where("event_id = ?", eventID)
+	}
+	if kind := c.Query("kind"); kind != "" {
+		query = query.Where("kind = ?", kind)
+	}
+
+	countResult := query.Count(&total)
+	if countResult.Error != nil {
+		c.JSON(500, gin.H{"error": "Failed to retrieve total record count"})
+		return
+	}
+
+	contentRange := fmt.Sprintf("print_templates %d-%d/%d", start, end, total)
+
+	result := query.
+		Limit(limit).
+		Offset(offset).
+		Order(sortField + " " + sortOrder).
+		Find(&templates)
+	if result.Error != nil {
+		c.JSON(500, gin.H{"error": "Database error"})
+		return
+	}
+
+	c.Header("Content-Range", contentRange)
+	c.Header("X-Total-Count", strconv.Itoa(int(total)))
+	c.JSON(200, templates)
+}
+
+func UpdatePrintTemplate(c *gin.Context) {
+	id := c.Param("id")
+
+	var input models.CreatePrintTemplateRequest
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := validatePrintTemplateInput(&input); err != nil {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := checkPrintTemplateUnique(&input, id); err != nil {
+		respondPrintTemplateUniqueError(c, err)
+		return
+	}
+
+	var tpl models.PrintTemplate
+
+	result := database.DB.Model(&tpl).Where("id = ?", id).
+		Select("event_id", "kind", "width", "height", "elements").
+		Updates(models.PrintTemplate{
+			EventID:  input.EventID,
+			Kind:     input.Kind,
+			Width:    input.Width,
+			Height:   input.Height,
+			Elements: input.Elements,
+		})
+
+	if result.Error != nil {
+		log.Printf("Database Error (Update): %v", result.Error)
+		c.JSON(500, gin.H{"error": "Failed to update print template. Database error."})
+		return
+	}
+
+	if result.RowsAffected == 0 {
+		c.JSON(404, gin.H{"error": "Print template not found."})
+		return
+	}
+
+	database.DB.First(&tpl, id)
+
+	c.JSON(200, tpl)
+}
+
+func DeletePrintTemplate(c *gin.Context) {
+	id := c.Param("id")
+
+	result := database.DB.Delete(&models.PrintTemplate{}, id)
+
+	if result.Error != nil {
+		log.Printf("Database Error (Delete): %v", result.Error)
+		c.JSON(500, gin.H{"error": "Failed to delete print template. Database error."})
+		return
+	}
+
+	c.JSON(200, gin.H{})
+}
+
+// @Summary Создать макет печати
+// @Description Создает макет тикета или бейджа для события (или по умолчанию, без event_id). Текстовые элементы - шаблоны Go text/template с полями .Event, .Participant, .Ticket, .StartTime, .EndTime и .Date
+// @Tags PrintTemplates
+// @Accept json
+// @Produce json
+// @Param template body models.CreatePrintTemplateRequest true "Данные макета"
+// @Success 201 {object} models.PrintTemplate
+// @Failure 400 {object} map[string]string
+// @Failure 409 {object} map[string]string "Макет этого вида уже есть"
+// @Failure 500 {object} map[string]string
+// @Router /print_templates [post]
+func PostPrintTemplate(c *gin.Context) {
+	var newTemplate models.CreatePrintTemplateRequest
+
+	if err := c.ShouldBindJSON(&newTemplate); err != nil {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := validatePrintTemplateInput(&newTemplate); err != nil {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := checkPrintTemplateUnique(&newTemplate, ""); err != nil {
+		respondPrintTemplateUniqueError(c, err)
+		return
+	}
+
+	tpl := models.PrintTemplate{
+		EventID:  newTemplate.EventID,
+		Kind:     newTemplate.Kind,
+		Width:    newTemplate.Width,
+		Height:   newTemplate.Height,
+		Elements: newTemplate.Elements,
+	}
+
+	result := database.DB.Create(&tpl)
+
+	if result.Error != nil {
+		log.Printf("Database Error (Create): %v", result.Error)
+		c.JSON(500, gin.H{"error": "Failed to create print template. Database error."})
+		return
+	}
+
+	c.JSON(201, tpl)
+}
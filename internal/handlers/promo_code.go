@@ -0,0 +1,407 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"eventflow/internal/database"
+	"eventflow/internal/models"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// promoError - промокод нельзя применить; Reason - машиночитаемая причина
+type promoError struct {
+	Reason  string
+	Message string
+}
+
+func (e *promoError) Error() string {
+	return e.Message
+}
+
+func normalizePromoCode(code string) string {
+	return strings.ToUpper(strings.TrimSpace(code))
+}
+
+func validatePromoCodeInput(input *models.CreatePromoCodeRequest) error {
+	input.Code = normalizePromoCode(input.Code)
+	input.Currency = strings.ToUpper(input.Currency)
+
+	if input.DiscountType == "percentage" && input.DiscountValue > 100 {
+		return errors.New("percentage discount must be between 1 and 100")
+	}
+	if input.DiscountType == "fixed" && len(input.Currency) != 3 {
+		return errors.New("currency is required for a fixed discount")
+	}
+	if input.ValidFrom != nil && input.ValidUntil != nil && !input.ValidUntil.After(*input.ValidFrom) {
+		return errors.New("valid_until must be after valid_from")
+	}
+	return nil
+}
+
+// promoDiscount вычисляет скидку для цены price; скидка не превышает цену
+func promoDiscount(promo *models.PromoCode, price int64) int64 {
+	discount := promo.DiscountValue
+	if promo.DiscountType == "percentage" {
+		discount = price * promo.DiscountValue / 100
+	}
+	if discount > price {
+		discount = price
+	}
+	return discount
+}
+
+// lockPromoCode находит промокод и блокирует его строку до конца транзакции, поэтому
+// лимиты погашений соблюдаются при параллельных запросах
+func lockPromoCode(tx *gorm.DB, query string, args ...interface{}) (*models.PromoCode, error) {
+	var promo models.PromoCode
+	err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where(query, args...).First(&promo).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, &promoError{Reason: "promo_not_found", Message: "promo code not found"}
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &promo, nil
+}
+
+// checkPromoApplicable проверяет срок действия промокода и что он относится к событию,
+// типу тикета и валюте цены
+func checkPromoApplicable(promo *models.PromoCode, eventID uint, ticketType string, price int64, currency string) error {
+	now := time.Now().UTC()
+	if (promo.ValidFrom != nil && now.Before(*promo.ValidFrom)) || (promo.ValidUntil != nil && !now.Before(*promo.ValidUntil)) {
+		return &promoError{Reason: "promo_not_valid", Message: "promo code is not valid at this time"}
+	}
+
+	if len(promo.EventIDs) > 0 && !containsID(promo.EventIDs, eventID) {
+		return &promoError{Reason: "promo_not_applicable", Message: "promo code does not apply to this event"}
+	}
+	if len(promo.TicketTypes) > 0 && !containsString(promo.TicketTypes, ticketType) {
+		return &promoError{Reason: "promo_not_applicable", Message: "promo code does not apply to this ticket type"}
+	}
+	if promo.DiscountType == "fixed" && price > 0 && currency != "" && currency != promo.Currency {
+		return &promoError{Reason: "promo_not_applicable", Message: "promo code currency does not match the ticket currency"}
+	}
+	return nil
+}
+
+// checkPromoLimits проверяет, что участник может погасить промокод еще count раз
+func checkPromoLimits(tx *gorm.DB, promo *models.PromoCode, participantID uint, count int) error {
+	if promo.MaxRedemptions != nil && promo.Redeemed+count > *promo.MaxRedemptions {
+		return &promoError{Reason: "promo_exhausted", Message: "promo code has reached its redemption limit"}
+	}
+	if promo.MaxPerParticipant != nil {
+		var used int64
+		err := tx.Model(&models.PromoRedemption{}).
+			Where("promo_code_id = ? AND participant_id = ?", promo.ID, participantID).
+			Count(&used).Error
+		if err != nil {
+			return err
+		}
+		if used+int64(count) > int64(*promo.MaxPerParticipant) {
+			return &promoError{Reason: "promo_participant_limit", Message: "participant has already used this promo code"}
+		}
+	}
+	return nil
+}
+
+// recordPromoRedemption записывает скидку discount по тикету, цена которого уже уменьшена
+// на нее, и погашение промокода. Промокод должен быть заблокирован (lockPromoCode);
+// применение без скидки погашением не считается
+func recordPromoRedemption(tx *gorm.DB, promo *models.PromoCode, ticket *models.Ticket, discount int64) error {
+	if discount == 0 {
+		return nil
+	}
+
+	ticket.DiscountAmount = discount
+	ticket.PromoCodeID = &promo.ID
+	err := tx.Model(ticket).Updates(map[string]interface{}{
+		"price":           ticket.Price,
+		"discount_amount": discount,
+		"promo_code_id":   promo.ID,
+	}).Error
+	if err != nil {
+		return err
+	}
+
+	if err := tx.Model(promo).Update("redeemed", gorm.Expr("redeemed + 1")).Error; err != nil {
+		return err
+	}
+	promo.Redeemed++
+
+	return tx.Create(&models.PromoRedemption{
+		PromoCodeID:    promo.ID,
+		ParticipantID:  ticket.ParticipantID,
+		EventID:        ticket.EventID,
+		TicketID:       ticket.ID,
+		DiscountAmount: discount,
+	}).Error
+}
+
+// redeemPromoCode применяет промокод к только что выданному тикету: уменьшает его цену
+// и записывает погашение.
+func redeemPromoCode(tx *gorm.DB, code string, ticket *models.Ticket) error {
+	promo, err := lockPromoCode(tx, "code = ?", normalizePromoCode(code))
+	if err != nil {
+		return err
+	}
+	if err := checkPromoApplicable(promo, ticket.EventID, ticket.TicketType, ticket.Price, ticket.Currency); err != nil {
+		return err
+	}
+
+	discount := promoDiscount(promo, ticket.Price)
+	if discount == 0 {
+		return nil
+	}
+	if err := checkPromoLimits(tx, promo, ticket.ParticipantID, 1); err != nil {
+		return err
+	}
+
+	ticket.Price -= discount
+	return recordPromoRedemption(tx, promo, ticket, discount)
+}
+
+func containsID(values []uint, value uint) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+func respondPromoError(c *gin.Context, err error) bool {
+	var promoErr *promoError
+	if !errors.As(err, &promoErr) {
+		return false
+	}
+
+	code := 400
+	if promoErr.Reason == "promo_exhausted" || promoErr.Reason == "promo_participant_limit" {
+		code = 409
+	}
+	c.JSON(code, gin.H{"error": promoErr.Message, "reason": promoErr.Reason})
+	return true
+}
+
+func GetPromoCodeById(c *gin.Context) {
+	id := c.Param("id")
+
+	if id == "" {
+		c.JSON(400, gin.H{"error": "ID parameter is required"})
+		return
+	}
+
+	var promo models.PromoCode
+
+	result := database.DB.First(&promo, id)
+
+	if result.Error != nil {
+		if errors.Is(result.Error, gorm.ErrRecordNotFound) {
+			c.JSON(404, gin.H{"error": "Promo code not found"})
+		} else {
+			c.JSON(500, gin.H{"error": "Database error"})
+		}
+		return
+	}
+
+	c.JSON(200, promo)
+}
+
+// @Summary Получить список промокодов
+// @Description Возвращает промокоды с пагинацией
+// @Tags PromoCodes
+// @Accept json
+// @Produce json
+// @Param range query string false "Пагинация [start, end]"
+// @Param sort query string false "Сортировка [field, order]"
+// @Success 200 {array} models.PromoCode
+// @Header 200 {string} X-Total-Count "Общее количество записей"
+// @Header 200 {string} Content-Range "Диапазон записей"
+// @Router /promo_codes [get]
+func GetPromoCodes(c *gin.Context) {
+	var promos []models.PromoCode
+	var total int64
+
+	rangeParam := c.Query("range")
+	var start, end int = 0, 25
+	if rangeParam != "" {
+		var rangeArray []int
+		if err := json.Unmarshal([]byte(rangeParam), &rangeArray); err == nil && len(rangeArray) == 2 {
+			start = rangeArray[0]
+			end = rangeArray[1]
+		}
+	}
+
+	sortParam := c.Query("sort")
+	var sortField, sortOrder string = "id", "ASC"
+	if sortParam != "" {
+		var sortArray []string
+		if err := json.Unmarshal([]byte(sortParam), &sortArray); err == nil && len(sortArray) == 2 {
+			sortField = sortArray[0]
+			sortOrder = sortArray[1]
+		}
+	}
+
+	limit := end - start + 1
+	offset := start
+
+	countResult := database.DB.Model(&models.PromoCode{}).Count(&total)
+	if countResult.Error != nil {
+		c.JSON(500, gin.H{"error": "Failed to retrieve total record count"})
+		return
+	}
+
+	contentRange := fmt.Sprintf("promo_codes %d-%d/%d", start, end, total)
+
+	result := database.DB.
+		Limit(limit).
+		Offset(offset).
+		Order(sortField + " " + sortOrder).
+		Find(&promos)
+	if result.Error != nil {
+		c.JSON(500, gin.H{"error": "Database error"})
+		return
+	}
+
+	c.Header("Content-Range", contentRange)
+	c.Header("X-Total-Count", strconv.Itoa(int(total)))
+	c.JSON(200, promos)
+}
+
+func UpdatePromoCode(c *gin.Context) {
+	id := c.Param("id")
+
+	var input models.CreatePromoCodeRequest
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := validatePromoCodeInput(&input); err != nil {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+
+	var promo models.PromoCode
+
+	result := database.DB.Model(&promo).Where("id = ?", id).
+		Select("code", "discount_type", "discount_value", "currency", "max_redemptions",
+			"max_per_participant", "valid_from", "valid_until", "event_ids", "ticket_types").
+		Updates(models.PromoCode{
+			Code:              input.Code,
+			DiscountType:      input.DiscountType,
+			DiscountValue:     input.DiscountValue,
+			Currency:          input.Currency,
+			MaxRedemptions:    input.MaxRedemptions,
+			MaxPerParticipant: input.MaxPerParticipant,
+			ValidFrom:         utcOrNil(input.ValidFrom),
+			ValidUntil:        utcOrNil(input.ValidUntil),
+			EventIDs:          input.EventIDs,
+			TicketTypes:       input.TicketTypes,
+		})
+
+	if result.Error != nil {
+		log.Printf("Database Error (Update): %v", result.Error)
+		c.JSON(500, gin.H{"error": "Failed to update promo code. Database error."})
+		return
+	}
+
+	if result.RowsAffected == 0 {
+		c.JSON(404, gin.H{"error": "Promo code not found."})
+		return
+	}
+
+	database.DB.First(&promo, id)
+
+	c.JSON(200, promo)
+}
+
+func DeletePromoCode(c *gin.Context) {
+	id := c.Param("id")
+
+	result := database.DB.Delete(&models.PromoCode{}, id)
+
+	if result.Error != nil {
+		log.Printf("Database Error (Delete): %v", result.Error)
+		c.JSON(500, gin.H{"error": "Failed to delete promo code. Database error."})
+		return
+	}
+
+	c.JSON(200, gin.H{})
+}
+
+// @Summary Создать промокод
+// @Description Создает промокод с процентной или фиксированной скидкой, лимитами погашений, сроком действия и областью применения
+// @Tags PromoCodes
+// @Accept json
+// @Produce json
+// @Param promo body models.CreatePromoCodeRequest true "Данные промокода"
+// @Success 201 {object} models.PromoCode
+// @Failure 400 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /promo_codes [post]
+func PostPromoCode(c *gin.Context) {
+	var newPromo models.CreatePromoCodeRequest
+
+	if err := c.ShouldBindJSON(&newPromo); err != nil {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := validatePromoCodeInput(&newPromo); err != nil {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+
+	promo := models.PromoCode{
+		Code:              newPromo.Code,
+		DiscountType:      newPromo.DiscountType,
+		DiscountValue:     newPromo.DiscountValue,
+		Currency:          newPromo.Currency,
+		MaxRedemptions:    newPromo.MaxRedemptions,
+		MaxPerParticipant: newPromo.MaxPerParticipant,
+		ValidFrom:         utcOrNil(newPromo.ValidFrom),
+		ValidUntil:        utcOrNil(newPromo.ValidUntil),
+		EventIDs:          newPromo.EventIDs,
+		TicketTypes:       newPromo.TicketTypes,
+	}
+
+	result := database.DB.Create(&promo)
+
+	if result.Error != nil {
+		log.Printf("Database Error (Create): %v", result.Error)
+		c.JSON(500, gin.H{"error": "Failed to create promo code. Database error."})
+		return
+	}
+
+	c.JSON(201, promo)
+}
+
+// @Summary Погашения промокода
+// @Description Возвращает все применения промокода к тикетам
+// @Tags PromoCodes
+// @Accept json
+// @Produce json
+// @Param id path int true "ID промокода"
+// @Success 200 {array} models.PromoRedemption
+// @Router /promo_codes/{id}/redemptions [get]
+func GetPromoCodeRedemptions(c *gin.Context) {
+	id := c.Param("id")
+
+	var redemptions []models.PromoRedemption
+	result := database.DB.Where("promo_code_id = ?", id).Order("created_at ASC, id ASC").Find(&redemptions)
+	if result.Error != nil {
+		c.JSON(500, gin.H{"error": "Database error"})
+		return
+	}
+
+	c.JSON(200, redemptions)
+}
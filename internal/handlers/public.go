@@ -0,0 +1,388 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"eventflow/internal/botcheck"
+	"eventflow/internal/database"
+	"eventflow/internal/middleware"
+	"eventflow/internal/models"
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+const (
+	defaultPublicRateLimit             = 60 // запросов в минуту с одного IP
+	defaultPublicRegistrationRateLimit = 5
+)
+
+var errRegistrationRejected = errors.New("registration for this event was rejected")
+
+// Поля, по которым публичный список событий можно сортировать
+var publicEventSortFields = []string{"id", "title", "start_time", "end_time"}
+
+// rateLimitFromEnv читает лимит запросов в минуту из переменной окружения
+func rateLimitFromEnv(key string, fallback int) int {
+	value := os.Getenv(key)
+	if value == "" {
+		return fallback
+	}
+	limit, err := strconv.Atoi(value)
+	if err != nil || limit <= 0 {
+		log.Printf("Warning: invalid %s=%q, using %d", key, value, fallback)
+		return fallback
+	}
+	return limit
+}
+
+// RegisterPublicRoutes подключает публичный API для участников к группе маршрутов.
+// Все маршруты ограничены по IP (PUBLIC_RATE_LIMIT запросов в минуту), регистрация -
+// дополнительно (PUBLIC_REGISTRATION_RATE_LIMIT).
+func RegisterPublicRoutes(group *gin.RouterGroup) {
+	group.Use(middleware.RateLimit(rateLimitFromEnv("PUBLIC_RATE_LIMIT", defaultPublicRateLimit), time.Minute))
+
+	registrationLimit := middleware.RateLimit(rateLimitFromEnv("PUBLIC_REGISTRATION_RATE_LIMIT", defaultPublicRegistrationRateLimit), time.Minute)
+
+	group.GET("/events", GetPublicEvents)
+	group.GET("/events/:id", GetPublicEventById)
+	group.GET("/events/:id/registration-form", GetPublicEventRegistrationForm)
+	group.POST("/events/:id/register", registrationLimit, PostPublicRegistration)
+}
+
+// findPublishedEvent находит опубликованное событие; неопубликованные не отличаются от несуществующих
+func findPublishedEvent(db *gorm.DB, id string, event *models.Event) error {
+	return db.Where("publish_status = ?", "published").First(event, id).Error
+}
+
+// @Summary Опубликованные события
+// @Description Публичный список событий: возвращаются только опубликованные события
+// @Tags Public
+// @Produce json
+// @Param range query string false "Пагинация [start, end]" example([0, 24])
+// @Param sort query string false "Сортировка [field, order]: id, title, start_time или end_time" example(["start_time", "ASC"])
+// @Param category_id query int false "ID категории"
+// @Param venue_id query int false "ID площадки"
+// @Param start_date query string false "События, начинающиеся не раньше (RFC 3339 или YYYY-MM-DD)"
+// @Param end_date query string false "События, заканчивающиеся не позже (RFC 3339 или YYYY-MM-DD)"
+// @Param tz query string false "Часовой пояс IANA для дат без смещения" default(UTC)
+// @Success 200 {array} models.Event
+// @Header 200 {string} X-Total-Count "Общее количество записей"
+// @Header 200 {string} Content-Range "Диапазон записей"
+// @Failure 429 {object} map[string]string "Превышен лимит запросов"
+// @Router /public/events [get]
+func GetPublicEvents(c *gin.Context) {
+	var events []models.Event
+	var total int64
+
+	rangeParam := c.Query("range")
+	var start, end int = 0, 24
+	if rangeParam != "" {
+		var rangeArray []int
+		if err := json.Unmarshal([]byte(rangeParam), &rangeArray); err == nil && len(rangeArray) == 2 {
+			start = rangeArray[0]
+			end = rangeArray[1]
+		}
+	}
+	if start < 0 || end < start || end-start >= 100 {
+		c.JSON(400, gin.H{"error": "range must be [start, end] with at most 100 items"})
+		return
+	}
+
+	// Сортировка публичного списка ограничена известными полями
+	sortParam := c.Query("sort")
+	var sortField, sortOrder string = "start_time", "ASC"
+	if sortParam != "" {
+		var sortArray []string
+		if err := json.Unmarshal([]byte(sortParam), &sortArray); err == nil && len(sortArray) == 2 {
+			sortField = sortArray[0]
+			sortOrder = strings.ToUpper(sortArray[1])
+		}
+	}
+	if !containsString(publicEventSortFields, sortField) || (sortOrder != "ASC" && sortOrder != "DESC") {
+		c.JSON(400, gin.H{"error": "sort must be [field, order] with field one of: " + strings.Join(publicEventSortFields, ", ")})
+		return
+	}
+
+	query := database.DB.Model(&models.Event{}).Where("publish_status = ?", "published")
+
+	if categoryID := c.Query("category_id"); categoryID != "" {
+		query = query.Where("category_id = ?", categoryID)
+	}
+	if venueID := c.Query("venue_id"); venueID != "" {
+		query = query.Where("venue_id = ?", venueID)
+	}
+
+	from, to, ok := timeRangeFilter(c)
+	if !ok {
+		return
+	}
+	if from != nil {
+		query = query.Where("start_time >= ?", *from)
+	}
+	if to != nil {
+		query = query.Where("end_time < ?", *to)
+	}
+
+	if err := query.Count(&total).Error; err != nil {
+		c.JSON(500, gin.H{"error": "Failed to retrieve total record count"})
+		return
+	}
+
+	result := query.
+		Limit(end - start + 1).
+		Offset(start).
+		Order(sortField + " " + sortOrder + ", id ASC").
+		Find(&events)
+	if result.Error != nil {
+		c.JSON(500, gin.H{"error": "Database error"})
+		return
+	}
+
+	c.Header("Content-Range", fmt.Sprintf("events %d-%d/%d", start, end, total))
+	c.Header("X-Total-Count", strconv.Itoa(int(total)))
+	c.JSON(200, events)
+}
+
+// @Summary Опубликованное событие
+// @Description Возвращает опубликованное событие; неопубликованное событие не отличается от несуществующего (404)
+// @Tags Public
+// @Produce json
+// @Param id path int true "ID события"
+// @Success 200 {object} models.Event
+// @Failure 404 {object} map[string]string
+// @Failure 429 {object} map[string]string "Превышен лимит запросов"
+// @Router /public/events/{id} [get]
+func GetPublicEventById(c *gin.Context) {
+	var event models.Event
+	if err := findPublishedEvent(database.DB, c.Param("id"), &event); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(404, gin.H{"error": "Event not found"})
+		} else {
+			c.JSON(500, gin.H{"error": "Database error"})
+		}
+		return
+	}
+
+	c.JSON(200, event)
+}
+
+// GetPublicEventRegistrationForm возвращает вопросы анкеты опубликованного события
+// (пустой список, если анкеты нет)
+func GetPublicEventRegistrationForm(c *gin.Context) {
+	var event models.Event
+	if err := findPublishedEvent(database.DB, c.Param("id"), &event); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(404, gin.H{"error": "Event not found"})
+		} else {
+			c.JSON(500, gin.H{"error": "Database error"})
+		}
+		return
+	}
+
+	fields, err := eventFormFields(database.DB, event.ID)
+	if err != nil {
+		c.JSON(500, gin.H{"error": "Database error"})
+		return
+	}
+	if fields == nil {
+		fields = []models.FormField{}
+	}
+
+	c.JSON(200, gin.H{"event_id": event.ID, "fields": fields})
+}
+
+// publicParticipant находит участника по email без учета регистра или создает нового.
+// Существующий участник не меняется: публичный запрос подтверждает только знание email,
+// и по нему нельзя переписать чужие имя и телефон
+func publicParticipant(tx *gorm.DB, email, fullName, phone string) (*models.Participant, error) {
+	var participant models.Participant
+	err := tx.Where("LOWER(email) = ?", strings.ToLower(email)).First(&participant).Error
+	if err == nil {
+		return &participant, nil
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+
+	created, _, err := insertParticipant(tx, email, fullName, phone)
+	return created, err
+}
+
+// @Summary Регистрация на событие
+// @Description Регистрирует участника на опубликованное событие одним запросом: участник находится по email (или создается), регистрация и тикет создаются в одной транзакции. Если мест нет, регистрация попадает в лист ожидания без тикета (202). На событие с одобрением создается заявка "pending" без тикета (202). Запрос проходит проверку на ботов и ограничен по IP
+// @Tags Public
+// @Accept json
+// @Produce json
+// @Param id path int true "ID события"
+// @Param registration body models.PublicRegistrationRequest true "Данные участника и ответы на анкету события"
+// @Success 201 {object} map[string]interface{} "ticket, qr_payload и registration"
+// @Success 202 {object} map[string]interface{} "registration в листе ожидания или на рассмотрении"
+// @Failure 400 {object} map[string]string
+// @Failure 403 {object} map[string]string "Запрос отклонен защитой от ботов"
+// @Failure 404 {object} map[string]string
+// @Failure 409 {object} map[string]string "Участник уже зарегистрирован, событие закрыто, бесплатные тикеты закончились (sold_out) или тикеты только платные (payment_required)"
+// @Failure 429 {object} map[string]string "Превышен лимит запросов"
+// @Router /public/events/{id}/register [post]
+func PostPublicRegistration(c *gin.Context) {
+	var input models.PublicRegistrationRequest
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+	input.Email = strings.TrimSpace(input.Email)
+	input.FullName = strings.TrimSpace(input.FullName)
+	input.Phone = strings.TrimSpace(input.Phone)
+
+	err := botcheck.Current.Verify(c.Request.Context(), botcheck.Request{
+		Token:    input.CaptchaToken,
+		Honeypot: input.Website,
+		RemoteIP: c.ClientIP(),
+	})
+	if errors.Is(err, botcheck.ErrRejected) {
+		c.JSON(403, gin.H{"error": "Request was rejected by bot protection", "reason": "bot_check_failed"})
+		return
+	}
+	if err != nil {
+		log.Printf("Bot Protection Error: %v", err)
+		c.JSON(503, gin.H{"error": "Bot protection is temporarily unavailable", "reason": "bot_check_unavailable"})
+		return
+	}
+
+	var registration models.EventRegistration
+	var ticket *models.Ticket
+	var event models.Event
+
+	err = database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := findPublishedEvent(tx, c.Param("id"), &event); err != nil {
+			return err
+		}
+		if err := lockEvent(tx, event.ID, &event); err != nil {
+			return err
+		}
+		if containsString(closedEventStatuses, event.Status) {
+			return errEventClosed
+		}
+
+		answers, err := checkRegistrationAnswers(tx, event.ID, input.Answers)
+		if err != nil {
+			return err
+		}
+
+		participant, err := publicParticipant(tx, input.Email, input.FullName, input.Phone)
+		if err != nil {
+			return err
+		}
+
+		// На событие с одобрением создается заявка, тикет выдается после одобрения
+		status := "registered"
+		if event.RegistrationMode == "approval" {
+			status = "pending"
+		} else {
+			free, err := hasSeatBeyondWaitlist(tx, &event)
+			if err != nil {
+				return err
+			}
+			if !free {
+				status = "waitlisted"
+			}
+		}
+		promoCode := ""
+		if status == "pending" {
+			promoCode = input.PromoCode
+		}
+
+		// Отмененная регистрация возобновляется: пара участник-событие уникальна
+		err = tx.Where("event_id = ? AND participant_id = ?", event.ID, participant.ID).First(&registration).Error
+		switch {
+		case errors.Is(err, gorm.ErrRecordNotFound):
+			registration = models.EventRegistration{
+				EventID:       event.ID,
+				ParticipantID: participant.ID,
+				Status:        status,
+				PromoCode:     promoCode,
+				RegisteredAt:  time.Now(),
+				Answers:       answers,
+			}
+			if err := tx.Create(&registration).Error; err != nil {
+				return err
+			}
+		case err != nil:
+			return err
+		case registration.Status == "rejected":
+			return errRegistrationRejected
+		case registration.Status != "canceled":
+			return errAlreadyRegistered
+		default:
+			registration.Status = status
+			registration.PromoCode = promoCode
+			registration.RegisteredAt = time.Now()
+			registration.Answers = answers
+			if err := tx.Model(&registration).Select("status", "promo_code", "registered_at", "answers").Updates(&registration).Error; err != nil {
+				return err
+			}
+		}
+
+		if status == "pending" {
+			return nil
+		}
+		if status == "waitlisted" {
+			return fillWaitlistPosition(tx, &registration)
+		}
+
+		ticket, err = issueRegistrationTicket(tx, event.ID, participant.ID)
+		if err != nil {
+			return err
+		}
+		if input.PromoCode != "" {
+			return redeemPromoCode(tx, input.PromoCode, ticket)
+		}
+		return nil
+	})
+
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(404, gin.H{"error": "Event not found"})
+			return
+		}
+		if errors.Is(err, errEventClosed) {
+			c.JSON(409, gin.H{"error": "Event is closed for registration", "reason": "event_closed"})
+			return
+		}
+		if errors.Is(err, errAlreadyRegistered) {
+			c.JSON(409, gin.H{"error": "Participant is already registered for this event", "reason": "already_registered"})
+			return
+		}
+		if respondAnswersError(c, err) {
+			return
+		}
+		if errors.Is(err, errRegistrationRejected) {
+			c.JSON(409, gin.H{"error": "Registration for this event was rejected", "reason": "registration_rejected"})
+			return
+		}
+		if respondTierError(c, err) || respondPromoError(c, err) {
+			return
+		}
+		log.Printf("Database Error (Public Registration): %v", err)
+		c.JSON(500, gin.H{"error": "Failed to register for the event. Database error."})
+		return
+	}
+
+	if ticket == nil {
+		c.JSON(202, gin.H{"registration": registration})
+		return
+	}
+
+	token, _ := signTicketToken(*ticket, event)
+	c.JSON(201, gin.H{
+		"ticket":       ticket,
+		"qr_payload":   token,
+		"registration": registration,
+	})
+}
@@ -0,0 +1,540 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"eventflow/internal/database"
+	"eventflow/internal/jobs"
+	"eventflow/internal/models"
+	"eventflow/internal/payments"
+	"fmt"
+	"log"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// refundError - возврат запрещен; Reason - машиночитаемая причина
+type refundError struct {
+	Reason   string
+	Message  string
+	Deadline *time.Time
+}
+
+func (e *refundError) Error() string {
+	return e.Message
+}
+
+// checkRefundPolicy проверяет политику возвратов события на момент now.
+// По отмененному событию возврат возможен всегда.
+func checkRefundPolicy(event *models.Event, now time.Time) error {
+	if event.Status == "canceled" {
+		return nil
+	}
+
+	if event.RefundPolicy == "none" {
+		return &refundError{Reason: "refunds_disabled", Message: "refunds are not allowed for this event"}
+	}
+
+	deadline := event.StartTime
+	if event.RefundCutoffHours != nil {
+		deadline = deadline.Add(-time.Duration(*event.RefundCutoffHours) * time.Hour)
+	}
+	if !now.Before(deadline) {
+		return &refundError{Reason: "refund_window_closed", Message: "refund window for this event has closed", Deadline: &deadline}
+	}
+
+	return nil
+}
+
+// refundProcessingTimeout - через это время незавершенный возврат считается
+// прерванным, и повтор возврата по тикету продолжает его с тем же ключом идемпотентности
+const refundProcessingTimeout = 2 * time.Minute
+
+// refundTicket оформляет возврат по тикету через обработчик возвратов в три шага,
+// чтобы обращение к обработчику не держало блокировки события и тикета: возврат
+// записывается в статусе "processing", затем идет обращение к обработчику, затем
+// результат фиксируется во второй транзакции. Успешный возврат всей оставшейся суммы
+// отменяет тикет и возвращает билет в продажу; частичный и неуспешный только записываются.
+func refundTicket(ctx context.Context, ticketID interface{}, organizerID uint, amount *int64, reason string) (*models.Refund, error) {
+	var refund *models.Refund
+	var paymentID, idempotencyKey string
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		var err error
+		refund, paymentID, idempotencyKey, err = startRefund(tx, ticketID, organizerID, amount, reason)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	result, err := payments.Refunds.Refund(ctx, payments.RefundRequest{
+		PaymentID:      paymentID,
+		Amount:         refund.Amount,
+		Currency:       refund.Currency,
+		Reason:         refund.Reason,
+		IdempotencyKey: idempotencyKey,
+	})
+
+	if finishErr := finishRefund(refund, result, err); finishErr != nil {
+		err = finishErr
+	}
+	if err != nil {
+		return nil, err
+	}
+	return refund, nil
+}
+
+// startRefund проверяет, что возврат по тикету возможен, и записывает его в
+// статусе "processing". Прерванный возврат по тикету продолжается как есть
+func startRefund(tx *gorm.DB, ticketID interface{}, organizerID uint, amount *int64, reason string) (*models.Refund, string, string, error) {
+	var ticket models.Ticket
+	if err := tx.First(&ticket, ticketID).Error; err != nil {
+		return nil, "", "", err
+	}
+
+	var event models.Event
+	if err := lockEvent(tx, ticket.EventID, &event); err != nil {
+		return nil, "", "", err
+	}
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&ticket, ticket.ID).Error; err != nil {
+		return nil, "", "", err
+	}
+
+	refunded, err := ticketRefundedAmount(tx, ticket.ID)
+	if err != nil {
+		return nil, "", "", err
+	}
+	if refunded > 0 && refunded >= ticket.Price {
+		return nil, "", "", &refundError{Reason: "already_refunded", Message: "ticket has already been refunded"}
+	}
+
+	if ticket.OrderID == nil || ticket.Price == 0 {
+		return nil, "", "", &refundError{Reason: "not_paid", Message: "ticket was not paid for"}
+	}
+
+	var order models.Order
+	if err := tx.First(&order, *ticket.OrderID).Error; err != nil {
+		return nil, "", "", err
+	}
+
+	var processing models.Refund
+	err = tx.Where("ticket_id = ? AND status = ?", ticket.ID, "processing").First(&processing).Error
+	switch {
+	case err == nil:
+		if time.Since(processing.CreatedAt) <= refundProcessingTimeout {
+			return nil, "", "", &refundError{Reason: "refund_in_progress", Message: "refund for this ticket is already in progress"}
+		}
+		return &processing, order.PaymentID, processing.IdempotencyKey, nil
+	case !errors.Is(err, gorm.ErrRecordNotFound):
+		return nil, "", "", err
+	}
+
+	if order.Status != "paid" {
+		return nil, "", "", &refundError{Reason: "not_paid", Message: "ticket order is not paid"}
+	}
+
+	if err := checkRefundPolicy(&event, time.Now().UTC()); err != nil {
+		return nil, "", "", err
+	}
+
+	// Частичные возвраты возможны, пока их сумма не достигнет цены тикета
+	refundAmount := ticket.Price - refunded
+	if amount != nil {
+		if *amount > refundAmount {
+			return nil, "", "", &refundError{Reason: "invalid_amount", Message: "refund amount exceeds the amount left to refund for the ticket"}
+		}
+		refundAmount = *amount
+	}
+
+	idempotencyKey, err := ticketRefundIdempotencyKey(tx, ticket.ID)
+	if err != nil {
+		return nil, "", "", err
+	}
+
+	refund := models.Refund{
+		TicketID:       &ticket.ID,
+		OrderID:        ticket.OrderID,
+		EventID:        ticket.EventID,
+		Amount:         refundAmount,
+		Currency:       ticket.Currency,
+		Reason:         reason,
+		Status:         "processing",
+		Provider:       payments.Refunds.Name(),
+		OrganizerID:    &organizerID,
+		IdempotencyKey: idempotencyKey,
+	}
+	if err := tx.Create(&refund).Error; err != nil {
+		return nil, "", "", err
+	}
+
+	return &refund, order.PaymentID, idempotencyKey, nil
+}
+
+// ticketRefundedAmount возвращает сумму успешных возвратов по тикету
+func ticketRefundedAmount(tx *gorm.DB, ticketID uint) (int64, error) {
+	var refunded int64
+	err := tx.Model(&models.Refund{}).
+		Select("COALESCE(SUM(amount), 0)").
+		Where("ticket_id = ? AND status = ?", ticketID, "succeeded").
+		Scan(&refunded).Error
+	return refunded, err
+}
+
+// ticketRefundIdempotencyKey возвращает ключ идемпотентности нового возврата по тикету -
+// номер попытки; возврат в "processing" продолжается со своим ключом
+func ticketRefundIdempotencyKey(tx *gorm.DB, ticketID uint) (string, error) {
+	var attempts int64
+	err := tx.Model(&models.Refund{}).
+		Where("ticket_id = ? AND status <> ?", ticketID, "processing").
+		Count(&attempts).Error
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("ticket-%d-refund-%d", ticketID, attempts+1), nil
+}
+
+// finishRefund фиксирует результат обращения к обработчику по возврату в
+// "processing". Если обработчик не ответил (refundErr), запись удаляется: повтор
+// возврата получит тот же ключ идемпотентности и не вернет деньги дважды
+func finishRefund(refund *models.Refund, result *payments.RefundResult, refundErr error) error {
+	return database.DB.Transaction(func(tx *gorm.DB) error {
+		var event models.Event
+		if err := lockEvent(tx, refund.EventID, &event); err != nil {
+			return err
+		}
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(refund, refund.ID).Error; err != nil {
+			return err
+		}
+		if refund.Status != "processing" {
+			// Возврат уже завершен параллельным повтором
+			return nil
+		}
+
+		if refundErr != nil {
+			return tx.Delete(refund).Error
+		}
+
+		refund.Status = result.Status
+		refund.ProviderRefundID = result.RefundID
+		refund.FailureReason = result.FailureReason
+		err := tx.Model(refund).Updates(map[string]interface{}{
+			"status":             refund.Status,
+			"provider_refund_id": refund.ProviderRefundID,
+			"failure_reason":     refund.FailureReason,
+		}).Error
+		if err != nil {
+			return err
+		}
+
+		if refund.Status != payments.StatusSucceeded {
+			return nil
+		}
+
+		// Тикет отменяется, когда возвращена вся его цена; после частичного возврата он действует
+		if refund.TicketID != nil {
+			var ticket models.Ticket
+			err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&ticket, *refund.TicketID).Error
+			if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+				return err
+			}
+			var refunded int64
+			if err == nil {
+				if refunded, err = ticketRefundedAmount(tx, ticket.ID); err != nil {
+					return err
+				}
+			}
+			if err == nil && ticket.Status == "active" && refunded >= ticket.Price {
+				if err := cancelTickets(tx, "id = ?", ticket.ID); err != nil {
+					return err
+				}
+				if err := releaseTicketSeat(tx, ticket.EventID, ticket.ParticipantID); err != nil {
+					return err
+				}
+			}
+		}
+
+		// Заказ считается возвращенным, когда полностью возвращены все его тикеты
+		if refund.OrderID != nil {
+			var notRefunded int64
+			err := tx.Model(&models.Ticket{}).
+				Where("order_id = ?", *refund.OrderID).
+				Where("price > (SELECT COALESCE(SUM(amount), 0) FROM refunds WHERE refunds.ticket_id = tickets.id AND refunds.status = ?)", "succeeded").
+				Count(&notRefunded).Error
+			if err != nil {
+				return err
+			}
+			if notRefunded == 0 {
+				if err := tx.Model(&models.Order{}).Where("id = ?", *refund.OrderID).Update("status", "refunded").Error; err != nil {
+					return err
+				}
+			}
+		}
+
+		log.Printf("Refund %d succeeded: %d %s", refund.ID, refund.Amount, refund.Currency)
+		return nil
+	})
+}
+
+// refundRecoveryBatchSize - сколько прерванных возвратов повторяется за один запуск задачи
+const refundRecoveryBatchSize = 20
+
+// StartRefundRecovery запускает задачу, повторяющую прерванные возвраты.
+// Интервал задается переменной окружения REFUND_RECOVERY_INTERVAL (по умолчанию 1m).
+func StartRefundRecovery() {
+	jobs.Every("refund-recovery", jobs.IntervalFromEnv("REFUND_RECOVERY_INTERVAL", time.Minute), RecoverRefunds)
+}
+
+// RecoverRefunds повторно отправляет обработчику возвраты, которые дольше
+// refundProcessingTimeout остаются в "processing": процесс упал во время обращения
+// к обработчику или обработчик не ответил на возврат по невыполненному заказу.
+// Ключ идемпотентности прежний, поэтому деньги не вернутся дважды, а возврат,
+// завершенный параллельным повтором, finishRefund пропускает
+func RecoverRefunds() error {
+	var refunds []models.Refund
+	err := database.DB.
+		Where("status = ? AND created_at < ?", "processing", time.Now().UTC().Add(-refundProcessingTimeout)).
+		Order("id").
+		Limit(refundRecoveryBatchSize).
+		Find(&refunds).Error
+	if err != nil {
+		return err
+	}
+
+	for i := range refunds {
+		refund := &refunds[i]
+
+		var order models.Order
+		if refund.OrderID != nil {
+			if err := database.DB.Select("id", "payment_id").First(&order, *refund.OrderID).Error; err != nil {
+				return fmt.Errorf("refund %d: %w", refund.ID, err)
+			}
+		}
+
+		result, err := payments.Refunds.Refund(context.Background(), payments.RefundRequest{
+			PaymentID:      order.PaymentID,
+			Amount:         refund.Amount,
+			Currency:       refund.Currency,
+			Reason:         refund.Reason,
+			IdempotencyKey: refund.IdempotencyKey,
+		})
+		if err != nil {
+			return fmt.Errorf("refund %d: %w", refund.ID, err)
+		}
+		if err := finishRefund(refund, result, nil); err != nil {
+			return fmt.Errorf("refund %d: %w", refund.ID, err)
+		}
+		log.Printf("Refund %d: interrupted refund resumed as %s", refund.ID, refund.Status)
+	}
+	return nil
+}
+
+// refundErrorResponse возвращает HTTP-код и тело ответа для ошибки возврата
+func refundErrorResponse(err error) (int, gin.H) {
+	var refundErr *refundError
+	switch {
+	case errors.As(err, &refundErr):
+		code := 409
+		if refundErr.Reason == "invalid_amount" {
+			code = 400
+		}
+		body := gin.H{"error": refundErr.Message, "reason": refundErr.Reason}
+		if refundErr.Deadline != nil {
+			body["deadline"] = refundErr.Deadline
+		}
+		return code, body
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		return 404, gin.H{"error": "Ticket not found"}
+	case errors.Is(err, payments.ErrProviderUnavailable):
+		return 502, gin.H{"error": "Refund processor unavailable, try again later"}
+	default:
+		log.Printf("Refund Error: %v", err)
+		return 500, gin.H{"error": "Failed to refund ticket."}
+	}
+}
+
+// @Summary Оформить возврат по тикету
+// @Description Возвращает деньги за оплаченный тикет через обработчик возвратов. Возврат может быть частичным: тикет отменяется, когда сумма возвратов достигает его цены. Учитывает политику возвратов события
+// @Tags Refunds
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "ID тикета"
+// @Param refund body models.CreateRefundRequest true "Сумма и причина возврата"
+// @Success 201 {object} models.Refund
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 409 {object} map[string]interface{} "Возврат запрещен политикой, уже выполнен или выполняется"
+// @Failure 502 {object} map[string]interface{} "Возврат отклонен или обработчик недоступен"
+// @Router /tickets/{id}/refunds [post]
+func PostTicketRefund(c *gin.Context) {
+	id := c.Param("id")
+
+	var input models.CreateRefundRequest
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+
+	organizerID, _ := currentOrganizerID(c)
+
+	refund, err := refundTicket(c.Request.Context(), id, organizerID, input.Amount, input.Reason)
+	if err != nil {
+		code, body := refundErrorResponse(err)
+		c.JSON(code, body)
+		return
+	}
+
+	if refund.Status != payments.StatusSucceeded {
+		c.JSON(502, gin.H{"error": "Refund was declined", "reason": refund.FailureReason, "refund": refund})
+		return
+	}
+
+	c.JSON(201, refund)
+}
+
+// @Summary Пакетный возврат по тикетам
+// @Description Оформляет возвраты по нескольким тикетам на оставшуюся к возврату сумму. Каждый тикет обрабатывается отдельно
+// @Tags Refunds
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param refunds body models.BulkRefundRequest true "ID тикетов и причина возврата"
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} map[string]string
+// @Router /tickets/refunds [post]
+func PostBulkRefunds(c *gin.Context) {
+	var input models.BulkRefundRequest
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+
+	organizerID, _ := currentOrganizerID(c)
+
+	results := make([]models.BulkRefundResult, 0, len(input.TicketIDs))
+	succeeded := 0
+
+	for _, ticketID := range uniqueIDs(input.TicketIDs) {
+		result := models.BulkRefundResult{TicketID: ticketID}
+
+		refund, err := refundTicket(c.Request.Context(), ticketID, organizerID, nil, input.Reason)
+		result.Refund = refund
+
+		switch {
+		case err != nil:
+			_, body := refundErrorResponse(err)
+			result.Error, _ = body["error"].(string)
+			result.Reason, _ = body["reason"].(string)
+		case result.Refund.Status != payments.StatusSucceeded:
+			result.Error = "Refund was declined"
+			result.Reason = result.Refund.FailureReason
+		default:
+			succeeded++
+		}
+
+		results = append(results, result)
+	}
+
+	c.JSON(200, gin.H{
+		"refunded": succeeded,
+		"failed":   len(results) - succeeded,
+		"results":  results,
+	})
+}
+
+// @Summary Возвраты по тикету
+// @Description Возвращает все попытки возврата по тикету
+// @Tags Refunds
+// @Accept json
+// @Produce json
+// @Param id path int true "ID тикета"
+// @Success 200 {array} models.Refund
+// @Router /tickets/{id}/refunds [get]
+func GetTicketRefunds(c *gin.Context) {
+	id := c.Param("id")
+
+	var refunds []models.Refund
+	result := database.DB.Where("ticket_id = ?", id).Order("created_at ASC, id ASC").Find(&refunds)
+	if result.Error != nil {
+		c.JSON(500, gin.H{"error": "Database error"})
+		return
+	}
+
+	c.JSON(200, refunds)
+}
+
+// @Summary Получить список возвратов
+// @Description Возвращает возвраты с пагинацией и фильтрами
+// @Tags Refunds
+// @Accept json
+// @Produce json
+// @Param range query string false "Пагинация [start, end]"
+// @Param sort query string false "Сортировка [field, order]"
+// @Param event_id query int false "Фильтр по ID события"
+// @Param status query string false "Фильтр по статусу"
+// @Success 200 {array} models.Refund
+// @Header 200 {string} X-Total-Count "Общее количество записей"
+// @Header 200 {string} Content-Range "Диапазон записей"
+// @Router /refunds [get]
+func GetRefunds(c *gin.Context) {
+	var refunds []models.Refund
+	var total int64
+
+	rangeParam := c.Query("range")
+	var start, end int = 0, 25
+	if rangeParam != "" {
+		var rangeArray []int
+		if err := json.Unmarshal([]byte(rangeParam), &rangeArray); err == nil && len(rangeArray) == 2 {
+			start = rangeArray[0]
+			end = rangeArray[1]
+		}
+	}
+
+	sortParam := c.Query("sort")
+	var sortField, sortOrder string = "id", "DESC"
+	if sortParam != "" {
+		var sortArray []string
+		if err := json.Unmarshal([]byte(sortParam), &sortArray); err == nil && len(sortArray) == 2 {
+			sortField = sortArray[0]
+			sortOrder = sortArray[1]
+		}
+	}
+
+	limit := end - start + 1
+	offset := start
+
+	query := database.DB.Model(&models.Refund{})
+	if eventID := c.Query("event_id"); eventID != "" {
+		query = query.Where("event_id = ?", eventID)
+	}
+	if status := c.Query("status"); status != "" {
+		query = query.Where("status = ?", status)
+	}
+
+	countResult := query.Count(&total)
+	if countResult.Error != nil {
+		c.JSON(500, gin.H{"error": "Failed to retrieve total record count"})
+		return
+	}
+
+	contentRange := fmt.Sprintf("refunds %d-%d/%d", start, end, total)
+
+	result := query.
+		Limit(limit).
+		Offset(offset).
+		Order(sortField + " " + sortOrder).
+		Find(&refunds)
+	if result.Error != nil {
+		c.JSON(500, gin.H{"error": "Database error"})
+		return
+	}
+
+	c.Header("Content-Range", contentRange)
+	c.Header("X-Total-Count", strconv.Itoa(int(total)))
+	c.JSON(200, refunds)
+}
@@ -0,0 +1,485 @@
+package handlers
+
+import (
+	"encoding/csv"
+	"errors"
+	"eventflow/internal/database"
+	"eventflow/internal/models"
+	"fmt"
+	"log"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Максимальная длина текстового ответа, если в вопросе не задана своя
+const defaultTextAnswerLength = 2000
+
+var formFieldKeyPattern = regexp.MustCompile(`^[a-z][a-z0-9_]*$`)
+
+// answersError - ответы не соответствуют анкете; Fields - ошибка по ключу вопроса
+type answersError struct {
+	Fields map[string]string
+}
+
+func (e *answersError) Error() string {
+	return "answers do not match the registration form"
+}
+
+func respondAnswersError(c *gin.Context, err error) bool {
+	var answersErr *answersError
+	if !errors.As(err, &answersErr) {
+		return false
+	}
+	c.JSON(400, gin.H{"error": "Invalid answers", "reason": "invalid_answers", "fields": answersErr.Fields})
+	return true
+}
+
+// validateFormFields проверяет определение анкеты: ключи уникальны, у вопросов с выбором
+// есть варианты без повторов, границы чисел согласованы
+func validateFormFields(fields []models.FormField) error {
+	keys := make(map[string]bool, len(fields))
+	for i, field := range fields {
+		if !formFieldKeyPattern.MatchString(field.Key) {
+			return fmt.Errorf("fields[%d].key must start with a letter and contain only a-z, 0-9 and _", i)
+		}
+		if keys[field.Key] {
+			return fmt.Errorf("fields[%d].key %q is duplicated", i, field.Key)
+		}
+		keys[field.Key] = true
+
+		switch field.Type {
+		case "single_choice", "multi_choice":
+			if len(field.Options) == 0 {
+				return fmt.Errorf("fields[%d].options are required for %s", i, field.Type)
+			}
+			options := make(map[string]bool, len(field.Options))
+			for _, option := range field.Options {
+				if strings.TrimSpace(option) == "" || options[option] {
+					return fmt.Errorf("fields[%d].options must be unique and non-empty", i)
+				}
+				options[option] = true
+			}
+		case "number":
+			if field.Min != nil && field.Max != nil && *field.Min > *field.Max {
+				return fmt.Errorf("fields[%d].min must not be greater than max", i)
+			}
+		}
+	}
+	return nil
+}
+
+// eventFormFields возвращает вопросы анкеты события (nil, если анкеты нет)
+func eventFormFields(db *gorm.DB, eventID uint) ([]models.FormField, error) {
+	var form models.RegistrationForm
+	err := db.Where("event_id = ?", eventID).First(&form).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return form.Fields, nil
+}
+
+// validateAnswers проверяет ответы по анкете и возвращает их в нормализованном виде:
+// строки без пробелов по краям, даты в формате YYYY-MM-DD, пустые ответы отброшены
+func validateAnswers(fields []models.FormField, answers map[string]interface{}) (map[string]interface{}, error) {
+	normalized := make(map[string]interface{}, len(fields))
+	problems := make(map[string]string)
+
+	known := make(map[string]bool, len(fields))
+	for _, field := range fields {
+		known[field.Key] = true
+
+		value, problem := normalizeAnswer(field, answers[field.Key])
+		switch {
+		case problem != "":
+			problems[field.Key] = problem
+		case value == nil && field.Required:
+			problems[field.Key] = "is required"
+		case value != nil:
+			normalized[field.Key] = value
+		}
+	}
+	for key := range answers {
+		if !known[key] {
+			problems[key] = "is not a question of the registration form"
+		}
+	}
+
+	if len(problems) > 0 {
+		return nil, &answersError{Fields: problems}
+	}
+	return normalized, nil
+}
+
+// normalizeAnswer проверяет ответ на один вопрос. Пустой ответ возвращается как nil
+func normalizeAnswer(field models.FormField, value interface{}) (interface{}, string) {
+	if value == nil {
+		return nil, ""
+	}
+
+	switch field.Type {
+	case "text":
+		s, ok := value.(string)
+		if !ok {
+			return nil, "must be a string"
+		}
+		s = strings.TrimSpace(s)
+		maxLength := defaultTextAnswerLength
+		if field.MaxLength != nil {
+			maxLength = *field.MaxLength
+		}
+		if utf8.RuneCountInString(s) > maxLength {
+			return nil, fmt.Sprintf("must be at most %d characters", maxLength)
+		}
+		if s == "" {
+			return nil, ""
+		}
+		return s, ""
+
+	case "single_choice":
+		s, ok := value.(string)
+		if !ok {
+			return nil, "must be one of the options"
+		}
+		if s == "" {
+			return nil, ""
+		}
+		if !containsString(field.Options, s) {
+			return nil, "must be one of: " + strings.Join(field.Options, ", ")
+		}
+		return s, ""
+
+	case "multi_choice":
+		items, ok := value.([]interface{})
+		if !ok {
+			return nil, "must be a list of options"
+		}
+		chosen := make([]string, 0, len(items))
+		for _, item := range items {
+			s, ok := item.(string)
+			if !ok || !containsString(field.Options, s) {
+				return nil, "must contain only: " + strings.Join(field.Options, ", ")
+			}
+			if !containsString(chosen, s) {
+				chosen = append(chosen, s)
+			}
+		}
+		if len(chosen) == 0 {
+			return nil, ""
+		}
+		return chosen, ""
+
+	case "number":
+		n, ok := value.(float64)
+		if !ok {
+			return nil, "must be a number"
+		}
+		if field.Min != nil && n < *field.Min {
+			return nil, fmt.Sprintf("must be at least %s", strconv.FormatFloat(*field.Min, 'f', -1, 64))
+		}
+		if field.Max != nil && n > *field.Max {
+			return nil, fmt.Sprintf("must be at most %s", strconv.FormatFloat(*field.Max, 'f', -1, 64))
+		}
+		return n, ""
+
+	case "date":
+		s, ok := value.(string)
+		if !ok {
+			return nil, "must be a date in YYYY-MM-DD format"
+		}
+		if s == "" {
+			return nil, ""
+		}
+		date, err := time.Parse("2006-01-02", s)
+		if err != nil {
+			return nil, "must be a date in YYYY-MM-DD format"
+		}
+		return date.Format("2006-01-02"), ""
+
+	case "consent":
+		accepted, ok := value.(bool)
+		if !ok {
+			return nil, "must be true or false"
+		}
+		// Обязательное согласие должно быть дано, а не просто передано
+		if !accepted {
+			return nil, ""
+		}
+		return true, ""
+	}
+
+	return nil, "has an unknown question type"
+}
+
+// checkRegistrationAnswers проверяет ответы по анкете события внутри транзакции
+func checkRegistrationAnswers(tx *gorm.DB, eventID uint, answers map[string]interface{}) (map[string]interface{}, error) {
+	fields, err := eventFormFields(tx, eventID)
+	if err != nil {
+		return nil, err
+	}
+	return validateAnswers(fields, answers)
+}
+
+// @Summary Анкета регистрации события
+// @Description Возвращает вопросы, на которые участник отвечает при регистрации на событие
+// @Tags EventRegistrations
+// @Produce json
+// @Param id path int true "ID события"
+// @Success 200 {object} models.RegistrationForm
+// @Failure 404 {object} map[string]string
+// @Router /events/{id}/registration-form [get]
+func GetEventRegistrationForm(c *gin.Context) {
+	var form models.RegistrationForm
+	if err := database.DB.Where("event_id = ?", c.Param("id")).First(&form).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(404, gin.H{"error": "Registration form not found"})
+		} else {
+			c.JSON(500, gin.H{"error": "Database error"})
+		}
+		return
+	}
+
+	c.JSON(200, form)
+}
+
+// @Summary Сохранить анкету регистрации события
+// @Description Создает или заменяет анкету события. Типы вопросов: text, single_choice, multi_choice, number, date, consent. Ранее данные ответы не меняются
+// @Tags EventRegistrations
+// @Accept json
+// @Produce json
+// @Param id path int true "ID события"
+// @Param form body models.SaveRegistrationFormRequest true "Вопросы анкеты"
+// @Success 200 {object} models.RegistrationForm
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /events/{id}/registration-form [put]
+func PutEventRegistrationForm(c *gin.Context) {
+	var input models.SaveRegistrationFormRequest
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+	if err := validateFormFields(input.Fields); err != nil {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+
+	var form models.RegistrationForm
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		var event models.Event
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&event, c.Param("id")).Error; err != nil {
+			return err
+		}
+
+		err := tx.Where("event_id = ?", event.ID).First(&form).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			form = models.RegistrationForm{EventID: event.ID, Fields: input.Fields}
+			return tx.Create(&form).Error
+		}
+		if err != nil {
+			return err
+		}
+
+		form.Fields = input.Fields
+		return tx.Model(&form).Select("fields", "updated_at").Updates(&form).Error
+	})
+
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(404, gin.H{"error": "Event not found"})
+			return
+		}
+		log.Printf("Database Error (Registration Form): %v", err)
+		c.JSON(500, gin.H{"error": "Failed to save registration form. Database error."})
+		return
+	}
+
+	c.JSON(200, form)
+}
+
+func DeleteEventRegistrationForm(c *gin.Context) {
+	result := database.DB.Where("event_id = ?", c.Param("id")).Delete(&models.RegistrationForm{})
+	if result.Error != nil {
+		log.Printf("Database Error (Delete): %v", result.Error)
+		c.JSON(500, gin.H{"error": "Failed to delete registration form. Database error."})
+		return
+	}
+
+	c.JSON(200, gin.H{})
+}
+
+// RegistrationAnswersRow - ответы одного участника в выгрузке
+type RegistrationAnswersRow struct {
+	RegistrationID uint                   `json:"registration_id"`
+	ParticipantID  uint                   `json:"participant_id"`
+	FullName       string                 `json:"full_name"`
+	Email          string                 `json:"email"`
+	Phone          string                 `json:"phone"`
+	Status         string                 `json:"status"`
+	RegisteredAt   time.Time              `json:"registered_at"`
+	Answers        map[string]interface{} `json:"answers"`
+}
+
+// csvAnswer форматирует ответ для ячейки CSV
+func csvAnswer(value interface{}) string {
+	switch v := value.(type) {
+	case nil:
+		return ""
+	case string:
+		return csvText(v)
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case bool:
+		if v {
+			return "yes"
+		}
+		return "no"
+	case []string:
+		items := make([]string, len(v))
+		for i, item := range v {
+			items[i] = csvText(item)
+		}
+		return strings.Join(items, "; ")
+	case []interface{}:
+		items := make([]string, len(v))
+		for i, item := range v {
+			items[i] = csvAnswer(item)
+		}
+		return strings.Join(items, "; ")
+	}
+	return csvText(fmt.Sprint(value))
+}
+
+// csvText экранирует текст, который табличный редактор принял бы за формулу
+func csvText(s string) string {
+	if s != "" && strings.ContainsRune("=+-@\t\r", rune(s[0])) {
+		return "'" + s
+	}
+	return s
+}
+
+// @Summary Выгрузка ответов на анкету
+// @Description Возвращает ответы участников события на анкету регистрации в JSON или CSV (колонки вопросов - ключи текущей анкеты)
+// @Tags EventRegistrations
+// @Produce json
+// @Produce text/csv
+// @Param id path int true "ID события"
+// @Param format query string false "Формат выгрузки" Enums(json, csv) default(json)
+// @Param status query string false "Только регистрации с этим статусом"
+// @Success 200 {array} RegistrationAnswersRow
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /events/{id}/registration-answers [get]
+func GetEventRegistrationAnswers(c *gin.Context) {
+	format := c.DefaultQuery("format", "json")
+	if format != "json" && format != "csv" {
+		c.JSON(400, gin.H{"error": "format must be either 'json' or 'csv'"})
+		return
+	}
+
+	var event models.Event
+	if err := database.DB.First(&event, c.Param("id")).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(404, gin.H{"error": "Event not found"})
+		} else {
+			c.JSON(500, gin.H{"error": "Database error"})
+		}
+		return
+	}
+
+	fields, err := eventFormFields(database.DB, event.ID)
+	if err != nil {
+		c.JSON(500, gin.H{"error": "Database error"})
+		return
+	}
+
+	query := database.DB.Where("event_id = ?", event.ID)
+	if status := c.Query("status"); status != "" {
+		query = query.Where("status = ?", status)
+	}
+	var registrations []models.EventRegistration
+	if err := query.Order("registered_at ASC, id ASC").Find(&registrations).Error; err != nil {
+		c.JSON(500, gin.H{"error": "Database error"})
+		return
+	}
+
+	participantIDs := make([]uint, len(registrations))
+	for i, registration := range registrations {
+		participantIDs[i] = registration.ParticipantID
+	}
+	var participants []models.Participant
+	if len(participantIDs) > 0 {
+		if err := database.DB.Where("id IN ?", participantIDs).Find(&participants).Error; err != nil {
+			c.JSON(500, gin.H{"error": "Database error"})
+			return
+		}
+	}
+	byID := make(map[uint]models.Participant, len(participants))
+	for _, participant := range participants {
+		byID[participant.ID] = participant
+	}
+
+	rows := make([]RegistrationAnswersRow, len(registrations))
+	for i, registration := range registrations {
+		participant := byID[registration.ParticipantID]
+		answers := registration.Answers
+		if answers == nil {
+			answers = map[string]interface{}{}
+		}
+		rows[i] = RegistrationAnswersRow{
+			RegistrationID: registration.ID,
+			ParticipantID:  registration.ParticipantID,
+			FullName:       participant.FullName,
+			Email:          participant.Email,
+			Phone:          participant.Phone,
+			Status:         registration.Status,
+			RegisteredAt:   registration.RegisteredAt.UTC(),
+			Answers:        answers,
+		}
+	}
+
+	if format == "json" {
+		c.JSON(200, rows)
+		return
+	}
+
+	c.Header("Content-Type", "text/csv; charset=utf-8")
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="event-%d-answers.csv"`, event.ID))
+	c.Status(200)
+
+	w := csv.NewWriter(c.Writer)
+	header := []string{"registration_id", "participant_id", "full_name", "email", "phone", "status", "registered_at"}
+	for _, field := range fields {
+		header = append(header, field.Key)
+	}
+	w.Write(header)
+
+	for _, row := range rows {
+		record := []string{
+			strconv.FormatUint(uint64(row.RegistrationID), 10),
+			strconv.FormatUint(uint64(row.ParticipantID), 10),
+			csvText(row.FullName),
+			csvText(row.Email),
+			csvText(row.Phone),
+			row.Status,
+			row.RegisteredAt.Format(time.RFC3339),
+		}
+		for _, field := range fields {
+			record = append(record, csvAnswer(row.Answers[field.Key]))
+		}
+		w.Write(record)
+	}
+	w.Flush()
+	if err := w.Error(); err != nil {
+		log.Printf("CSV Export Error (Event %d): %v", event.ID, err)
+	}
+}
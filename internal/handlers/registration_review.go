@@ -0,0 +1,258 @@
+package handlers
+
+import (
+	"errors"
+	"eventflow/internal/database"
+	"eventflow/internal/models"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// Допустимые переходы статуса регистрации (EventRegistration.Status).
+// Заявка "pending" при одобрении переходит в "registered" (или в лист ожидания, если мест нет).
+var registrationTransitions = map[string][]string{
+	"pending":    {"registered", "rejected", "canceled"},
+	"waitlisted": {"registered", "canceled"},
+	"registered": {"attended", "no-show", "canceled"},
+	"attended":   {"registered", "no-show"},
+	"no-show":    {"registered", "attended"},
+	"rejected":   {},
+	"canceled":   {},
+}
+
+// Статусы, с которыми может быть создана регистрация
+var initialRegistrationStatuses = []string{"registered", "pending"}
+
+var errEventFull = errors.New("event has no free seats")
+
+// registrationTransitionError описывает недопустимый переход регистрации
+type registrationTransitionError struct {
+	Reason string
+	From   string
+	To     string
+}
+
+func (e *registrationTransitionError) Error() string {
+	return fmt.Sprintf("cannot change registration status from '%s' to '%s'", e.From, e.To)
+}
+
+func checkRegistrationTransition(from, to string) error {
+	if _, ok := registrationTransitions[to]; !ok {
+		return &registrationTransitionError{Reason: "unknown_status", From: from, To: to}
+	}
+	if from == to {
+		return &registrationTransitionError{Reason: "already_in_status", From: from, To: to}
+	}
+	if !containsString(registrationTransitions[from], to) {
+		return &registrationTransitionError{Reason: "invalid_transition", From: from, To: to}
+	}
+	return nil
+}
+
+// registrationErrorResponse возвращает код и тело ответа для ошибок переходов регистрации
+func registrationErrorResponse(err error) (int, gin.H) {
+	var transitionErr *registrationTransitionError
+	var promoErr *promoError
+	switch {
+	case errors.As(err, &transitionErr):
+		code := 409
+		if transitionErr.Reason == "unknown_status" {
+			code = 400
+		}
+		return code, gin.H{
+			"error":   transitionErr.Error(),
+			"reason":  transitionErr.Reason,
+			"from":    transitionErr.From,
+			"to":      transitionErr.To,
+			"allowed": registrationTransitions[transitionErr.From],
+		}
+	case errors.Is(err, errEventFull):
+		return 409, gin.H{"error": "Event has no free seats", "reason": "event_full"}
+	case errors.Is(err, errEventClosed):
+		return 409, gin.H{"error": "Event is closed for registration", "reason": "event_closed"}
+	case errors.Is(err, errTierSoldOut):
+		return 409, gin.H{"error": "Free tickets are sold out", "reason": "sold_out"}
+	case errors.Is(err, errPaymentRequired):
+		return 409, gin.H{"error": errPaymentRequired.Error(), "reason": "payment_required"}
+	case errors.As(err, &promoErr):
+		return 409, gin.H{"error": promoErr.Message, "reason": promoErr.Reason}
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		return 404, gin.H{"error": "Event registration not found"}
+	default:
+		log.Printf("Database Error (Registration Review): %v", err)
+		return 500, gin.H{"error": "Failed to update event registration. Database error."}
+	}
+}
+
+// transitionRegistration переводит регистрацию в статус to и выполняет побочные эффекты:
+// при одобрении заявки или переводе из листа ожидания выдается тикет (с промокодом заявки),
+// при отмене отменяются тикеты участника. Событие должно быть заблокировано (lockEvent);
+// продвижение листа ожидания после отмены остается вызывающему.
+func transitionRegistration(tx *gorm.DB, event *models.Event, registration *models.EventRegistration, to string, reviewerID *uint, reason string) (*models.Ticket, error) {
+	from := registration.Status
+	if err := checkRegistrationTransition(from, to); err != nil {
+		return nil, err
+	}
+
+	columns := []string{"status"}
+	issue := to == "registered" && (from == "pending" || from == "waitlisted")
+	if issue {
+		if containsString(closedEventStatuses, event.Status) {
+			return nil, errEventClosed
+		}
+		// Одобренная заявка встает за листом ожидания, перевод из листа - решение организатора
+		seat := hasSeatBeyondWaitlist
+		if from == "waitlisted" {
+			seat = hasFreeSeat
+		}
+		free, err := seat(tx, event)
+		if err != nil {
+			return nil, err
+		}
+		if !free {
+			if from == "waitlisted" {
+				return nil, errEventFull
+			}
+			// Одобренная заявка ждет свободного места в общей очереди
+			to = "waitlisted"
+			issue = false
+		}
+	}
+	registration.Status = to
+
+	if from == "pending" {
+		now := time.Now()
+		registration.ReviewedBy = reviewerID
+		registration.ReviewedAt = &now
+		registration.ReviewReason = reason
+		columns = append(columns, "reviewed_by", "reviewed_at", "review_reason")
+	}
+
+	if err := tx.Model(registration).Select(columns).Updates(registration).Error; err != nil {
+		return nil, err
+	}
+
+	switch {
+	case issue:
+		ticket, err := issueRegistrationTicket(tx, registration.EventID, registration.ParticipantID)
+		if err != nil {
+			return nil, err
+		}
+		if from == "pending" && registration.PromoCode != "" {
+			if err := redeemPromoCode(tx, registration.PromoCode, ticket); err != nil {
+				return nil, err
+			}
+		}
+		return ticket, nil
+	case to == "waitlisted":
+		return nil, fillWaitlistPosition(tx, registration)
+	case to == "canceled":
+		return nil, cancelParticipantTickets(tx, registration.EventID, registration.ParticipantID)
+	}
+	return nil, nil
+}
+
+// reviewRegistration одобряет или отклоняет одну заявку в отдельной транзакции
+func reviewRegistration(id uint, to string, reviewerID *uint, reason string) models.RegistrationReviewResult {
+	result := models.RegistrationReviewResult{RegistrationID: id}
+
+	var registration models.EventRegistration
+	var ticket *models.Ticket
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.First(&registration, id).Error; err != nil {
+			return err
+		}
+
+		var event models.Event
+		if err := lockEvent(tx, registration.EventID, &event); err != nil {
+			return err
+		}
+		// Заявки закрытого события отменены вместе с ним, одобрить их нельзя
+		if to == "registered" && containsString(closedEventStatuses, event.Status) {
+			return errEventClosed
+		}
+
+		// Блокировка события сериализует рассмотрение: статус перечитывается под ней
+		if err := tx.First(&registration, id).Error; err != nil {
+			return err
+		}
+		if registration.Status != "pending" {
+			return &registrationTransitionError{Reason: "not_pending", From: registration.Status, To: to}
+		}
+
+		var err error
+		ticket, err = transitionRegistration(tx, &event, &registration, to, reviewerID, reason)
+		return err
+	})
+
+	if err != nil {
+		_, body := registrationErrorResponse(err)
+		result.Error, _ = body["error"].(string)
+		result.Reason, _ = body["reason"].(string)
+		return result
+	}
+	result.Registration = &registration
+	result.Ticket = ticket
+	return result
+}
+
+func reviewRegistrations(c *gin.Context, to, countKey string) {
+	var input models.ReviewRegistrationsRequest
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+
+	var reviewerID *uint
+	if organizerID, ok := currentOrganizerID(c); ok {
+		reviewerID = &organizerID
+	}
+
+	results := make([]models.RegistrationReviewResult, 0, len(input.RegistrationIDs))
+	succeeded := 0
+	for _, id := range uniqueIDs(input.RegistrationIDs) {
+		result := reviewRegistration(id, to, reviewerID, input.Reason)
+		if result.Error == "" {
+			succeeded++
+		}
+		results = append(results, result)
+	}
+
+	c.JSON(200, gin.H{
+		countKey:  succeeded,
+		"failed":  len(results) - succeeded,
+		"results": results,
+	})
+}
+
+// @Summary Одобрить заявки на регистрацию
+// @Description Переводит заявки "pending" в "registered" и выдает тикеты; если мест нет, одобренная заявка попадает в лист ожидания. Каждая заявка обрабатывается в отдельной транзакции
+// @Tags EventRegistrations
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param review body models.ReviewRegistrationsRequest true "ID заявок и комментарий"
+// @Success 200 {object} map[string]interface{} "approved, failed и результаты по каждой заявке"
+// @Failure 400 {object} map[string]string
+// @Router /event_registrations/approve [post]
+func PostApproveRegistrations(c *gin.Context) {
+	reviewRegistrations(c, "registered", "approved")
+}
+
+// @Summary Отклонить заявки на регистрацию
+// @Description Переводит заявки "pending" в "rejected". Каждая заявка обрабатывается в отдельной транзакции
+// @Tags EventRegistrations
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param review body models.ReviewRegistrationsRequest true "ID заявок и причина отказа"
+// @Success 200 {object} map[string]interface{} "rejected, failed и результаты по каждой заявке"
+// @Failure 400 {object} map[string]string
+// @Router /event_registrations/reject [post]
+func PostRejectRegistrations(c *gin.Context) {
+	reviewRegistrations(c, "rejected", "rejected")
+}
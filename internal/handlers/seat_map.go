@@ -0,0 +1,605 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"eventflow/internal/database"
+	"eventflow/internal/jobs"
+	"eventflow/internal/models"
+	"fmt"
+	"log"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var (
+	errSeatMapNotFound  = errors.New("event has no seat map")
+	errSeatMapOwner     = errors.New("venue or event of the seat map not found")
+	errSeatMapExists    = errors.New("seat map already exists for this venue or event")
+	errSeatMapDuplicate = errors.New("seat map contains duplicate seats")
+	errSeatMapInUse     = errors.New("seat map has seats bound to tickets")
+	errSeatNotOnMap     = errors.New("seat does not belong to the event's seat map")
+	errSeatTaken        = errors.New("seat is already taken")
+	errSeatHeld         = errors.New("seat is held by another buyer")
+)
+
+// seatHoldDuration - срок удержания места (SEAT_HOLD_DURATION, по умолчанию 10m)
+func seatHoldDuration() time.Duration {
+	return jobs.IntervalFromEnv("SEAT_HOLD_DURATION", 10*time.Minute)
+}
+
+func respondSeatError(c *gin.Context, err error) bool {
+	switch {
+	case errors.Is(err, errSeatMapNotFound):
+		c.JSON(404, gin.H{"error": err.Error()})
+	case errors.Is(err, errSeatMapOwner),
+		errors.Is(err, errSeatMapDuplicate),
+		errors.Is(err, errSeatNotOnMap):
+		c.JSON(400, gin.H{"error": err.Error()})
+	case errors.Is(err, errSeatMapExists):
+		c.JSON(409, gin.H{"error": err.Error(), "reason": "seat_map_exists"})
+	case errors.Is(err, errSeatMapInUse):
+		c.JSON(409, gin.H{"error": err.Error(), "reason": "seat_map_in_use"})
+	case errors.Is(err, errSeatTaken):
+		c.JSON(409, gin.H{"error": err.Error(), "reason": "seat_taken"})
+	case errors.Is(err, errSeatHeld):
+		c.JSON(409, gin.H{"error": err.Error(), "reason": "seat_held"})
+	default:
+		return false
+	}
+	return true
+}
+
+// checkSeatMapsUnbound возвращает errSeatMapInUse, если к местам схем из
+// подзапроса seatMapIDs привязаны тикеты: tickets.seat_id не дает удалить такие места
+func checkSeatMapsUnbound(tx *gorm.DB, seatMapIDs *gorm.DB) error {
+	var bound int64
+	err := tx.Model(&models.Ticket{}).
+		Where("seat_id IN (?)", tx.Model(&models.Seat{}).Select("id").Where("seat_map_id IN (?)", seatMapIDs)).
+		Count(&bound).Error
+	if err != nil {
+		return err
+	}
+	if bound > 0 {
+		return errSeatMapInUse
+	}
+	return nil
+}
+
+// eventSeatMap возвращает схему события, а если ее нет - схему площадки события
+func eventSeatMap(db *gorm.DB, event *models.Event) (*models.SeatMap, error) {
+	var seatMap models.SeatMap
+	query := db.Where("event_id = ?", event.ID)
+	if event.VenueID != nil {
+		query = query.Or("venue_id = ?", *event.VenueID)
+	}
+	err := query.Order("event_id IS NULL").First(&seatMap).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, errSeatMapNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &seatMap, nil
+}
+
+// checkEventSeats проверяет, что все места принадлежат схеме события
+func checkEventSeats(tx *gorm.DB, event *models.Event, seatIDs []uint) error {
+	seatMap, err := eventSeatMap(tx, event)
+	if err != nil {
+		return err
+	}
+
+	var count int64
+	err = tx.Model(&models.Seat{}).Where("seat_map_id = ? AND id IN ?", seatMap.ID, seatIDs).Count(&count).Error
+	if err != nil {
+		return err
+	}
+	if int(count) != len(seatIDs) {
+		return errSeatNotOnMap
+	}
+	return nil
+}
+
+// bindTicketSeat привязывает новый тикет к месту: место должно быть на схеме события
+// и не удерживаться другим покупателем. Удержание с holdToken снимается. Занятость
+// места гарантирует уникальный индекс tickets(event_id, seat_id), поэтому тикет
+// создается здесь же: при конфликте запись не вставляется и возвращается errSeatTaken.
+func bindTicketSeat(tx *gorm.DB, ticket *models.Ticket, seatID uint, holdToken string) error {
+	var event models.Event
+	if err := tx.First(&event, ticket.EventID).Error; err != nil {
+		return err
+	}
+	if err := checkEventSeats(tx, &event, []uint{seatID}); err != nil {
+		return err
+	}
+
+	var hold models.SeatHold
+	err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("event_id = ? AND seat_id = ?", ticket.EventID, seatID).
+		First(&hold).Error
+	switch {
+	case err == nil:
+		if hold.HoldToken != holdToken && hold.ExpiresAt.After(time.Now()) {
+			return errSeatHeld
+		}
+		if err := tx.Delete(&hold).Error; err != nil {
+			return err
+		}
+	case !errors.Is(err, gorm.ErrRecordNotFound):
+		return err
+	}
+
+	ticket.SeatID = &seatID
+	if ticket.Status != "active" {
+		return tx.Create(ticket).Error
+	}
+
+	result := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(ticket)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return errSeatTaken
+	}
+	return nil
+}
+
+func GetSeatMapById(c *gin.Context) {
+	id := c.Param("id")
+
+	if id == "" {
+		c.JSON(400, gin.H{"error": "ID parameter is required"})
+		return
+	}
+
+	var seatMap models.SeatMap
+
+	result := database.DB.Preload("Seats", func(db *gorm.DB) *gorm.DB {
+		return db.Order(`section, "row", id`)
+	}).First(&seatMap, id)
+
+	if result.Error != nil {
+		if errors.Is(result.Error, gorm.ErrRecordNotFound) {
+			c.JSON(404, gin.H{"error": "Seat map not found"})
+		} else {
+			c.JSON(500, gin.H{"error": "Database error"})
+		}
+		return
+	}
+
+	c.JSON(200, seatMap)
+}
+
+// @Summary Получить список схем зала
+// @Description Возвращает схемы зала (без мест) с пагинацией и фильтрами по площадке и событию
+// @Tags SeatMaps
+// @Accept json
+// @Produce json
+// @Param range query string false "Пагинация [start, end]"
+// @Param sort query string false "Сортировка [field, order]"
+// @Param venue_id query int false "Фильтр по ID площадки"
+// @Param event_id query int false "Фильтр по ID события"
+// @Success 200 {array} models.SeatMap
+// @Header 200 {string} X-Total-Count "Общее количество записей"
+// @Header 200 {string} Content-Range "Диапазон записей"
+// @Router /seat_maps [get]
+func GetSeatMaps(c *gin.Context) {
+	var seatMaps []models.SeatMap
+	var total int64
+
+	rangeParam := c.Query("range")
+	var start, end int = 0, 25
+	if rangeParam != "" {
+		var rangeArray []int
+		if err := json.Unmarshal([]byte(rangeParam), &rangeArray); err == nil && len(rangeArray) == 2 {
+			start = rangeArray[0]
+			end = rangeArray[1]
+		}
+	}
+
+	sortParam := c.Query("sort")
+	var sortField, sortOrder string = "id", "ASC"
+	if sortParam != "" {
+		var sortArray []string
+		if err := json.Unmarshal([]byte(sortParam), &sortArray); err == nil && len(sortArray) == 2 {
+			sortField = sortArray[0]
+			sortOrder = sortArray[1]
+		}
+	}
+
+	limit := end - start + 1
+	offset := start
+
+	query := database.DB.Model(&models.SeatMap{})
+	if venueID := c.Query("venue_id"); venueID != "" {
+		query = query.Where("venue_id = ?", venueID)
+	}
+	if eventID := c.Query("event_id"); eventID != "" {
+		query = query.Where("event_id = ?", eventID)
+	}
+
+	countResult := query.Count(&total)
+	if countResult.Error != nil {
+		c.JSON(500, gin.H{"error": "Failed to retrieve total record count"})
+		return
+	}
+
+	contentRange := fmt.Sprintf("seat_maps %d-%d/%d", start, end, total)
+
+	result := query.
+		Limit(limit).
+		Offset(offset).
+		Order(sortField + " " + sortOrder).
+		Find(&seatMaps)
+	if result.Error != nil {
+		c.JSON(500, gin.H{"error": "Database error"})
+		return
+	}
+
+	c.Header("Content-Range", contentRange)
+	c.Header("X-Total-Count", strconv.Itoa(int(total)))
+	c.JSON(200, seatMaps)
+}
+
+// UpdateSeatMap меняет только название: места схемы могут быть привязаны к тикетам
+func UpdateSeatMap(c *gin.Context) {
+	id := c.Param("id")
+
+	var input models.UpdateSeatMapRequest
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+
+	var seatMap models.SeatMap
+
+	result := database.DB.Model(&seatMap).Where("id = ?", id).
+		Select("name").
+		Updates(models.SeatMap{Name: input.Name})
+
+	if result.Error != nil {
+		log.Printf("Database Error (Update): %v", result.Error)
+		c.JSON(500, gin.H{"error": "Failed to update seat map. Database error."})
+		return
+	}
+
+	if result.RowsAffected == 0 {
+		c.JSON(404, gin.H{"error": "Seat map not found."})
+		return
+	}
+
+	database.DB.First(&seatMap, id)
+
+	c.JSON(200, seatMap)
+}
+
+func DeleteSeatMap(c *gin.Context) {
+	id := c.Param("id")
+
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := checkSeatMapsUnbound(tx, tx.Model(&models.SeatMap{}).Select("id").Where("id = ?", id)); err != nil {
+			return err
+		}
+		return tx.Delete(&models.SeatMap{}, id).Error
+	})
+
+	if err != nil {
+		if respondSeatError(c, err) {
+			return
+		}
+		log.Printf("Database Error (Delete): %v", err)
+		c.JSON(500, gin.H{"error": "Failed to delete seat map. Database error."})
+		return
+	}
+
+	c.JSON(200, gin.H{})
+}
+
+// @Summary Создать схему зала
+// @Description Создает схему зала для площадки или события: сектора, ряды и места с признаками доступности
+// @Tags SeatMaps
+// @Accept json
+// @Produce json
+// @Param seat_map body models.CreateSeatMapRequest true "Схема зала"
+// @Success 201 {object} models.SeatMap
+// @Failure 400 {object} map[string]string
+// @Failure 409 {object} map[string]string "Схема уже есть"
+// @Failure 500 {object} map[string]string
+// @Router /seat_maps [post]
+func PostSeatMap(c *gin.Context) {
+	var newSeatMap models.CreateSeatMapRequest
+
+	if err := c.ShouldBindJSON(&newSeatMap); err != nil {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+
+	seatMap := models.SeatMap{
+		VenueID: newSeatMap.VenueID,
+		EventID: newSeatMap.EventID,
+		Name:    newSeatMap.Name,
+	}
+
+	positions := make(map[string]bool)
+	for _, section := range newSeatMap.Sections {
+		for _, row := range section.Rows {
+			for _, seat := range row.Seats {
+				key := section.Name + "\x00" + row.Label + "\x00" + seat.Number
+				if positions[key] {
+					respondSeatError(c, errSeatMapDuplicate)
+					return
+				}
+				positions[key] = true
+
+				seatMap.Seats = append(seatMap.Seats, models.Seat{
+					Section:     section.Name,
+					Row:         row.Label,
+					Number:      seat.Number,
+					Wheelchair:  seat.Wheelchair,
+					Companion:   seat.Companion,
+					LimitedView: seat.LimitedView,
+				})
+			}
+		}
+	}
+
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		owner := tx.Model(&models.SeatMap{})
+		if seatMap.VenueID != nil {
+			var venue models.Venue
+			if err := tx.Select("id").First(&venue, *seatMap.VenueID).Error; err != nil {
+				return errSeatMapOwner
+			}
+			owner = owner.Where("venue_id = ?", *seatMap.VenueID)
+		} else {
+			var event models.Event
+			if err := tx.Select("id").First(&event, *seatMap.EventID).Error; err != nil {
+				return errSeatMapOwner
+			}
+			owner = owner.Where("event_id = ?", *seatMap.EventID)
+		}
+
+		var existing int64
+		if err := owner.Count(&existing).Error; err != nil {
+			return err
+		}
+		if existing > 0 {
+			return errSeatMapExists
+		}
+
+		return tx.Create(&seatMap).Error
+	})
+
+	if err != nil {
+		if respondSeatError(c, err) {
+			return
+		}
+		log.Printf("Database Error (Create): %v", err)
+		c.JSON(500, gin.H{"error": "Failed to create seat map. Database error."})
+		return
+	}
+
+	c.JSON(201, seatMap)
+}
+
+// @Summary Доступность мест события
+// @Description Возвращает схему зала события с состоянием каждого места: свободно, удерживается или занято
+// @Tags SeatMaps
+// @Produce json
+// @Param id path int true "ID события"
+// @Success 200 {object} map[string]interface{}
+// @Failure 404 {object} map[string]string
+// @Router /events/{id}/seats [get]
+func GetEventSeats(c *gin.Context) {
+	id := c.Param("id")
+
+	var event models.Event
+	if err := database.DB.First(&event, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(404, gin.H{"error": "Event not found"})
+		} else {
+			c.JSON(500, gin.H{"error": "Database error"})
+		}
+		return
+	}
+
+	seatMap, err := eventSeatMap(database.DB, &event)
+	if err != nil {
+		if !respondSeatError(c, err) {
+			c.JSON(500, gin.H{"error": "Database error"})
+		}
+		return
+	}
+
+	var seats []models.Seat
+	var taken []uint
+	var holds []models.SeatHold
+	now := time.Now()
+
+	if err := database.DB.Where("seat_map_id = ?", seatMap.ID).Order(`section, "row", id`).Find(&seats).Error; err != nil {
+		c.JSON(500, gin.H{"error": "Database error"})
+		return
+	}
+	err = database.DB.Model(&models.Ticket{}).
+		Where("event_id = ? AND status = ? AND seat_id IS NOT NULL", event.ID, "active").
+		Pluck("seat_id", &taken).Error
+	if err != nil {
+		c.JSON(500, gin.H{"error": "Database error"})
+		return
+	}
+	if err := database.DB.Where("event_id = ? AND expires_at > ?", event.ID, now).Find(&holds).Error; err != nil {
+		c.JSON(500, gin.H{"error": "Database error"})
+		return
+	}
+
+	takenSet := make(map[uint]bool, len(taken))
+	for _, seatID := range taken {
+		takenSet[seatID] = true
+	}
+	holdBySeat := make(map[uint]time.Time, len(holds))
+	for _, hold := range holds {
+		holdBySeat[hold.SeatID] = hold.ExpiresAt
+	}
+
+	counts := map[string]int{"available": 0, "held": 0, "taken": 0}
+	availability := make([]models.SeatAvailability, len(seats))
+	for i, seat := range seats {
+		availability[i] = models.SeatAvailability{Seat: seat, State: "available"}
+		if takenSet[seat.ID] {
+			availability[i].State = "taken"
+		} else if expiresAt, ok := holdBySeat[seat.ID]; ok {
+			availability[i].State = "held"
+			availability[i].HoldExpiresAt = &expiresAt
+		}
+		counts[availability[i].State]++
+	}
+
+	c.JSON(200, gin.H{
+		"event_id":    event.ID,
+		"seat_map_id": seatMap.ID,
+		"name":        seatMap.Name,
+		"available":   counts["available"],
+		"held":        counts["held"],
+		"taken":       counts["taken"],
+		"seats":       availability,
+	})
+}
+
+// @Summary Удержать места
+// @Description Временно удерживает места за покупателем, чтобы их не заняли другие. Удерживаются все места или ни одного; hold_token передается при оформлении тикета
+// @Tags SeatMaps
+// @Accept json
+// @Produce json
+// @Param id path int true "ID события"
+// @Param hold body models.CreateSeatHoldRequest true "Места"
+// @Success 201 {object} map[string]interface{}
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 409 {object} map[string]interface{} "Место занято или удерживается"
+// @Router /events/{id}/seat-holds [post]
+func PostEventSeatHold(c *gin.Context) {
+	id := c.Param("id")
+
+	var input models.CreateSeatHoldRequest
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+
+	seatIDs := uniqueIDs(input.SeatIDs)
+	sort.Slice(seatIDs, func(i, j int) bool { return seatIDs[i] < seatIDs[j] })
+
+	token, err := generateQRCode()
+	if err != nil {
+		c.JSON(500, gin.H{"error": "Failed to generate hold token"})
+		return
+	}
+
+	now := time.Now().UTC()
+	expiresAt := now.Add(seatHoldDuration())
+	var holds []models.SeatHold
+	var unavailable []uint
+
+	err = database.DB.Transaction(func(tx *gorm.DB) error {
+		var event models.Event
+		if err := tx.First(&event, id).Error; err != nil {
+			return err
+		}
+		if err := checkEventSeats(tx, &event, seatIDs); err != nil {
+			return err
+		}
+
+		err := tx.Model(&models.Ticket{}).
+			Where("event_id = ? AND status = ? AND seat_id IN ?", event.ID, "active", seatIDs).
+			Pluck("seat_id", &unavailable).Error
+		if err != nil {
+			return err
+		}
+		if len(unavailable) > 0 {
+			return errSeatTaken
+		}
+
+		// Истекшие удержания освобождают места; действующие блокируют их уникальным индексом
+		if err := tx.Where("event_id = ? AND seat_id IN ? AND expires_at <= ?", event.ID, seatIDs, now).
+			Delete(&models.SeatHold{}).Error; err != nil {
+			return err
+		}
+
+		for _, seatID := range seatIDs {
+			holds = append(holds, models.SeatHold{
+				EventID:       event.ID,
+				SeatID:        seatID,
+				ParticipantID: input.ParticipantID,
+				HoldToken:     token,
+				ExpiresAt:     expiresAt,
+			})
+		}
+		result := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&holds)
+		if result.Error != nil {
+			return result.Error
+		}
+		if int(result.RowsAffected) != len(holds) {
+			err := tx.Model(&models.SeatHold{}).
+				Where("event_id = ? AND seat_id IN ? AND hold_token <> ?", event.ID, seatIDs, token).
+				Pluck("seat_id", &unavailable).Error
+			if err != nil {
+				return err
+			}
+			return errSeatHeld
+		}
+		return nil
+	})
+
+	if err != nil {
+		if errors.Is(err, errSeatTaken) || errors.Is(err, errSeatHeld) {
+			reason := "seat_held"
+			if errors.Is(err, errSeatTaken) {
+				reason = "seat_taken"
+			}
+			c.JSON(409, gin.H{"error": err.Error(), "reason": reason, "seat_ids": unavailable})
+			return
+		}
+		if respondSeatError(c, err) {
+			return
+		}
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(404, gin.H{"error": "Event not found"})
+			return
+		}
+		log.Printf("Database Error (Seat hold): %v", err)
+		c.JSON(500, gin.H{"error": "Failed to hold seats. Database error."})
+		return
+	}
+
+	c.JSON(201, gin.H{
+		"hold_token": token,
+		"expires_at": expiresAt,
+		"holds":      holds,
+	})
+}
+
+// @Summary Снять удержание мест
+// @Description Освобождает все места, удержанные по hold_token
+// @Tags SeatMaps
+// @Produce json
+// @Param token path string true "hold_token"
+// @Success 200 {object} map[string]interface{}
+// @Router /seat-holds/{token} [delete]
+func DeleteSeatHold(c *gin.Context) {
+	token := c.Param("token")
+
+	result := database.DB.Where("hold_token = ?", token).Delete(&models.SeatHold{})
+	if result.Error != nil {
+		log.Printf("Database Error (Delete): %v", result.Error)
+		c.JSON(500, gin.H{"error": "Failed to release seat hold. Database error."})
+		return
+	}
+
+	c.JSON(200, gin.H{"released": result.RowsAffected})
+}
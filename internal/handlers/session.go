@@ -0,0 +1,501 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"eventflow/internal/database"
+	"eventflow/internal/models"
+	"fmt"
+	"log"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var (
+	errSessionEventNotFound = errors.New("event not found")
+	errSessionTimeRange     = errors.New("session must end after it starts and fit within the event time")
+	errTrackEventMismatch   = errors.New("track does not belong to the session event")
+	errSpeakerNotFound      = errors.New("one or more speakers not found")
+	errSessionFull          = errors.New("session is full")
+	errNotRegisteredToEvent = errors.New("participant is not registered for the event")
+	errSessionOtherEvent    = errors.New("session does not belong to the ticket event")
+)
+
+// sessionFromRequest проверяет входные данные сессии относительно события,
+// трека и спикеров и возвращает заполненную модель.
+func sessionFromRequest(db *gorm.DB, input models.CreateSessionRequest) (models.Session, error) {
+	var event models.Event
+	if err := db.First(&event, input.EventID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return models.Session{}, errSessionEventNotFound
+		}
+		return models.Session{}, err
+	}
+
+	start, end := input.StartTime.UTC(), input.EndTime.UTC()
+	if end.Before(start) || start.Before(event.StartTime) || end.After(event.EndTime) {
+		return models.Session{}, errSessionTimeRange
+	}
+
+	if input.TrackID != nil {
+		var track models.Track
+		if err := db.First(&track, *input.TrackID).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return models.Session{}, errTrackEventMismatch
+			}
+			return models.Session{}, err
+		}
+		if track.EventID != event.ID {
+			return models.Session{}, errTrackEventMismatch
+		}
+	}
+
+	speakers := []models.Speaker{}
+	if len(input.SpeakerIDs) > 0 {
+		if err := db.Where("id IN ?", input.SpeakerIDs).Find(&speakers).Error; err != nil {
+			return models.Session{}, err
+		}
+		if len(speakers) != len(uniqueIDs(input.SpeakerIDs)) {
+			return models.Session{}, errSpeakerNotFound
+		}
+	}
+
+	return models.Session{
+		EventID:     event.ID,
+		TrackID:     input.TrackID,
+		Title:       input.Title,
+		Description: input.Description,
+		StartTime:   start,
+		EndTime:     end,
+		Room:        input.Room,
+		Capacity:    input.Capacity,
+		Speakers:    speakers,
+	}, nil
+}
+
+func uniqueIDs(ids []uint) []uint {
+	seen := make(map[uint]bool, len(ids))
+	unique := make([]uint, 0, len(ids))
+	for _, id := range ids {
+		if !seen[id] {
+			seen[id] = true
+			unique = append(unique, id)
+		}
+	}
+	return unique
+}
+
+func respondSessionError(c *gin.Context, err error) bool {
+	switch {
+	case errors.Is(err, errSessionEventNotFound):
+		c.JSON(404, gin.H{"error": "Event not found"})
+	case errors.Is(err, errSessionTimeRange),
+		errors.Is(err, errTrackEventMismatch),
+		errors.Is(err, errSpeakerNotFound):
+		c.JSON(400, gin.H{"error": err.Error()})
+	default:
+		return false
+	}
+	return true
+}
+
+func GetSessionById(c *gin.Context) {
+	id := c.Param("id")
+
+	if id == "" {
+		c.JSON(400, gin.H{"error": "ID parameter is required"})
+		return
+	}
+
+	var session models.Session
+
+	result := database.DB.Preload("Speakers").First(&session, id)
+
+	if result.Error != nil {
+		if errors.Is(result.Error, gorm.ErrRecordNotFound) {
+			c.JSON(404, gin.H{"error": "Session not found"})
+		} else {
+			c.JSON(500, gin.H{"error": "Database error"})
+		}
+		return
+	}
+
+	c.JSON(200, session)
+}
+
+// @Summary Получить список сессий
+// @Description Возвращает сессии (программу) событий с пагинацией и фильтрами
+// @Tags Sessions
+// @Accept json
+// @Produce json
+// @Param range query string false "Пагинация [start, end]"
+// @Param sort query string false "Сортировка [field, order]"
+// @Param event_id query int false "Фильтр по ID события"
+// @Param track_id query int false "Фильтр по ID трека"
+// @Param speaker_id query int false "Фильтр по ID спикера"
+// @Success 200 {array} models.Session
+// @Header 200 {string} X-Total-Count "Общее количество записей"
+// @Header 200 {string} Content-Range "Диапазон записей"
+// @Router /sessions [get]
+func GetSessions(c *gin.Context) {
+	var sessions []models.Session
+	var total int64
+
+	rangeParam := c.Query("range")
+	var start, end int = 0, 25
+	if rangeParam != "" {
+		var rangeArray []int
+		if err := json.Unmarshal([]byte(rangeParam), &rangeArray); err == nil && len(rangeArray) == 2 {
+			start = rangeArray[0]
+			end = rangeArray[1]
+		}
+	}
+
+	sortParam := c.Query("sort")
+	var sortField, sortOrder string = "start_time", "ASC"
+	if sortParam != "" {
+		var sortArray []string
+		if err := json.Unmarshal([]byte(sortParam), &sortArray); err == nil && len(sortArray) == 2 {
+			sortField = sortArray[0]
+			sortOrder = sortArray[1]
+		}
+	}
+
+	limit := end - start + 1
+	offset := start
+
+	query := database.DB.Model(&models.Session{})
+	if eventID := c.Query("event_id"); eventID != "" {
+		query = query.Where("event_id = ?", eventID)
+	}
+	if trackID := c.Query("track_id"); trackID != "" {
+		query = query.Where("track_id = ?", trackID)
+	}
+	if speakerID := c.Query("speaker_id"); speakerID != "" {
+		query = query.Where("id IN (?)", database.DB.Table("session_speakers").
+			Select("session_id").Where("speaker_id = ?", speakerID))
+	}
+
+	countResult := query.Count(&total)
+	if countResult.Error != nil {
+		c.JSON(500, gin.H{"error": "Failed to retrieve total record count"})
+		return
+	}
+
+	contentRange := fmt.Sprintf("sessions %d-%d/%d", start, end, total)
+
+	result := query.
+		Preload("Speakers").
+		Limit(limit).
+		Offset(offset).
+		Order(sortField + " " + sortOrder).
+		Find(&sessions)
+	if result.Error != nil {
+		c.JSON(500, gin.H{"error": "Database error"})
+		return
+	}
+
+	c.Header("Content-Range", contentRange)
+	c.Header("X-Total-Count", strconv.Itoa(int(total)))
+	c.JSON(200, sessions)
+}
+
+func UpdateSession(c *gin.Context) {
+	id := c.Param("id")
+
+	var input models.CreateSessionRequest
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+
+	var session models.Session
+
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&session, id).Error; err != nil {
+			return err
+		}
+
+		updated, err := sessionFromRequest(tx, input)
+		if err != nil {
+			return err
+		}
+
+		err = tx.Model(&session).
+			Select("event_id", "track_id", "title", "description", "start_time", "end_time", "room", "capacity").
+			Updates(updated).Error
+		if err != nil {
+			return err
+		}
+
+		return tx.Model(&session).Association("Speakers").Replace(updated.Speakers)
+	})
+
+	if err != nil {
+		if respondSessionError(c, err) {
+			return
+		}
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(404, gin.H{"error": "Session not found."})
+			return
+		}
+		log.Printf("Database Error (Update): %v", err)
+		c.JSON(500, gin.H{"error": "Failed to update session. Database error."})
+		return
+	}
+
+	database.DB.Preload("Speakers").First(&session, id)
+
+	c.JSON(200, session)
+}
+
+func DeleteSession(c *gin.Context) {
+	id := c.Param("id")
+
+	result := database.DB.Delete(&models.Session{}, id)
+
+	if result.Error != nil {
+		log.Printf("Database Error (Delete): %v", result.Error)
+		c.JSON(500, gin.H{"error": "Failed to delete session. Database error."})
+		return
+	}
+
+	c.JSON(200, gin.H{})
+}
+
+// @Summary Создать сессию
+// @Description Создает сессию в программе события. Время сессии должно укладываться во время события
+// @Tags Sessions
+// @Accept json
+// @Produce json
+// @Param session body models.CreateSessionRequest true "Данные сессии"
+// @Success 201 {object} models.Session
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /sessions [post]
+func PostSession(c *gin.Context) {
+	var newSession models.CreateSessionRequest
+
+	if err := c.ShouldBindJSON(&newSession); err != nil {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+
+	session, err := sessionFromRequest(database.DB, newSession)
+	if err != nil {
+		if respondSessionError(c, err) {
+			return
+		}
+		c.JSON(500, gin.H{"error": "Database error"})
+		return
+	}
+
+	result := database.DB.Create(&session)
+
+	if result.Error != nil {
+		log.Printf("Database Error (Create): %v", result.Error)
+		c.JSON(500, gin.H{"error": "Failed to create session. Database error."})
+		return
+	}
+
+	c.JSON(201, session)
+}
+
+// @Summary Регистрации на сессию
+// @Description Возвращает активные регистрации участников на сессию
+// @Tags Sessions
+// @Accept json
+// @Produce json
+// @Param id path int true "ID сессии"
+// @Success 200 {array} models.SessionRegistration
+// @Failure 404 {object} map[string]string
+// @Router /sessions/{id}/registrations [get]
+func GetSessionRegistrations(c *gin.Context) {
+	id := c.Param("id")
+
+	var session models.Session
+	if err := database.DB.First(&session, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(404, gin.H{"error": "Session not found"})
+		} else {
+			c.JSON(500, gin.H{"error": "Database error"})
+		}
+		return
+	}
+
+	var registrations []models.SessionRegistration
+	result := database.DB.
+		Where("session_id = ? AND status = ?", session.ID, "registered").
+		Order("created_at ASC, id ASC").
+		Find(&registrations)
+	if result.Error != nil {
+		c.JSON(500, gin.H{"error": "Database error"})
+		return
+	}
+
+	c.JSON(200, registrations)
+}
+
+// @Summary Зарегистрироваться на сессию
+// @Description Регистрирует участника события на сессию с проверкой вместимости сессии
+// @Tags Sessions
+// @Accept json
+// @Produce json
+// @Param id path int true "ID сессии"
+// @Param registration body models.CreateSessionRegistrationRequest true "Участник"
+// @Success 201 {object} models.SessionRegistration
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 409 {object} map[string]string "Нет мест или участник уже зарегистрирован"
+// @Router /sessions/{id}/registrations [post]
+func PostSessionRegistration(c *gin.Context) {
+	id := c.Param("id")
+
+	var input models.CreateSessionRegistrationRequest
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+
+	var registration models.SessionRegistration
+	alreadyRegistered := false
+
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		// Блокировка сессии сериализует параллельные регистрации на нее
+		var session models.Session
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&session, id).Error; err != nil {
+			return err
+		}
+
+		var eventRegistrations int64
+		err := tx.Model(&models.EventRegistration{}).
+			Where("event_id = ? AND participant_id = ? AND status IN ?",
+				session.EventID, input.ParticipantID, []string{"registered", "attended"}).
+			Count(&eventRegistrations).Error
+		if err != nil {
+			return err
+		}
+		if eventRegistrations == 0 {
+			return errNotRegisteredToEvent
+		}
+
+		err = tx.Where("session_id = ? AND participant_id = ?", session.ID, input.ParticipantID).
+			First(&registration).Error
+		if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+			return err
+		}
+		if err == nil && registration.Status == "registered" {
+			alreadyRegistered = true
+			return nil
+		}
+
+		if session.Capacity != nil {
+			var taken int64
+			err := tx.Model(&models.SessionRegistration{}).
+				Where("session_id = ? AND status = ?", session.ID, "registered").
+				Count(&taken).Error
+			if err != nil {
+				return err
+			}
+			if taken >= int64(*session.Capacity) {
+				return errSessionFull
+			}
+		}
+
+		// Ранее отмененная регистрация активируется повторно
+		if registration.ID != 0 {
+			return tx.Model(&registration).Update("status", "registered").Error
+		}
+
+		registration = models.SessionRegistration{
+			SessionID:     session.ID,
+			ParticipantID: input.ParticipantID,
+			Status:        "registered",
+		}
+		return tx.Create(&registration).Error
+	})
+
+	if err != nil {
+		switch {
+		case errors.Is(err, gorm.ErrRecordNotFound):
+			c.JSON(404, gin.H{"error": "Session not found"})
+		case errors.Is(err, errNotRegisteredToEvent):
+			c.JSON(400, gin.H{"error": "Participant must be registered for the event first"})
+		case errors.Is(err, errSessionFull):
+			c.JSON(409, gin.H{"error": "Session is full", "reason": "session_full"})
+		default:
+			log.Printf("Database Error (Create): %v", err)
+			c.JSON(500, gin.H{"error": "Failed to register for session. Database error."})
+		}
+		return
+	}
+
+	if alreadyRegistered {
+		c.JSON(409, gin.H{"error": "Participant is already registered for this session", "registration": registration})
+		return
+	}
+
+	c.JSON(201, registration)
+}
+
+// @Summary Отменить регистрацию на сессию
+// @Description Отменяет регистрацию участника на сессию и освобождает место
+// @Tags Sessions
+// @Accept json
+// @Produce json
+// @Param id path int true "ID сессии"
+// @Param participant_id path int true "ID участника"
+// @Success 200 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /sessions/{id}/registrations/{participant_id} [delete]
+func DeleteSessionRegistration(c *gin.Context) {
+	id := c.Param("id")
+	participantID := c.Param("participant_id")
+
+	result := database.DB.Model(&models.SessionRegistration{}).
+		Where("session_id = ? AND participant_id = ? AND status = ?", id, participantID, "registered").
+		Update("status", "canceled")
+
+	if result.Error != nil {
+		log.Printf("Database Error (Update): %v", result.Error)
+		c.JSON(500, gin.H{"error": "Failed to cancel session registration. Database error."})
+		return
+	}
+
+	if result.RowsAffected == 0 {
+		c.JSON(404, gin.H{"error": "Session registration not found."})
+		return
+	}
+
+	c.JSON(200, gin.H{})
+}
+
+// recordSessionAttendance отмечает посещение сессии по тикету. Сессия должна
+// принадлежать событию тикета; повторное сканирование не создает дубликат.
+func recordSessionAttendance(db *gorm.DB, sessionID string, ticket models.Ticket) (*models.Session, error) {
+	var session models.Session
+	if err := db.First(&session, sessionID).Error; err != nil {
+		return nil, err
+	}
+	if session.EventID != ticket.EventID {
+		return &session, errSessionOtherEvent
+	}
+
+	attendance := models.SessionAttendance{
+		SessionID:     session.ID,
+		ParticipantID: ticket.ParticipantID,
+		TicketID:      ticket.ID,
+		CheckedInAt:   time.Now().UTC(),
+	}
+	err := db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "session_id"}, {Name: "participant_id"}},
+		DoNothing: true,
+	}).Create(&attendance).Error
+
+	return &session, err
+}
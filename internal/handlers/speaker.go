@@ -0,0 +1,187 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"eventflow/internal/database"
+	"eventflow/internal/models"
+	"fmt"
+	"log"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+func GetSpeakerById(c *gin.Context) {
+	id := c.Param("id")
+
+	if id == "" {
+		c.JSON(400, gin.H{"error": "ID parameter is required"})
+		return
+	}
+
+	var speaker models.Speaker
+
+	result := database.DB.First(&speaker, id)
+
+	if result.Error != nil {
+		if errors.Is(result.Error, gorm.ErrRecordNotFound) {
+			c.JSON(404, gin.H{"error": "Speaker not found"})
+		} else {
+			c.JSON(500, gin.H{"error": "Database error"})
+		}
+		return
+	}
+
+	c.JSON(200, speaker)
+}
+
+// @Summary Получить список спикеров
+// @Description Возвращает список спикеров с пагинацией
+// @Tags Speakers
+// @Accept json
+// @Produce json
+// @Param range query string false "Пагинация [start, end]"
+// @Param sort query string false "Сортировка [field, order]"
+// @Success 200 {array} models.Speaker
+// @Header 200 {string} X-Total-Count "Общее количество записей"
+// @Header 200 {string} Content-Range "Диапазон записей"
+// @Router /speakers [get]
+func GetSpeakers(c *gin.Context) {
+	var speakers []models.Speaker
+	var total int64
+
+	rangeParam := c.Query("range")
+	var start, end int = 0, 25
+	if rangeParam != "" {
+		var rangeArray []int
+		if err := json.Unmarshal([]byte(rangeParam), &rangeArray); err == nil && len(rangeArray) == 2 {
+			start = rangeArray[0]
+			end = rangeArray[1]
+		}
+	}
+
+	sortParam := c.Query("sort")
+	var sortField, sortOrder string = "id", "ASC"
+	if sortParam != "" {
+		var sortArray []string
+		if err := json.Unmarshal([]byte(sortParam), &sortArray); err == nil && len(sortArray) == 2 {
+			sortField = sortArray[0]
+			sortOrder = sortArray[1]
+		}
+	}
+
+	limit := end - start + 1
+	offset := start
+
+	countResult := database.DB.Model(&models.Speaker{}).Count(&total)
+	if countResult.Error != nil {
+		c.JSON(500, gin.H{"error": "Failed to retrieve total record count"})
+		return
+	}
+
+	contentRange := fmt.Sprintf("speakers %d-%d/%d", start, end, total)
+
+	result := database.DB.
+		Limit(limit).
+		Offset(offset).
+		Order(sortField + " " + sortOrder).
+		Find(&speakers)
+	if result.Error != nil {
+		c.JSON(500, gin.H{"error": "Database error"})
+		return
+	}
+
+	c.Header("Content-Range", contentRange)
+	c.Header("X-Total-Count", strconv.Itoa(int(total)))
+	c.JSON(200, speakers)
+}
+
+func UpdateSpeaker(c *gin.Context) {
+	id := c.Param("id")
+
+	var input models.CreateSpeakerRequest
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+
+	var speaker models.Speaker
+
+	result := database.DB.Model(&speaker).Where("id = ?", id).
+		Select("full_name", "email", "company", "bio", "photo_url").
+		Updates(models.Speaker{
+			FullName: input.FullName,
+			Email:    input.Email,
+			Company:  input.Company,
+			Bio:      input.Bio,
+			PhotoURL: input.PhotoURL,
+		})
+
+	if result.Error != nil {
+		log.Printf("Database Error (Update): %v", result.Error)
+		c.JSON(500, gin.H{"error": "Failed to update speaker. Database error."})
+		return
+	}
+
+	if result.RowsAffected == 0 {
+		c.JSON(404, gin.H{"error": "Speaker not found."})
+		return
+	}
+
+	database.DB.First(&speaker, id)
+
+	c.JSON(200, speaker)
+}
+
+func DeleteSpeaker(c *gin.Context) {
+	id := c.Param("id")
+
+	result := database.DB.Delete(&models.Speaker{}, id)
+
+	if result.Error != nil {
+		log.Printf("Database Error (Delete): %v", result.Error)
+		c.JSON(500, gin.H{"error": "Failed to delete speaker. Database error."})
+		return
+	}
+
+	c.JSON(200, gin.H{})
+}
+
+// @Summary Создать спикера
+// @Description Создает спикера, которого можно назначить на сессии событий
+// @Tags Speakers
+// @Accept json
+// @Produce json
+// @Param speaker body models.CreateSpeakerRequest true "Данные спикера"
+// @Success 201 {object} models.Speaker
+// @Failure 400 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /speakers [post]
+func PostSpeaker(c *gin.Context) {
+	var newSpeaker models.CreateSpeakerRequest
+
+	if err := c.ShouldBindJSON(&newSpeaker); err != nil {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+
+	speaker := models.Speaker{
+		FullName: newSpeaker.FullName,
+		Email:    newSpeaker.Email,
+		Company:  newSpeaker.Company,
+		Bio:      newSpeaker.Bio,
+		PhotoURL: newSpeaker.PhotoURL,
+	}
+
+	result := database.DB.Create(&speaker)
+
+	if result.Error != nil {
+		log.Printf("Database Error (Create): %v", result.Error)
+		c.JSON(500, gin.H{"error": "Failed to create speaker. Database error."})
+		return
+	}
+
+	c.JSON(201, speaker)
+}
@@ -10,9 +10,11 @@ import (
 	"fmt"
 	"log"
 	"strconv"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 func generateQRCode() (string, error) {
@@ -23,6 +25,61 @@ func generateQRCode() (string, error) {
 	return hex.EncodeToString(bytes), nil
 }
 
+// issueTicket создает активный тикет с новым QR-кодом в рамках переданной транзакции.
+// Остаток категории tier должен быть уже списан (reserveTier); без категории
+// выдается бесплатный тикет.
+func issueTicket(tx *gorm.DB, eventID, participantID uint, tier *models.TicketTier) (*models.Ticket, error) {
+	qrCode, err := generateQRCode()
+	if err != nil {
+		return nil, err
+	}
+
+	ticket := models.Ticket{
+		EventID:       eventID,
+		ParticipantID: participantID,
+		TicketType:    "free",
+		Status:        "active",
+		QRCode:        qrCode,
+	}
+	if tier != nil {
+		ticket.TierID = &tier.ID
+		ticket.TicketType = tier.Name
+		ticket.Price = tier.Price
+		ticket.Currency = tier.Currency
+	}
+
+	if err := tx.Create(&ticket).Error; err != nil {
+		return nil, err
+	}
+
+	return &ticket, nil
+}
+
+// releaseTicketSeat вызывается после отмены тикета: если у участника не осталось
+// активных тикетов на событие, регистрация отменяется, а место переходит
+// следующему участнику из листа ожидания.
+func releaseTicketSeat(tx *gorm.DB, eventID, participantID uint) error {
+	var active int64
+	err := tx.Model(&models.Ticket{}).
+		Where("event_id = ? AND participant_id = ? AND status = ?", eventID, participantID, "active").
+		Count(&active).Error
+	if err != nil {
+		return err
+	}
+	if active > 0 {
+		return nil
+	}
+
+	if err := tx.Model(&models.EventRegistration{}).
+		Where("event_id = ? AND participant_id = ? AND status = ?", eventID, participantID, "registered").
+		Update("status", "canceled").Error; err != nil {
+		return err
+	}
+
+	_, err = promoteWaitlist(tx, eventID)
+	return err
+}
+
 func GetTicketById(c *gin.Context) {
 	id := c.Param("id")
 
@@ -108,6 +165,9 @@ func GetTickets(c *gin.Context) {
 	c.JSON(200, tickets)
 }
 
+// errTicketManualExpiry - статус "expired" выставляет только закрытие посещаемости события
+var errTicketManualExpiry = errors.New("status 'expired' is set only when the event attendance is closed")
+
 func UpdateTicket(c *gin.Context) {
 	id := c.Param("id")
 
@@ -117,34 +177,84 @@ func UpdateTicket(c *gin.Context) {
 		return
 	}
 
-	if input.TicketType != "free" && input.TicketType != "paid" {
-		c.JSON(400, gin.H{"error": "Ticket type must be either 'free' or 'paid'"})
-		return
-	}
-
-	if input.Status != "active" && input.Status != "canceled" {
-		c.JSON(400, gin.H{"error": "Status must be either 'active' or 'canceled'"})
+	if input.Status != "active" && input.Status != "canceled" && input.Status != "expired" {
+		c.JSON(400, gin.H{"error": "Status must be one of 'active', 'canceled' or 'expired'"})
 		return
 	}
 
 	var ticket models.Ticket
 
-	result := database.DB.Model(&ticket).Where("id = ?", id).Updates(models.Ticket{
-		TicketType: input.TicketType,
-		Status:     input.Status,
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.First(&ticket, id).Error; err != nil {
+			return err
+		}
+
+		var event models.Event
+		if err := lockEvent(tx, ticket.EventID, &event); err != nil {
+			return err
+		}
+
+		if input.Status == "expired" && ticket.Status != "expired" {
+			return errTicketManualExpiry
+		}
+
+		ticketType := ticket.TicketType
+		if input.TicketType != "" && input.TicketType != ticket.TicketType {
+			if ticket.TierID != nil {
+				return errTierTicketType
+			}
+			if input.TicketType != "free" && input.TicketType != "paid" {
+				return errLegacyTicketType
+			}
+			ticketType = input.TicketType
+		}
+
+		// Истекший тикет по-прежнему занимает билет категории и место
+		wasActive := ticket.Status != "canceled"
+
+		// Повторная активация снова занимает билет категории
+		if !wasActive && input.Status == "active" && ticket.TierID != nil {
+			if err := takeTierInventory(tx, *ticket.TierID, 1); err != nil {
+				return err
+			}
+		}
+		if wasActive && input.Status == "canceled" && ticket.TierID != nil {
+			if err := releaseTierInventory(tx, *ticket.TierID, 1); err != nil {
+				return err
+			}
+		}
+
+		if err := tx.Model(&ticket).Updates(models.Ticket{
+			TicketType: ticketType,
+			Status:     input.Status,
+		}).Error; err != nil {
+			return err
+		}
+
+		if !wasActive || input.Status != "canceled" {
+			return nil
+		}
+
+		return releaseTicketSeat(tx, ticket.EventID, ticket.ParticipantID)
 	})
 
-	if result.Error != nil {
-		log.Printf("Database Error (Update): %v", result.Error)
+	if err != nil {
+		if respondTierError(c, err) {
+			return
+		}
+		if errors.Is(err, errTicketManualExpiry) {
+			c.JSON(400, gin.H{"error": err.Error()})
+			return
+		}
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(404, gin.H{"error": "Ticket not found."})
+			return
+		}
+		log.Printf("Database Error (Update): %v", err)
 		c.JSON(500, gin.H{"error": "Failed to update ticket. Database error."})
 		return
 	}
 
-	if result.RowsAffected == 0 {
-		c.JSON(404, gin.H{"error": "Ticket not found."})
-		return
-	}
-
 	database.DB.First(&ticket, id)
 
 	c.JSON(200, ticket)
@@ -153,10 +263,27 @@ func UpdateTicket(c *gin.Context) {
 func DeleteTicket(c *gin.Context) {
 	id := c.Param("id")
 
-	result := database.DB.Delete(&models.Ticket{}, id)
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		var ticket models.Ticket
+		err := tx.First(&ticket, id).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
 
-	if result.Error != nil {
-		log.Printf("Database Error (Delete): %v", result.Error)
+		if ticket.Status == "active" && ticket.TierID != nil {
+			if err := releaseTierInventory(tx, *ticket.TierID, 1); err != nil {
+				return err
+			}
+		}
+
+		return tx.Delete(&ticket).Error
+	})
+
+	if err != nil {
+		log.Printf("Database Error (Delete): %v", err)
 		c.JSON(500, gin.H{"error": "Failed to delete ticket. Database error."})
 		return
 	}
@@ -165,13 +292,15 @@ func DeleteTicket(c *gin.Context) {
 }
 
 // @Summary Создать тикет
-// @Description Создает новый тикет с уникальным QR-кодом
+// @Description Создает новый тикет с уникальным QR-кодом. При указании tier_id тикет получает цену категории, а ее остаток уменьшается; тикеты платных категорий выдаются только через заказы (409 payment_required). Промокод уменьшает цену тикета
 // @Tags Tickets
 // @Accept json
 // @Produce json
 // @Param ticket body models.CreateTicketRequest true "Данные тикета"
 // @Success 201 {object} models.Ticket
 // @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string "Категория не найдена"
+// @Failure 409 {object} map[string]string "Категория распродана, вне окна продаж или лимит промокода исчерпан"
 // @Failure 500 {object} map[string]string
 // @Router /tickets [post]
 func PostTicket(c *gin.Context) {
@@ -182,8 +311,8 @@ func PostTicket(c *gin.Context) {
 		return
 	}
 
-	if newTicket.TicketType != "free" && newTicket.TicketType != "paid" {
-		c.JSON(400, gin.H{"error": "Ticket type must be either 'free' or 'paid'"})
+	if newTicket.TierID == nil && newTicket.TicketType != "free" && newTicket.TicketType != "paid" {
+		c.JSON(400, gin.H{"error": "Ticket type must be either 'free' or 'paid' when no tier is given"})
 		return
 	}
 
@@ -207,10 +336,55 @@ func PostTicket(c *gin.Context) {
 		QRCode:        qrCode,
 	}
 
-	result := database.DB.Create(&ticket)
+	err = database.DB.Transaction(func(tx *gorm.DB) error {
+		if newTicket.TierID != nil {
+			var tier *models.TicketTier
+			var err error
+			if newTicket.Status == "active" {
+				tier, err = reserveTier(tx, *newTicket.TierID, newTicket.EventID, 1)
+			} else {
+				// Отмененный тикет не занимает остаток категории
+				tier = &models.TicketTier{}
+				err = tx.First(tier, *newTicket.TierID).Error
+				if errors.Is(err, gorm.ErrRecordNotFound) {
+					err = errTierNotFound
+				} else if err == nil && tier.EventID != newTicket.EventID {
+					err = errTierOtherEvent
+				}
+			}
+			if err != nil {
+				return err
+			}
+			// Платные тикеты выдаются только по оплаченному заказу
+			if tier.Price > 0 {
+				return errPaymentRequired
+			}
+
+			ticket.TierID = &tier.ID
+			ticket.TicketType = tier.Name
+			ticket.Price = tier.Price
+			ticket.Currency = tier.Currency
+		}
 
-	if result.Error != nil {
-		log.Printf("Database Error (Create): %v", result.Error)
+		if newTicket.SeatID != nil {
+			if err := bindTicketSeat(tx, &ticket, *newTicket.SeatID, newTicket.HoldToken); err != nil {
+				return err
+			}
+		} else if err := tx.Create(&ticket).Error; err != nil {
+			return err
+		}
+
+		if newTicket.PromoCode != "" {
+			return redeemPromoCode(tx, newTicket.PromoCode, &ticket)
+		}
+		return nil
+	})
+
+	if err != nil {
+		if respondTierError(c, err) || respondPromoError(c, err) || respondSeatError(c, err) {
+			return
+		}
+		log.Printf("Database Error (Create): %v", err)
 		c.JSON(500, gin.H{"error": "Failed to create ticket. Database error."})
 		return
 	}
@@ -219,11 +393,11 @@ func PostTicket(c *gin.Context) {
 }
 
 // @Summary Получить тикет по QR-коду
-// @Description Получение тикета по QR-коду для отслеживания посещаемости
+// @Description Получение тикета по QR-коду для отслеживания посещаемости. Принимает подписанный токен или случайный код тикетов, выпущенных раньше
 // @Tags Tickets
 // @Accept json
 // @Produce json
-// @Param qrcode path string true "QR-код тикета"
+// @Param qrcode path string true "Содержимое QR-кода: токен или код тикета"
 // @Success 200 {object} models.Ticket
 // @Failure 400 {object} map[string]string
 // @Failure 404 {object} map[string]string
@@ -236,31 +410,34 @@ func GetTicketByQRCode(c *gin.Context) {
 		return
 	}
 
-	var ticket models.Ticket
-
-	result := database.DB.Where("qr_code = ?", qrCode).First(&ticket)
-
-	if result.Error != nil {
-		if errors.Is(result.Error, gorm.ErrRecordNotFound) {
-			c.JSON(404, gin.H{"error": "Ticket not found"})
-		} else {
-			c.JSON(500, gin.H{"error": "Database error"})
-		}
+	ticket, err := findTicketByCode(database.DB, qrCode, time.Now())
+	if err != nil {
+		respondTicketCodeError(c, err)
 		return
 	}
 
 	c.JSON(200, ticket)
 }
 
+var (
+	errTicketCanceled = errors.New("ticket is canceled")
+	errTicketExpired  = errors.New("ticket has expired")
+)
+
 // @Summary Отметить тикет как использованный
-// @Description Отметить тикет как использованный для отслеживания посещаемости
+// @Description Отметить тикет как использованный для отслеживания посещаемости. Сканирование на входе записывается в журнал проходов и проверяется по политике прохода события; сканирование на входе в сессию (session_id) отмечает посещение сессии и записывается в журнал без проверки политики прохода
 // @Tags Tickets
 // @Accept json
 // @Produce json
-// @Param qrcode path string true "QR-код тикета"
+// @Param qrcode path string true "Содержимое QR-кода: токен или код тикета"
+// @Param session_id query int false "ID сессии для отметки посещения сессии"
+// @Param direction query string false "Направление прохода: in или out" default(in)
+// @Param gate query string false "Вход (ворота)"
+// @Param device_id query string false "ID сканирующего устройства"
 // @Success 200 {object} models.Ticket
 // @Failure 400 {object} map[string]string
 // @Failure 404 {object} map[string]string
+// @Failure 409 {object} map[string]interface{} "Проход запрещен; previous_scan_at - время более раннего сканирования"
 // @Router /tickets/qr/{qrcode}/use [post]
 func MarkTicketAsUsed(c *gin.Context) {
 	qrCode := c.Param("qrcode")
@@ -270,33 +447,111 @@ func MarkTicketAsUsed(c *gin.Context) {
 		return
 	}
 
-	var ticket models.Ticket
+	ticket, err := findTicketByCode(database.DB, qrCode, time.Now())
+	if err != nil {
+		respondTicketCodeError(c, err)
+		return
+	}
 
-	result := database.DB.Where("qr_code = ?", qrCode).First(&ticket)
-	if result.Error != nil {
-		if errors.Is(result.Error, gorm.ErrRecordNotFound) {
-			c.JSON(404, gin.H{"error": "Ticket not found"})
-		} else {
+	direction := c.DefaultQuery("direction", "in")
+	if direction != "in" && direction != "out" {
+		c.JSON(400, gin.H{"error": "Direction must be either 'in' or 'out'"})
+		return
+	}
+
+	// Каждое сканирование записывается в журнал проходов. Проход на событие проверяется
+	// по политике прохода, регистрация отмечается посещенной при принятом входе;
+	// сканирование на входе в сессию отмечает посещение сессии и события, но в политике
+	// прохода не учитывается
+	checkIn := &models.CheckIn{
+		EventID:       ticket.EventID,
+		TicketID:      ticket.ID,
+		ParticipantID: ticket.ParticipantID,
+		DeviceID:      c.Query("device_id"),
+		Gate:          c.Query("gate"),
+		Direction:     direction,
+		ScannedAt:     time.Now().UTC(),
+		Source:        "online",
+	}
+	if organizerID, ok := currentOrganizerID(c); ok {
+		checkIn.OperatorID = &organizerID
+	}
+
+	var session *models.Session
+	var denied *checkInError
+	err = database.DB.Transaction(func(tx *gorm.DB) error {
+		// Статус тикета проверяется под блокировкой строки: параллельная отмена, возврат
+		// или другое сканирование того же тикета дожидаются конца транзакции
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&ticket, ticket.ID).Error; err != nil {
+			return err
+		}
+		switch ticket.Status {
+		case "canceled":
+			return errTicketCanceled
+		case "expired":
+			return errTicketExpired
+		}
+
+		if sessionID := c.Query("session_id"); sessionID != "" {
+			var err error
+			session, err = recordSessionAttendance(tx, sessionID, ticket)
+			if err != nil {
+				return err
+			}
+			checkIn.SessionID = &session.ID
+			checkIn.Status = "accepted"
+			if err := tx.Create(checkIn).Error; err != nil {
+				return err
+			}
+			return tx.Model(&models.EventRegistration{}).
+				Where("event_id = ? AND participant_id = ?", ticket.EventID, ticket.ParticipantID).
+				Update("status", "attended").Error
+		}
+
+		var event models.Event
+		if err := tx.First(&event, ticket.EventID).Error; err != nil {
+			return err
+		}
+		var err error
+		denied, _, err = admitCheckIn(tx, &event, checkIn)
+		return err
+	})
+
+	if err != nil {
+		switch {
+		case errors.Is(err, errTicketCanceled):
+			c.JSON(400, gin.H{"error": "Ticket is canceled and cannot be used"})
+		case errors.Is(err, errTicketExpired):
+			c.JSON(400, gin.H{"error": "Ticket has expired and cannot be used"})
+		case errors.Is(err, gorm.ErrRecordNotFound):
+			c.JSON(404, gin.H{"error": "Session not found"})
+		case errors.Is(err, errSessionOtherEvent):
+			c.JSON(400, gin.H{"error": "Session does not belong to the ticket's event"})
+		default:
+			log.Printf("Database Error (Check-in): %v", err)
 			c.JSON(500, gin.H{"error": "Database error"})
 		}
 		return
 	}
 
-	if ticket.Status == "canceled" {
-		c.JSON(400, gin.H{"error": "Ticket is canceled and cannot be used"})
+	if denied != nil {
+		response := gin.H{"error": denied.Message, "reason": denied.Reason, "check_in": checkIn}
+		if denied.Previous != nil {
+			response["previous_scan_at"] = denied.Previous.ScannedAt
+			response["previous_check_in"] = denied.Previous
+		}
+		c.JSON(409, response)
 		return
 	}
 
 	var registration models.EventRegistration
 	regResult := database.DB.Where("event_id = ? AND participant_id = ?", ticket.EventID, ticket.ParticipantID).First(&registration)
 
-	if regResult.Error == nil {
-		database.DB.Model(&registration).Update("status", "attended")
-	}
-
 	c.JSON(200, gin.H{
-		"message":        "Ticket successfully used. Attendance recorded.",
-		"ticket":         ticket,
-		"attendance_marked": regResult.Error == nil,
+		"message":           "Ticket successfully used. Attendance recorded.",
+		"ticket":            ticket,
+		"attendance_marked": regResult.Error == nil && (session != nil || direction == "in"),
+		"session":           session,
+		"check_in":          checkIn,
 	})
 }
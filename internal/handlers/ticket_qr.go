@@ -0,0 +1,134 @@
+package handlers
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"eventflow/internal/database"
+	"eventflow/internal/models"
+	"eventflow/internal/qrcode"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+const (
+	defaultQRImageSize = 256
+	minQRImageSize     = 64
+	maxQRImageSize     = 2048
+)
+
+// ticketQR находит тикет и кодирует его QR-код с параметрами запроса size и ecl.
+// Ответ уже отправлен, если ok == false.
+func ticketQR(c *gin.Context) (ticket models.Ticket, code *qrcode.Code, size int, ok bool) {
+	id := c.Param("id")
+
+	size = defaultQRImageSize
+	if value := c.Query("size"); value != "" {
+		parsed, err := strconv.Atoi(value)
+		if err != nil || parsed < minQRImageSize || parsed > maxQRImageSize {
+			c.JSON(400, gin.H{"error": fmt.Sprintf("size must be an integer between %d and %d", minQRImageSize, maxQRImageSize)})
+			return
+		}
+		size = parsed
+	}
+
+	level := qrcode.Medium
+	if value := c.Query("ecl"); value != "" {
+		parsed, err := qrcode.ParseLevel(value)
+		if err != nil {
+			c.JSON(400, gin.H{"error": "ecl must be one of L, M, Q, H"})
+			return
+		}
+		level = parsed
+	}
+
+	if err := database.DB.First(&ticket, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(404, gin.H{"error": "Ticket not found"})
+		} else {
+			c.JSON(500, gin.H{"error": "Database error"})
+		}
+		return
+	}
+
+	// В QR-код кодируется подписанный токен; ETag зависит от него, поэтому после
+	// смены кода или переноса события кэш устаревает
+	payload, err := ticketQRPayload(ticket)
+	if err != nil {
+		c.JSON(500, gin.H{"error": "Database error"})
+		return
+	}
+
+	sum := sha256.Sum256([]byte(fmt.Sprintf("%s|%d|%d", payload, size, level)))
+	etag := `"` + hex.EncodeToString(sum[:8]) + `"`
+	c.Header("ETag", etag)
+	c.Header("Cache-Control", "private, max-age=300")
+	c.Header("Last-Modified", ticket.UpdatedAt.UTC().Format(http.TimeFormat))
+
+	if c.GetHeader("If-None-Match") == etag {
+		c.Status(http.StatusNotModified)
+		return
+	}
+
+	code, err = qrcode.Encode([]byte(payload), level)
+	if err != nil {
+		c.JSON(500, gin.H{"error": "Failed to encode QR code"})
+		return
+	}
+
+	return ticket, code, size, true
+}
+
+// @Summary QR-код тикета в PNG
+// @Description Возвращает QR-код тикета как PNG-изображение для писем, печати и киосков
+// @Tags Tickets
+// @Produce png
+// @Param id path int true "ID тикета"
+// @Param size query int false "Размер изображения в пикселях (64-2048, по умолчанию 256)"
+// @Param ecl query string false "Уровень коррекции ошибок: L, M, Q или H (по умолчанию M)"
+// @Success 200 {file} binary
+// @Success 304 "Не изменилось (If-None-Match)"
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /tickets/{id}/qr.png [get]
+func GetTicketQRPNG(c *gin.Context) {
+	ticket, code, size, ok := ticketQR(c)
+	if !ok {
+		return
+	}
+
+	image, err := code.PNG(size)
+	if err != nil {
+		c.JSON(500, gin.H{"error": "Failed to render QR code"})
+		return
+	}
+
+	c.Header("Content-Disposition", fmt.Sprintf(`inline; filename="ticket-%d-qr.png"`, ticket.ID))
+	c.Data(200, "image/png", image)
+}
+
+// @Summary QR-код тикета в SVG
+// @Description Возвращает QR-код тикета как SVG-изображение
+// @Tags Tickets
+// @Produce image/svg+xml
+// @Param id path int true "ID тикета"
+// @Param size query int false "Размер изображения в пикселях (64-2048, по умолчанию 256)"
+// @Param ecl query string false "Уровень коррекции ошибок: L, M, Q или H (по умолчанию M)"
+// @Success 200 {string} string
+// @Success 304 "Не изменилось (If-None-Match)"
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /tickets/{id}/qr.svg [get]
+func GetTicketQRSVG(c *gin.Context) {
+	ticket, code, size, ok := ticketQR(c)
+	if !ok {
+		return
+	}
+
+	c.Header("Content-Disposition", fmt.Sprintf(`inline; filename="ticket-%d-qr.svg"`, ticket.ID))
+	c.Data(200, "image/svg+xml; charset=utf-8", []byte(code.SVG(size)))
+}
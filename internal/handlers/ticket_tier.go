@@ -0,0 +1,406 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"eventflow/internal/database"
+	"eventflow/internal/models"
+	"fmt"
+	"log"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var (
+	errTierNotFound     = errors.New("ticket tier not found")
+	errTierOtherEvent   = errors.New("ticket tier does not belong to the event")
+	errTierNotOnSale    = errors.New("ticket tier is not on sale")
+	errTierSoldOut      = errors.New("ticket tier is sold out")
+	errTierOrderLimit   = errors.New("quantity exceeds the ticket tier per-order limit")
+	errTierTicketType   = errors.New("ticket type of a tier ticket is defined by its tier")
+	errTierBelowSold    = errors.New("quantity cannot be less than the number of sold tickets")
+	errTierHasTickets   = errors.New("ticket tier has issued tickets")
+	errTierSalesWindow  = errors.New("sales_end must be after sales_start")
+	errLegacyTicketType = errors.New("ticket type must be either 'free' or 'paid'")
+	errPaymentRequired  = errors.New("event has no free ticket tier on sale, tickets must be bought with an order")
+)
+
+// takeTierInventory атомарно списывает qty билетов категории. Условие в UPDATE
+// не дает продать больше quantity даже при параллельных запросах.
+func takeTierInventory(tx *gorm.DB, tierID uint, qty int) error {
+	result := tx.Model(&models.TicketTier{}).
+		Where("id = ? AND sold + ? <= quantity", tierID, qty).
+		Update("sold", gorm.Expr("sold + ?", qty))
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return errTierSoldOut
+	}
+	return nil
+}
+
+// releaseTierInventory возвращает qty билетов в продажу
+func releaseTierInventory(tx *gorm.DB, tierID uint, qty int) error {
+	return tx.Model(&models.TicketTier{}).
+		Where("id = ?", tierID).
+		Update("sold", gorm.Expr("GREATEST(sold - ?, 0)", qty)).Error
+}
+
+// reserveTier проверяет категорию (событие, окно продаж, лимит на заказ)
+// и списывает qty билетов из ее остатка.
+func reserveTier(tx *gorm.DB, tierID, eventID uint, qty int) (*models.TicketTier, error) {
+	var tier models.TicketTier
+	if err := tx.First(&tier, tierID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errTierNotFound
+		}
+		return nil, err
+	}
+
+	if tier.EventID != eventID {
+		return nil, errTierOtherEvent
+	}
+	if !tier.OnSale(time.Now().UTC()) {
+		return nil, errTierNotOnSale
+	}
+	if tier.MaxPerOrder != nil && qty > *tier.MaxPerOrder {
+		return nil, errTierOrderLimit
+	}
+
+	if err := takeTierInventory(tx, tier.ID, qty); err != nil {
+		return nil, err
+	}
+
+	tier.Sold += qty
+	tier.Available -= qty
+	return &tier, nil
+}
+
+// cancelTickets отменяет активные тикеты, подходящие под условие, и возвращает
+// их места в остатки категорий.
+func cancelTickets(tx *gorm.DB, query string, args ...interface{}) error {
+	var released []struct {
+		TierID uint
+		Count  int
+	}
+	err := tx.Model(&models.Ticket{}).
+		Select("tier_id, COUNT(*) AS count").
+		Where("status = ? AND tier_id IS NOT NULL", "active").
+		Where(query, args...).
+		Group("tier_id").
+		Scan(&released).Error
+	if err != nil {
+		return err
+	}
+
+	for _, r := range released {
+		if err := releaseTierInventory(tx, r.TierID, r.Count); err != nil {
+			return err
+		}
+	}
+
+	return tx.Model(&models.Ticket{}).
+		Where("status = ?", "active").
+		Where(query, args...).
+		Update("status", "canceled").Error
+}
+
+// issueRegistrationTicket выдает тикет при регистрации из бесплатной категории события
+// в продаже с остатком. Тикет без категории выдается только на событие без категорий:
+// такое событие бесплатное, места ограничивает capacity. Если категории есть, а бесплатной
+// с остатком нет, возвращается errTierSoldOut или errPaymentRequired - платные тикеты
+// выдаются только по оплаченному заказу.
+func issueRegistrationTicket(tx *gorm.DB, eventID, participantID uint) (*models.Ticket, error) {
+	now := time.Now().UTC()
+
+	var tiers []models.TicketTier
+	err := tx.Where("event_id = ? AND price = 0", eventID).
+		Where("sales_start IS NULL OR sales_start <= ?", now).
+		Where("sales_end IS NULL OR sales_end > ?", now).
+		Order("id ASC").
+		Find(&tiers).Error
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range tiers {
+		err := takeTierInventory(tx, tiers[i].ID, 1)
+		if errors.Is(err, errTierSoldOut) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		return issueTicket(tx, eventID, participantID, &tiers[i])
+	}
+	if len(tiers) > 0 {
+		return nil, errTierSoldOut
+	}
+
+	var tierCount int64
+	if err := tx.Model(&models.TicketTier{}).Where("event_id = ?", eventID).Count(&tierCount).Error; err != nil {
+		return nil, err
+	}
+	if tierCount > 0 {
+		return nil, errPaymentRequired
+	}
+	return issueTicket(tx, eventID, participantID, nil)
+}
+
+func respondTierError(c *gin.Context, err error) bool {
+	switch {
+	case errors.Is(err, errTierNotFound):
+		c.JSON(404, gin.H{"error": "Ticket tier not found"})
+	case errors.Is(err, errTierOtherEvent),
+		errors.Is(err, errTierTicketType),
+		errors.Is(err, errTierSalesWindow),
+		errors.Is(err, errLegacyTicketType):
+		c.JSON(400, gin.H{"error": err.Error()})
+	case errors.Is(err, errTierNotOnSale):
+		c.JSON(409, gin.H{"error": err.Error(), "reason": "not_on_sale"})
+	case errors.Is(err, errTierSoldOut):
+		c.JSON(409, gin.H{"error": err.Error(), "reason": "sold_out"})
+	case errors.Is(err, errPaymentRequired):
+		c.JSON(409, gin.H{"error": err.Error(), "reason": "payment_required"})
+	case errors.Is(err, errTierOrderLimit):
+		c.JSON(409, gin.H{"error": err.Error(), "reason": "order_limit_exceeded"})
+	case errors.Is(err, errTierBelowSold),
+		errors.Is(err, errTierHasTickets):
+		c.JSON(409, gin.H{"error": err.Error()})
+	default:
+		return false
+	}
+	return true
+}
+
+func validateTierInput(input models.CreateTicketTierRequest) error {
+	if input.SalesStart != nil && input.SalesEnd != nil && !input.SalesEnd.After(*input.SalesStart) {
+		return errTierSalesWindow
+	}
+	return nil
+}
+
+func GetTicketTierById(c *gin.Context) {
+	id := c.Param("id")
+
+	if id == "" {
+		c.JSON(400, gin.H{"error": "ID parameter is required"})
+		return
+	}
+
+	var tier models.TicketTier
+
+	result := database.DB.First(&tier, id)
+
+	if result.Error != nil {
+		if errors.Is(result.Error, gorm.ErrRecordNotFound) {
+			c.JSON(404, gin.H{"error": "Ticket tier not found"})
+		} else {
+			c.JSON(500, gin.H{"error": "Database error"})
+		}
+		return
+	}
+
+	c.JSON(200, tier)
+}
+
+// @Summary Получить список категорий билетов
+// @Description Возвращает категории билетов с ценой, остатком и окном продаж
+// @Tags TicketTiers
+// @Accept json
+// @Produce json
+// @Param range query string false "Пагинация [start, end]"
+// @Param sort query string false "Сортировка [field, order]"
+// @Param event_id query int false "Фильтр по ID события"
+// @Success 200 {array} models.TicketTier
+// @Header 200 {string} X-Total-Count "Общее количество записей"
+// @Header 200 {string} Content-Range "Диапазон записей"
+// @Router /ticket_tiers [get]
+func GetTicketTiers(c *gin.Context) {
+	var tiers []models.TicketTier
+	var total int64
+
+	rangeParam := c.Query("range")
+	var start, end int = 0, 25
+	if rangeParam != "" {
+		var rangeArray []int
+		if err := json.Unmarshal([]byte(rangeParam), &rangeArray); err == nil && len(rangeArray) == 2 {
+			start = rangeArray[0]
+			end = rangeArray[1]
+		}
+	}
+
+	sortParam := c.Query("sort")
+	var sortField, sortOrder string = "id", "ASC"
+	if sortParam != "" {
+		var sortArray []string
+		if err := json.Unmarshal([]byte(sortParam), &sortArray); err == nil && len(sortArray) == 2 {
+			sortField = sortArray[0]
+			sortOrder = sortArray[1]
+		}
+	}
+
+	limit := end - start + 1
+	offset := start
+
+	query := database.DB.Model(&models.TicketTier{})
+	if eventID := c.Query("event_id"); eventID != "" {
+		query = query.Where("event_id = ?", eventID)
+	}
+
+	countResult := query.Count(&total)
+	if countResult.Error != nil {
+		c.JSON(500, gin.H{"error": "Failed to retrieve total record count"})
+		return
+	}
+
+	contentRange := fmt.Sprintf("ticket_tiers %d-%d/%d", start, end, total)
+
+	result := query.
+		Limit(limit).
+		Offset(offset).
+		Order(sortField + " " + sortOrder).
+		Find(&tiers)
+	if result.Error != nil {
+		c.JSON(500, gin.H{"error": "Database error"})
+		return
+	}
+
+	c.Header("Content-Range", contentRange)
+	c.Header("X-Total-Count", strconv.Itoa(int(total)))
+	c.JSON(200, tiers)
+}
+
+func UpdateTicketTier(c *gin.Context) {
+	id := c.Param("id")
+
+	var input models.CreateTicketTierRequest
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := validateTierInput(input); err != nil {
+		respondTierError(c, err)
+		return
+	}
+
+	var tier models.TicketTier
+
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&tier, id).Error; err != nil {
+			return err
+		}
+
+		if input.Quantity < tier.Sold {
+			return errTierBelowSold
+		}
+		if input.EventID != tier.EventID && tier.Sold > 0 {
+			return errTierHasTickets
+		}
+
+		return tx.Model(&tier).
+			Select("event_id", "name", "price", "currency", "quantity", "sales_start", "sales_end", "max_per_order").
+			Updates(models.TicketTier{
+				EventID:     input.EventID,
+				Name:        input.Name,
+				Price:       input.Price,
+				Currency:    input.Currency,
+				Quantity:    input.Quantity,
+				SalesStart:  utcOrNil(input.SalesStart),
+				SalesEnd:    utcOrNil(input.SalesEnd),
+				MaxPerOrder: input.MaxPerOrder,
+			}).Error
+	})
+
+	if err != nil {
+		if respondTierError(c, err) {
+			return
+		}
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(404, gin.H{"error": "Ticket tier not found."})
+			return
+		}
+		log.Printf("Database Error (Update): %v", err)
+		c.JSON(500, gin.H{"error": "Failed to update ticket tier. Database error."})
+		return
+	}
+
+	database.DB.First(&tier, id)
+
+	c.JSON(200, tier)
+}
+
+func DeleteTicketTier(c *gin.Context) {
+	id := c.Param("id")
+
+	var issued int64
+	if err := database.DB.Model(&models.Ticket{}).Where("tier_id = ?", id).Count(&issued).Error; err != nil {
+		c.JSON(500, gin.H{"error": "Database error"})
+		return
+	}
+	if issued > 0 {
+		respondTierError(c, errTierHasTickets)
+		return
+	}
+
+	result := database.DB.Delete(&models.TicketTier{}, id)
+
+	if result.Error != nil {
+		log.Printf("Database Error (Delete): %v", result.Error)
+		c.JSON(500, gin.H{"error": "Failed to delete ticket tier. Database error."})
+		return
+	}
+
+	c.JSON(200, gin.H{})
+}
+
+// @Summary Создать категорию билетов
+// @Description Создает категорию билетов события: цена в минимальных единицах валюты, количество, окно продаж и лимит на заказ
+// @Tags TicketTiers
+// @Accept json
+// @Produce json
+// @Param tier body models.CreateTicketTierRequest true "Данные категории"
+// @Success 201 {object} models.TicketTier
+// @Failure 400 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /ticket_tiers [post]
+func PostTicketTier(c *gin.Context) {
+	var newTier models.CreateTicketTierRequest
+
+	if err := c.ShouldBindJSON(&newTier); err != nil {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := validateTierInput(newTier); err != nil {
+		respondTierError(c, err)
+		return
+	}
+
+	tier := models.TicketTier{
+		EventID:     newTier.EventID,
+		Name:        newTier.Name,
+		Price:       newTier.Price,
+		Currency:    newTier.Currency,
+		Quantity:    newTier.Quantity,
+		SalesStart:  utcOrNil(newTier.SalesStart),
+		SalesEnd:    utcOrNil(newTier.SalesEnd),
+		MaxPerOrder: newTier.MaxPerOrder,
+	}
+
+	result := database.DB.Create(&tier)
+
+	if result.Error != nil {
+		log.Printf("Database Error (Create): %v", result.Error)
+		c.JSON(500, gin.H{"error": "Failed to create ticket tier. Database error."})
+		return
+	}
+
+	c.JSON(201, tier)
+}
@@ -0,0 +1,179 @@
+package handlers
+
+import (
+	"errors"
+	"eventflow/internal/database"
+	"eventflow/internal/models"
+	"eventflow/internal/tickettoken"
+	"os"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+const defaultTicketTokenGrace = 24 * time.Hour
+
+var errTicketTokenRevoked = errors.New("ticket token was revoked")
+
+// ticketTokenGrace - сколько токен действует после окончания события (TICKET_TOKEN_GRACE)
+func ticketTokenGrace() time.Duration {
+	if value := os.Getenv("TICKET_TOKEN_GRACE"); value != "" {
+		if grace, err := time.ParseDuration(value); err == nil && grace >= 0 {
+			return grace
+		}
+	}
+	return defaultTicketTokenGrace
+}
+
+// signTicketToken выпускает токен тикета: он действует с момента выдачи тикета
+// до окончания события с запасом ticketTokenGrace
+func signTicketToken(ticket models.Ticket, event models.Event) (string, time.Time) {
+	notAfter := event.EndTime.Add(ticketTokenGrace())
+	token := tickettoken.Sign(tickettoken.Claims{
+		TicketID:  ticket.ID,
+		EventID:   ticket.EventID,
+		NotBefore: ticket.CreatedAt,
+		NotAfter:  notAfter,
+		CodeTag:   tickettoken.CodeTag(ticket.QRCode),
+	})
+	return token, notAfter
+}
+
+// ticketQRPayload возвращает содержимое QR-кода тикета - подписанный токен
+func ticketQRPayload(ticket models.Ticket) (string, error) {
+	var event models.Event
+	if err := database.DB.First(&event, ticket.EventID).Error; err != nil {
+		return "", err
+	}
+	token, _ := signTicketToken(ticket, event)
+	return token, nil
+}
+
+// findTicketByCode находит тикет по содержимому QR-кода: подписанному токену
+// или случайному коду тикетов, выпущенных до появления токенов. Срок действия
+// токена проверяется на момент сканирования at.
+func findTicketByCode(db *gorm.DB, code string, at time.Time) (models.Ticket, error) {
+	var ticket models.Ticket
+
+	if !tickettoken.IsToken(code) {
+		err := db.Where("qr_code = ?", code).First(&ticket).Error
+		return ticket, err
+	}
+
+	claims, err := tickettoken.Verify(code, at)
+	if err != nil {
+		return ticket, err
+	}
+
+	if err := db.First(&ticket, claims.TicketID).Error; err != nil {
+		return ticket, err
+	}
+	if ticket.EventID != claims.EventID || string(tickettoken.CodeTag(ticket.QRCode)) != string(claims.CodeTag) {
+		return ticket, errTicketTokenRevoked
+	}
+	return ticket, nil
+}
+
+// ticketCodeErrorReason возвращает машиночитаемую причину ошибки findTicketByCode
+// (пустая строка - ошибка базы данных)
+func ticketCodeErrorReason(err error) string {
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		return "ticket_not_found"
+	case errors.Is(err, tickettoken.ErrExpired):
+		return "token_expired"
+	case errors.Is(err, tickettoken.ErrNotYetValid):
+		return "token_not_yet_valid"
+	case errors.Is(err, errTicketTokenRevoked):
+		return "token_revoked"
+	case errors.Is(err, tickettoken.ErrMalformed),
+		errors.Is(err, tickettoken.ErrSignature),
+		errors.Is(err, tickettoken.ErrUnknownKey):
+		return "invalid_token"
+	}
+	return ""
+}
+
+// respondTicketCodeError отвечает на ошибку findTicketByCode
+func respondTicketCodeError(c *gin.Context, err error) {
+	switch reason := ticketCodeErrorReason(err); reason {
+	case "ticket_not_found":
+		c.JSON(404, gin.H{"error": "Ticket not found"})
+	case "":
+		c.JSON(500, gin.H{"error": "Database error"})
+	default:
+		c.JSON(400, gin.H{"error": err.Error(), "reason": reason})
+	}
+}
+
+// @Summary Ключи проверки токенов тикетов
+// @Description Возвращает публичные ключи Ed25519 (JWK), которыми сканеры проверяют токены из QR-кодов без подключения к API
+// @Tags Tickets
+// @Produce json
+// @Success 200 {object} map[string]interface{} "keys - список JWK"
+// @Router /tickets/verification-keys [get]
+func GetTicketVerificationKeys(c *gin.Context) {
+	c.Header("Cache-Control", "public, max-age=3600")
+	c.JSON(200, gin.H{
+		"keys":         tickettoken.JWKs(),
+		"token_prefix": tickettoken.Prefix,
+	})
+}
+
+// respondTicketNotIssuable отвечает 409 для отмененного или истекшего тикета:
+// для него не выдаются QR-токен, PDF и карта кошелька
+func respondTicketNotIssuable(c *gin.Context, ticket *models.Ticket) bool {
+	switch ticket.Status {
+	case "canceled":
+		c.JSON(409, gin.H{"error": "Ticket is canceled", "reason": "ticket_canceled"})
+	case "expired":
+		c.JSON(409, gin.H{"error": "Ticket has expired", "reason": "ticket_expired"})
+	default:
+		return false
+	}
+	return true
+}
+
+// @Summary Подписанный токен тикета
+// @Description Возвращает токен, который кодируется в QR-код тикета, и срок его действия
+// @Tags Tickets
+// @Produce json
+// @Param id path int true "ID тикета"
+// @Success 200 {object} map[string]interface{}
+// @Failure 404 {object} map[string]string
+// @Failure 409 {object} map[string]string "Тикет отменен или истек"
+// @Router /tickets/{id}/token [get]
+func GetTicketToken(c *gin.Context) {
+	id := c.Param("id")
+
+	var ticket models.Ticket
+	if err := database.DB.First(&ticket, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(404, gin.H{"error": "Ticket not found"})
+		} else {
+			c.JSON(500, gin.H{"error": "Database error"})
+		}
+		return
+	}
+
+	if respondTicketNotIssuable(c, &ticket) {
+		return
+	}
+
+	var event models.Event
+	if err := database.DB.First(&event, ticket.EventID).Error; err != nil {
+		c.JSON(500, gin.H{"error": "Database error"})
+		return
+	}
+
+	token, expiresAt := signTicketToken(ticket, event)
+	c.JSON(200, gin.H{
+		"ticket_id":  ticket.ID,
+		"event_id":   ticket.EventID,
+		"token":      token,
+		"not_before": ticket.CreatedAt.UTC(),
+		"expires_at": expiresAt.UTC(),
+		"key_id":     tickettoken.Signing.ID,
+	})
+}
@@ -0,0 +1,289 @@
+package handlers
+
+import (
+	"errors"
+	"eventflow/internal/database"
+	"eventflow/internal/models"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// transferError - передача запрещена; Reason - машиночитаемая причина
+type transferError struct {
+	Reason   string
+	Message  string
+	Deadline *time.Time
+}
+
+func (e *transferError) Error() string {
+	return e.Message
+}
+
+// checkTransferPolicy проверяет политику передачи тикетов события на момент now
+func checkTransferPolicy(event *models.Event, now time.Time) error {
+	if event.TransferPolicy == "none" {
+		return &transferError{Reason: "transfers_disabled", Message: "ticket transfers are not allowed for this event"}
+	}
+	if event.Status == "canceled" || event.Status == "completed" {
+		return &transferError{Reason: "event_" + event.Status, Message: "tickets of a " + event.Status + " event cannot be transferred"}
+	}
+
+	deadline := event.StartTime
+	if event.TransferCutoffHours != nil {
+		deadline = deadline.Add(-time.Duration(*event.TransferCutoffHours) * time.Hour)
+	}
+	if !now.Before(deadline) {
+		return &transferError{Reason: "transfer_window_closed", Message: "transfer window for this event has closed", Deadline: &deadline}
+	}
+
+	return nil
+}
+
+// findOrCreateParticipant находит участника по email или создает его
+func findOrCreateParticipant(tx *gorm.DB, email, fullName, phone string) (*models.Participant, error) {
+	var participant models.Participant
+	err := tx.Where("LOWER(email) = ?", strings.ToLower(email)).First(&participant).Error
+	if err == nil {
+		return &participant, nil
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+
+	if strings.TrimSpace(fullName) == "" {
+		return nil, &transferError{Reason: "full_name_required", Message: "full_name is required for a new participant"}
+	}
+
+	// Участник мог быть создан параллельным запросом: тогда берется существующий
+	created, _, err := insertParticipant(tx, email, fullName, phone)
+	return created, err
+}
+
+// moveRegistration переносит место на событии event от участника from к участнику to.
+// Если у from не осталось активных тикетов, место переходит к to и число занятых мест
+// не меняется. Иначе регистрация from сохраняется, а to занимает еще одно место -
+// только если оно свободно. Событие должно быть заблокировано (lockEvent).
+func moveRegistration(tx *gorm.DB, event *models.Event, from, to uint) (*models.EventRegistration, error) {
+	eventID := event.ID
+	var remaining int64
+	err := tx.Model(&models.Ticket{}).
+		Where("event_id = ? AND participant_id = ? AND status = ?", eventID, from, "active").
+		Count(&remaining).Error
+	if err != nil {
+		return nil, err
+	}
+
+	var source *models.EventRegistration
+	if remaining == 0 {
+		var registration models.EventRegistration
+		err := tx.Where("event_id = ? AND participant_id = ?", eventID, from).First(&registration).Error
+		if err == nil {
+			source = &registration
+		} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, err
+		}
+	}
+
+	var target models.EventRegistration
+	err = tx.Where("event_id = ? AND participant_id = ?", eventID, to).First(&target).Error
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+
+	if source == nil && (err != nil || !containsString(occupyingStatuses, target.Status)) {
+		free, err := hasFreeSeat(tx, event)
+		if err != nil {
+			return nil, err
+		}
+		if !free {
+			return nil, &transferError{Reason: "event_full", Message: "event has no free seats for the recipient"}
+		}
+	}
+
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound) && source != nil:
+		// Регистрация переходит к получателю вместе со статусом и датой
+		if err := tx.Model(source).Update("participant_id", to).Error; err != nil {
+			return nil, err
+		}
+		return source, nil
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		target = models.EventRegistration{
+			EventID:       eventID,
+			ParticipantID: to,
+			RegisteredAt:  time.Now(),
+			Status:        "registered",
+		}
+		if err := tx.Create(&target).Error; err != nil {
+			return nil, err
+		}
+	default:
+		if target.Status != "registered" && target.Status != "attended" {
+			if err := tx.Model(&target).Update("status", "registered").Error; err != nil {
+				return nil, err
+			}
+		}
+		if source != nil {
+			if err := tx.Model(source).Update("status", "canceled").Error; err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	return &target, nil
+}
+
+// transferTicket передает активный тикет участнику с email из запроса и меняет QR-код,
+// чтобы прежний код и подписанные токены перестали приниматься
+func transferTicket(tx *gorm.DB, ticketID string, organizerID uint, input models.TransferTicketRequest) (*models.TicketTransfer, *models.Ticket, *models.EventRegistration, error) {
+	var ticket models.Ticket
+	if err := tx.First(&ticket, ticketID).Error; err != nil {
+		return nil, nil, nil, err
+	}
+
+	var event models.Event
+	if err := lockEvent(tx, ticket.EventID, &event); err != nil {
+		return nil, nil, nil, err
+	}
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&ticket, ticket.ID).Error; err != nil {
+		return nil, nil, nil, err
+	}
+
+	if ticket.Status != "active" {
+		return nil, nil, nil, &transferError{Reason: "ticket_canceled", Message: "only active tickets can be transferred"}
+	}
+	if err := checkTransferPolicy(&event, time.Now().UTC()); err != nil {
+		return nil, nil, nil, err
+	}
+
+	recipient, err := findOrCreateParticipant(tx, input.Email, input.FullName, input.Phone)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	if recipient.ID == ticket.ParticipantID {
+		return nil, nil, nil, &transferError{Reason: "same_participant", Message: "ticket already belongs to this participant"}
+	}
+
+	qrCode, err := generateQRCode()
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	from := ticket.ParticipantID
+	err = tx.Model(&ticket).Updates(map[string]interface{}{
+		"participant_id": recipient.ID,
+		"qr_code":        qrCode,
+	}).Error
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	registration, err := moveRegistration(tx, &event, from, recipient.ID)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	transfer := models.TicketTransfer{
+		TicketID:          ticket.ID,
+		EventID:           ticket.EventID,
+		FromParticipantID: from,
+		ToParticipantID:   recipient.ID,
+		OrganizerID:       organizerID,
+		Reason:            input.Reason,
+	}
+	if err := tx.Create(&transfer).Error; err != nil {
+		return nil, nil, nil, err
+	}
+
+	log.Printf("Ticket %d transferred from participant %d to %d", ticket.ID, from, recipient.ID)
+	return &transfer, &ticket, registration, nil
+}
+
+// @Summary Передать тикет другому участнику
+// @Description Передает активный тикет участнику с указанным email (создает участника, если его нет), меняет QR-код и переносит регистрацию. Учитывает политику передачи события
+// @Tags Tickets
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "ID тикета"
+// @Param transfer body models.TransferTicketRequest true "Получатель тикета"
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 409 {object} map[string]interface{} "Передача запрещена политикой события или для получателя нет свободного места"
+// @Router /tickets/{id}/transfer [post]
+func PostTicketTransfer(c *gin.Context) {
+	id := c.Param("id")
+
+	var input models.TransferTicketRequest
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+
+	organizerID, _ := currentOrganizerID(c)
+
+	var transfer *models.TicketTransfer
+	var ticket *models.Ticket
+	var registration *models.EventRegistration
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		var err error
+		transfer, ticket, registration, err = transferTicket(tx, id, organizerID, input)
+		return err
+	})
+
+	if err != nil {
+		var transferErr *transferError
+		switch {
+		case errors.As(err, &transferErr):
+			code := 409
+			if transferErr.Reason == "full_name_required" || transferErr.Reason == "same_participant" {
+				code = 400
+			}
+			body := gin.H{"error": transferErr.Message, "reason": transferErr.Reason}
+			if transferErr.Deadline != nil {
+				body["deadline"] = transferErr.Deadline
+			}
+			c.JSON(code, body)
+		case errors.Is(err, gorm.ErrRecordNotFound):
+			c.JSON(404, gin.H{"error": "Ticket not found"})
+		default:
+			log.Printf("Database Error (Transfer): %v", err)
+			c.JSON(500, gin.H{"error": "Failed to transfer ticket. Database error."})
+		}
+		return
+	}
+
+	database.DB.First(ticket, ticket.ID)
+
+	c.JSON(200, gin.H{
+		"ticket":       ticket,
+		"transfer":     transfer,
+		"registration": registration,
+	})
+}
+
+// @Summary История передач тикета
+// @Description Возвращает все передачи тикета от участника к участнику
+// @Tags Tickets
+// @Produce json
+// @Param id path int true "ID тикета"
+// @Success 200 {array} models.TicketTransfer
+// @Router /tickets/{id}/transfers [get]
+func GetTicketTransfers(c *gin.Context) {
+	id := c.Param("id")
+
+	var transfers []models.TicketTransfer
+	result := database.DB.Where("ticket_id = ?", id).Order("created_at ASC, id ASC").Find(&transfers)
+	if result.Error != nil {
+		c.JSON(500, gin.H{"error": "Database error"})
+		return
+	}
+
+	c.JSON(200, transfers)
+}
@@ -0,0 +1,222 @@
+package handlers
+
+import (
+	"errors"
+	"eventflow/internal/database"
+	"eventflow/internal/models"
+	"eventflow/internal/wallet"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// walletTicket - данные тикета, из которых собираются карточки кошельков
+type walletTicket struct {
+	Ticket      models.Ticket
+	Event       models.Event
+	Participant models.Participant
+	Venue       *models.Venue
+	Seat        *models.Seat
+	Token       string
+	ExpiresAt   time.Time
+}
+
+func loadWalletTicket(id string) (walletTicket, error) {
+	var data walletTicket
+	if err := database.DB.First(&data.Ticket, id).Error; err != nil {
+		return data, err
+	}
+	if err := database.DB.First(&data.Event, data.Ticket.EventID).Error; err != nil {
+		return data, err
+	}
+	if err := database.DB.First(&data.Participant, data.Ticket.ParticipantID).Error; err != nil {
+		return data, err
+	}
+	if data.Event.VenueID != nil {
+		var venue models.Venue
+		if err := database.DB.First(&venue, *data.Event.VenueID).Error; err == nil {
+			data.Venue = &venue
+		} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+			return data, err
+		}
+	}
+	if data.Ticket.SeatID != nil {
+		var seat models.Seat
+		if err := database.DB.First(&seat, *data.Ticket.SeatID).Error; err == nil {
+			data.Seat = &seat
+		} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+			return data, err
+		}
+	}
+	data.Token, data.ExpiresAt = signTicketToken(data.Ticket, data.Event)
+	return data, nil
+}
+
+// venueAddress - адрес площадки одной строкой
+func venueAddress(venue *models.Venue) string {
+	parts := []string{}
+	for _, part := range []string{venue.Address, venue.City, venue.Country} {
+		if part != "" {
+			parts = append(parts, part)
+		}
+	}
+	return strings.Join(parts, ", ")
+}
+
+// appleWalletPass собирает pass.json для Apple Wallet
+func appleWalletPass(data walletTicket) wallet.Pass {
+	loc := data.Event.Location()
+	start := data.Event.StartTime.In(loc)
+
+	pass := wallet.Pass{
+		SerialNumber:    fmt.Sprintf("ticket-%d", data.Ticket.ID),
+		Description:     data.Event.Title,
+		LogoText:        data.Event.Title,
+		RelevantDate:    data.Event.StartTime.Format(time.RFC3339),
+		ExpirationDate:  data.ExpiresAt.UTC().Format(time.RFC3339),
+		Barcodes:        []wallet.PassBarcode{wallet.NewQRBarcode(data.Token, fmt.Sprintf("#%d", data.Ticket.ID))},
+		ForegroundColor: "rgb(255, 255, 255)",
+		BackgroundColor: "rgb(31, 42, 68)",
+		LabelColor:      "rgb(180, 190, 210)",
+		EventTicket: wallet.PassStructure{
+			HeaderFields:  []wallet.PassField{{Key: "date", Label: "Дата", Value: start.Format("02.01.2006 15:04")}},
+			PrimaryFields: []wallet.PassField{{Key: "event", Label: "Событие", Value: data.Event.Title}},
+			SecondaryFields: []wallet.PassField{
+				{Key: "attendee", Label: "Участник", Value: data.Participant.FullName},
+				{Key: "type", Label: "Тикет", Value: data.Ticket.TicketType},
+			},
+			BackFields: []wallet.PassField{
+				{Key: "ticket", Label: "Номер тикета", Value: fmt.Sprintf("%d", data.Ticket.ID)},
+				{Key: "timezone", Label: "Часовой пояс", Value: loc.String()},
+			},
+		},
+	}
+
+	if data.Venue != nil {
+		pass.EventTicket.AuxiliaryFields = append(pass.EventTicket.AuxiliaryFields,
+			wallet.PassField{Key: "venue", Label: "Площадка", Value: data.Venue.Name})
+		pass.EventTicket.BackFields = append(pass.EventTicket.BackFields,
+			wallet.PassField{Key: "address", Label: "Адрес", Value: venueAddress(data.Venue)})
+		if data.Venue.Latitude != nil && data.Venue.Longitude != nil {
+			pass.Locations = []wallet.PassLocation{{Latitude: *data.Venue.Latitude, Longitude: *data.Venue.Longitude}}
+		}
+	}
+	if data.Seat != nil {
+		pass.EventTicket.AuxiliaryFields = append(pass.EventTicket.AuxiliaryFields,
+			wallet.PassField{Key: "section", Label: "Сектор", Value: data.Seat.Section},
+			wallet.PassField{Key: "row", Label: "Ряд", Value: data.Seat.Row},
+			wallet.PassField{Key: "seat", Label: "Место", Value: data.Seat.Number})
+	}
+	return pass
+}
+
+// googleWalletObjects собирает класс события и объект тикета для Google Wallet
+func googleWalletObjects(cfg *wallet.GoogleConfig, data walletTicket) (wallet.EventTicketClass, wallet.EventTicketObject) {
+	class := wallet.EventTicketClass{
+		ID:                 cfg.ObjectID(fmt.Sprintf("event-%d", data.Event.ID)),
+		IssuerName:         "EventFlow",
+		EventName:          wallet.NewLocalizedString(data.Event.Title),
+		EventID:            fmt.Sprintf("%d", data.Event.ID),
+		ReviewStatus:       "UNDER_REVIEW",
+		HexBackgroundColor: "#1f2a44",
+		DateTime: &wallet.EventDateTime{
+			Start: data.Event.LocalStartTime,
+			End:   data.Event.LocalEndTime,
+		},
+	}
+	if data.Venue != nil {
+		class.Venue = &wallet.EventVenue{
+			Name:    wallet.NewLocalizedString(data.Venue.Name),
+			Address: wallet.NewLocalizedString(venueAddress(data.Venue)),
+		}
+	}
+
+	object := wallet.EventTicketObject{
+		ID:               cfg.ObjectID(fmt.Sprintf("ticket-%d", data.Ticket.ID)),
+		ClassID:          class.ID,
+		State:            "ACTIVE",
+		TicketHolderName: data.Participant.FullName,
+		TicketNumber:     fmt.Sprintf("%d", data.Ticket.ID),
+		Barcode:          wallet.NewQRCodeBarcode(data.Token, fmt.Sprintf("#%d", data.Ticket.ID)),
+		ValidTimeInterval: &wallet.TimeInterval{
+			Start: &wallet.WalletDateTime{Date: data.Ticket.CreatedAt.UTC().Format(time.RFC3339)},
+			End:   &wallet.WalletDateTime{Date: data.ExpiresAt.UTC().Format(time.RFC3339)},
+		},
+	}
+	if data.Seat != nil {
+		section := wallet.NewLocalizedString(data.Seat.Section)
+		row := wallet.NewLocalizedString(data.Seat.Row)
+		seat := wallet.NewLocalizedString(data.Seat.Number)
+		object.SeatInfo = &wallet.SeatInfo{Section: &section, Row: &row, Seat: &seat}
+	}
+	return class, object
+}
+
+// @Summary Тикет для мобильного кошелька
+// @Description apple - подписанный архив .pkpass для Apple Wallet; google - JWT и ссылка "Добавить в Google Кошелек"
+// @Tags Tickets
+// @Produce application/vnd.apple.pkpass
+// @Produce json
+// @Param id path int true "ID тикета"
+// @Param provider path string true "Кошелек" Enums(apple, google)
+// @Success 200 {file} binary
+// @Failure 404 {object} map[string]string
+// @Failure 409 {object} map[string]string "Тикет отменен или истек"
+// @Failure 503 {object} map[string]string "Кошелек не настроен"
+// @Router /tickets/{id}/wallet/{provider} [get]
+func GetTicketWallet(c *gin.Context) {
+	provider := c.Param("provider")
+	if provider != "apple" && provider != "google" {
+		c.JSON(400, gin.H{"error": "Provider must be one of: apple, google"})
+		return
+	}
+	if (provider == "apple" && wallet.Apple == nil) || (provider == "google" && wallet.Google == nil) {
+		c.JSON(503, gin.H{"error": "Wallet provider is not configured", "reason": "wallet_not_configured"})
+		return
+	}
+
+	data, err := loadWalletTicket(c.Param("id"))
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(404, gin.H{"error": "Ticket not found"})
+		} else {
+			log.Printf("Database Error (Wallet Ticket): %v", err)
+			c.JSON(500, gin.H{"error": "Database error"})
+		}
+		return
+	}
+
+	if respondTicketNotIssuable(c, &data.Ticket) {
+		return
+	}
+
+	switch provider {
+	case "apple":
+		pkpass, err := wallet.Apple.BuildPKPass(appleWalletPass(data), nil, time.Now())
+		if err != nil {
+			log.Printf("Wallet Error (Apple, Ticket %d): %v", data.Ticket.ID, err)
+			c.JSON(500, gin.H{"error": "Failed to build wallet pass"})
+			return
+		}
+		c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="ticket-%d.pkpass"`, data.Ticket.ID))
+		c.Data(200, "application/vnd.apple.pkpass", pkpass)
+
+	case "google":
+		class, object := googleWalletObjects(wallet.Google, data)
+		token, err := wallet.Google.SaveJWT(class, object, time.Now())
+		if err != nil {
+			log.Printf("Wallet Error (Google, Ticket %d): %v", data.Ticket.ID, err)
+			c.JSON(500, gin.H{"error": "Failed to build wallet pass"})
+			return
+		}
+		c.JSON(200, gin.H{
+			"ticket_id": data.Ticket.ID,
+			"jwt":       token,
+			"save_url":  wallet.SaveURLPrefix + token,
+		})
+	}
+}
@@ -0,0 +1,133 @@
+package handlers
+
+import (
+	"errors"
+	"eventflow/internal/models"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+var errInvalidTimezone = errors.New("invalid timezone")
+
+// venueDefaults дополняет настройки события значениями площадки: вместимость и часовой
+// пояс берутся из площадки, если не заданы явно. Без площадки пояс по умолчанию - UTC.
+func venueDefaults(db *gorm.DB, venueID *uint, capacity *int, timezone string) (*int, string, error) {
+	if timezone != "" {
+		if _, err := time.LoadLocation(timezone); err != nil {
+			return nil, "", errInvalidTimezone
+		}
+	}
+
+	if venueID == nil {
+		if timezone == "" {
+			timezone = "UTC"
+		}
+		return capacity, timezone, nil
+	}
+
+	var venue models.Venue
+	if err := db.First(&venue, *venueID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, "", errVenueNotFound
+		}
+		return nil, "", err
+	}
+
+	if capacity == nil {
+		capacity = venue.Capacity
+	}
+	if timezone == "" {
+		timezone = venue.Timezone
+	}
+
+	return capacity, timezone, nil
+}
+
+// respondVenueDefaultsError отвечает клиенту на ошибку venueDefaults
+func respondVenueDefaultsError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, errVenueNotFound):
+		c.JSON(400, gin.H{"error": "Venue not found"})
+	case errors.Is(err, errInvalidTimezone):
+		c.JSON(400, gin.H{"error": "Timezone must be a valid IANA time zone name"})
+	default:
+		c.JSON(500, gin.H{"error": "Database error"})
+	}
+}
+
+// parseTimeFilter разбирает границу диапазона дат. Поддерживаются RFC 3339 со смещением
+// ("2025-03-01T10:00:00+03:00"), дата и время без смещения и просто дата - последние
+// два варианта трактуются как местное время в поясе loc. dateOnly сообщает, что время не указано.
+func parseTimeFilter(value string, loc *time.Location) (t time.Time, dateOnly bool, err error) {
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, false, nil
+	}
+
+	// "+" в query-строке без кодирования превращается в пробел
+	if t, err := time.Parse(time.RFC3339, strings.Replace(value, " ", "+", 1)); err == nil {
+		return t, false, nil
+	}
+
+	for _, layout := range []string{"2006-01-02T15:04:05", "2006-01-02 15:04:05", "2006-01-02T15:04"} {
+		if t, err := time.ParseInLocation(layout, value, loc); err == nil {
+			return t, false, nil
+		}
+	}
+
+	t, err = time.ParseInLocation("2006-01-02", value, loc)
+	if err != nil {
+		return time.Time{}, false, err
+	}
+	return t, true, nil
+}
+
+// timeRangeFilter читает параметры start_date, end_date и tz. Верхняя граница
+// возвращается исключающей: для end_date без времени это начало следующего дня.
+// При ошибке отвечает 400 и возвращает ok = false.
+func timeRangeFilter(c *gin.Context) (from, to *time.Time, ok bool) {
+	loc := time.UTC
+	if tz := c.Query("tz"); tz != "" {
+		var err error
+		loc, err = time.LoadLocation(tz)
+		if err != nil {
+			c.JSON(400, gin.H{"error": "tz must be a valid IANA time zone name"})
+			return nil, nil, false
+		}
+	}
+
+	if startDate := c.Query("start_date"); startDate != "" {
+		t, _, err := parseTimeFilter(startDate, loc)
+		if err != nil {
+			c.JSON(400, gin.H{"error": "Invalid start_date, expected RFC 3339 or YYYY-MM-DD"})
+			return nil, nil, false
+		}
+		from = &t
+	}
+
+	if endDate := c.Query("end_date"); endDate != "" {
+		t, dateOnly, err := parseTimeFilter(endDate, loc)
+		if err != nil {
+			c.JSON(400, gin.H{"error": "Invalid end_date, expected RFC 3339 or YYYY-MM-DD"})
+			return nil, nil, false
+		}
+		if dateOnly {
+			t = t.AddDate(0, 0, 1)
+		} else {
+			t = t.Add(time.Microsecond)
+		}
+		to = &t
+	}
+
+	return from, to, true
+}
+
+func utcOrNil(t *time.Time) *time.Time {
+	if t == nil {
+		return nil
+	}
+	utc := t.UTC()
+	return &utc
+}
@@ -0,0 +1,189 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"eventflow/internal/database"
+	"eventflow/internal/models"
+	"fmt"
+	"log"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+func GetTrackById(c *gin.Context) {
+	id := c.Param("id")
+
+	if id == "" {
+		c.JSON(400, gin.H{"error": "ID parameter is required"})
+		return
+	}
+
+	var track models.Track
+
+	result := database.DB.First(&track, id)
+
+	if result.Error != nil {
+		if errors.Is(result.Error, gorm.ErrRecordNotFound) {
+			c.JSON(404, gin.H{"error": "Track not found"})
+		} else {
+			c.JSON(500, gin.H{"error": "Database error"})
+		}
+		return
+	}
+
+	c.JSON(200, track)
+}
+
+// @Summary Получить список треков
+// @Description Возвращает список треков программы с пагинацией и фильтром по событию
+// @Tags Tracks
+// @Accept json
+// @Produce json
+// @Param range query string false "Пагинация [start, end]"
+// @Param sort query string false "Сортировка [field, order]"
+// @Param event_id query int false "Фильтр по ID события"
+// @Success 200 {array} models.Track
+// @Header 200 {string} X-Total-Count "Общее количество записей"
+// @Header 200 {string} Content-Range "Диапазон записей"
+// @Router /tracks [get]
+func GetTracks(c *gin.Context) {
+	var tracks []models.Track
+	var total int64
+
+	rangeParam := c.Query("range")
+	var start, end int = 0, 25
+	if rangeParam != "" {
+		var rangeArray []int
+		if err := json.Unmarshal([]byte(rangeParam), &rangeArray); err == nil && len(rangeArray) == 2 {
+			start = rangeArray[0]
+			end = rangeArray[1]
+		}
+	}
+
+	sortParam := c.Query("sort")
+	var sortField, sortOrder string = "id", "ASC"
+	if sortParam != "" {
+		var sortArray []string
+		if err := json.Unmarshal([]byte(sortParam), &sortArray); err == nil && len(sortArray) == 2 {
+			sortField = sortArray[0]
+			sortOrder = sortArray[1]
+		}
+	}
+
+	limit := end - start + 1
+	offset := start
+
+	query := database.DB.Model(&models.Track{})
+	if eventID := c.Query("event_id"); eventID != "" {
+		query = query.Where("event_id = ?", eventID)
+	}
+
+	countResult := query.Count(&total)
+	if countResult.Error != nil {
+		c.JSON(500, gin.H{"error": "Failed to retrieve total record count"})
+		return
+	}
+
+	contentRange := fmt.Sprintf("tracks %d-%d/%d", start, end, total)
+
+	result := query.
+		Limit(limit).
+		Offset(offset).
+		Order(sortField + " " + sortOrder).
+		Find(&tracks)
+	if result.Error != nil {
+		c.JSON(500, gin.H{"error": "Database error"})
+		return
+	}
+
+	c.Header("Content-Range", contentRange)
+	c.Header("X-Total-Count", strconv.Itoa(int(total)))
+	c.JSON(200, tracks)
+}
+
+func UpdateTrack(c *gin.Context) {
+	id := c.Param("id")
+
+	var input models.CreateTrackRequest
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+
+	var track models.Track
+
+	result := database.DB.Model(&track).Where("id = ?", id).
+		Select("event_id", "name", "color").
+		Updates(models.Track{
+			EventID: input.EventID,
+			Name:    input.Name,
+			Color:   input.Color,
+		})
+
+	if result.Error != nil {
+		log.Printf("Database Error (Update): %v", result.Error)
+		c.JSON(500, gin.H{"error": "Failed to update track. Database error."})
+		return
+	}
+
+	if result.RowsAffected == 0 {
+		c.JSON(404, gin.H{"error": "Track not found."})
+		return
+	}
+
+	database.DB.First(&track, id)
+
+	c.JSON(200, track)
+}
+
+func DeleteTrack(c *gin.Context) {
+	id := c.Param("id")
+
+	result := database.DB.Delete(&models.Track{}, id)
+
+	if result.Error != nil {
+		log.Printf("Database Error (Delete): %v", result.Error)
+		c.JSON(500, gin.H{"error": "Failed to delete track. Database error."})
+		return
+	}
+
+	c.JSON(200, gin.H{})
+}
+
+// @Summary Создать трек
+// @Description Создает трек (тематическое направление) в программе события
+// @Tags Tracks
+// @Accept json
+// @Produce json
+// @Param track body models.CreateTrackRequest true "Данные трека"
+// @Success 201 {object} models.Track
+// @Failure 400 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /tracks [post]
+func PostTrack(c *gin.Context) {
+	var newTrack models.CreateTrackRequest
+
+	if err := c.ShouldBindJSON(&newTrack); err != nil {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+
+	track := models.Track{
+		EventID: newTrack.EventID,
+		Name:    newTrack.Name,
+		Color:   newTrack.Color,
+	}
+
+	result := database.DB.Create(&track)
+
+	if result.Error != nil {
+		log.Printf("Database Error (Create): %v", result.Error)
+		c.JSON(500, gin.H{"error": "Failed to create track. Database error."})
+		return
+	}
+
+	c.JSON(201, track)
+}
@@ -0,0 +1,232 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"eventflow/internal/database"
+	"eventflow/internal/models"
+	"fmt"
+	"log"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+var errVenueNotFound = errors.New("venue not found")
+
+const earthRadiusKm = 6371.0
+
+// venuesWithinRadius возвращает подзапрос ID площадок в пределах radiusKm от точки
+// (формула гаверсинусов). Грубый фильтр по широте позволяет использовать индекс координат.
+func venuesWithinRadius(lat, lng, radiusKm float64) *gorm.DB {
+	latDelta := radiusKm / 111.0
+
+	return database.DB.Model(&models.Venue{}).
+		Select("id").
+		Where("latitude IS NOT NULL AND longitude IS NOT NULL").
+		Where("latitude BETWEEN ? AND ?", lat-latDelta, lat+latDelta).
+		Where(`? * 2 * ASIN(LEAST(1, SQRT(
+			POWER(SIN(RADIANS(latitude - ?) / 2), 2) +
+			COS(RADIANS(?)) * COS(RADIANS(latitude)) * POWER(SIN(RADIANS(longitude - ?) / 2), 2)
+		))) <= ?`, earthRadiusKm, lat, lat, lng, radiusKm)
+}
+
+func GetVenueById(c *gin.Context) {
+	id := c.Param("id")
+
+	if id == "" {
+		c.JSON(400, gin.H{"error": "ID parameter is required"})
+		return
+	}
+
+	var venue models.Venue
+
+	result := database.DB.First(&venue, id)
+
+	if result.Error != nil {
+		if errors.Is(result.Error, gorm.ErrRecordNotFound) {
+			c.JSON(404, gin.H{"error": "Venue not found"})
+		} else {
+			c.JSON(500, gin.H{"error": "Database error"})
+		}
+		return
+	}
+
+	c.JSON(200, venue)
+}
+
+// @Summary Получить список площадок
+// @Description Возвращает список площадок проведения событий с пагинацией
+// @Tags Venues
+// @Accept json
+// @Produce json
+// @Param range query string false "Пагинация [start, end]"
+// @Param sort query string false "Сортировка [field, order]"
+// @Success 200 {array} models.Venue
+// @Header 200 {string} X-Total-Count "Общее количество записей"
+// @Header 200 {string} Content-Range "Диапазон записей"
+// @Router /venues [get]
+func GetVenues(c *gin.Context) {
+	var venues []models.Venue
+	var total int64
+
+	rangeParam := c.Query("range")
+	var start, end int = 0, 25
+	if rangeParam != "" {
+		var rangeArray []int
+		if err := json.Unmarshal([]byte(rangeParam), &rangeArray); err == nil && len(rangeArray) == 2 {
+			start = rangeArray[0]
+			end = rangeArray[1]
+		}
+	}
+
+	sortParam := c.Query("sort")
+	var sortField, sortOrder string = "id", "ASC"
+	if sortParam != "" {
+		var sortArray []string
+		if err := json.Unmarshal([]byte(sortParam), &sortArray); err == nil && len(sortArray) == 2 {
+			sortField = sortArray[0]
+			sortOrder = sortArray[1]
+		}
+	}
+
+	limit := end - start + 1
+	offset := start
+
+	countResult := database.DB.Model(&models.Venue{}).Count(&total)
+	if countResult.Error != nil {
+		c.JSON(500, gin.H{"error": "Failed to retrieve total record count"})
+		return
+	}
+
+	contentRange := fmt.Sprintf("venues %d-%d/%d", start, end, total)
+
+	result := database.DB.
+		Limit(limit).
+		Offset(offset).
+		Order(sortField + " " + sortOrder).
+		Find(&venues)
+	if result.Error != nil {
+		c.JSON(500, gin.H{"error": "Database error"})
+		return
+	}
+
+	c.Header("Content-Range", contentRange)
+	c.Header("X-Total-Count", strconv.Itoa(int(total)))
+	c.JSON(200, venues)
+}
+
+func UpdateVenue(c *gin.Context) {
+	id := c.Param("id")
+
+	var input models.CreateVenueRequest
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+
+	if _, err := time.LoadLocation(input.Timezone); err != nil {
+		c.JSON(400, gin.H{"error": "Timezone must be a valid IANA time zone name"})
+		return
+	}
+
+	var venue models.Venue
+
+	result := database.DB.Model(&venue).Where("id = ?", id).
+		Select("name", "address", "city", "country", "latitude", "longitude", "timezone", "capacity").
+		Updates(models.Venue{
+			Name:      input.Name,
+			Address:   input.Address,
+			City:      input.City,
+			Country:   input.Country,
+			Latitude:  input.Latitude,
+			Longitude: input.Longitude,
+			Timezone:  input.Timezone,
+			Capacity:  input.Capacity,
+		})
+
+	if result.Error != nil {
+		log.Printf("Database Error (Update): %v", result.Error)
+		c.JSON(500, gin.H{"error": "Failed to update venue. Database error."})
+		return
+	}
+
+	if result.RowsAffected == 0 {
+		c.JSON(404, gin.H{"error": "Venue not found."})
+		return
+	}
+
+	database.DB.First(&venue, id)
+
+	c.JSON(200, venue)
+}
+
+func DeleteVenue(c *gin.Context) {
+	id := c.Param("id")
+
+	// Схема зала площадки удаляется вместе с ней, поэтому места не должны быть заняты тикетами
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := checkSeatMapsUnbound(tx, tx.Model(&models.SeatMap{}).Select("id").Where("venue_id = ?", id)); err != nil {
+			return err
+		}
+		return tx.Delete(&models.Venue{}, id).Error
+	})
+
+	if err != nil {
+		if respondSeatError(c, err) {
+			return
+		}
+		log.Printf("Database Error (Delete): %v", err)
+		c.JSON(500, gin.H{"error": "Failed to delete venue. Database error."})
+		return
+	}
+
+	c.JSON(200, gin.H{})
+}
+
+// @Summary Создать площадку
+// @Description Создает площадку с адресом, координатами, часовым поясом и вместимостью
+// @Tags Venues
+// @Accept json
+// @Produce json
+// @Param venue body models.CreateVenueRequest true "Данные площадки"
+// @Success 201 {object} models.Venue
+// @Failure 400 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /venues [post]
+func PostVenue(c *gin.Context) {
+	var newVenue models.CreateVenueRequest
+
+	if err := c.ShouldBindJSON(&newVenue); err != nil {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+
+	if _, err := time.LoadLocation(newVenue.Timezone); err != nil {
+		c.JSON(400, gin.H{"error": "Timezone must be a valid IANA time zone name"})
+		return
+	}
+
+	venue := models.Venue{
+		Name:      newVenue.Name,
+		Address:   newVenue.Address,
+		City:      newVenue.City,
+		Country:   newVenue.Country,
+		Latitude:  newVenue.Latitude,
+		Longitude: newVenue.Longitude,
+		Timezone:  newVenue.Timezone,
+		Capacity:  newVenue.Capacity,
+	}
+
+	result := database.DB.Create(&venue)
+
+	if result.Error != nil {
+		log.Printf("Database Error (Create): %v", result.Error)
+		c.JSON(500, gin.H{"error": "Failed to create venue. Database error."})
+		return
+	}
+
+	c.JSON(201, venue)
+}
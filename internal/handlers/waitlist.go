@@ -0,0 +1,192 @@
+package handlers
+
+import (
+	"errors"
+	"eventflow/internal/database"
+	"eventflow/internal/models"
+	"log"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Статусы регистраций, которые занимают место на событии
+var occupyingStatuses = []string{"registered", "attended", "no-show"}
+
+// lockEvent блокирует строку события до конца транзакции, чтобы параллельные
+// регистрации и отмены на одно событие выполнялись последовательно.
+func lockEvent(tx *gorm.DB, eventID uint, event *models.Event) error {
+	return tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(event, eventID).Error
+}
+
+// freeSeats возвращает число свободных мест на событии; limited = false - без ограничения мест
+func freeSeats(tx *gorm.DB, event *models.Event) (free int64, limited bool, err error) {
+	if event.Capacity == nil {
+		return 0, false, nil
+	}
+
+	var occupied int64
+	err = tx.Model(&models.EventRegistration{}).
+		Where("event_id = ? AND status IN ?", event.ID, occupyingStatuses).
+		Count(&occupied).Error
+	if err != nil {
+		return 0, true, err
+	}
+
+	return int64(*event.Capacity) - occupied, true, nil
+}
+
+func hasFreeSeat(tx *gorm.DB, event *models.Event) (bool, error) {
+	free, limited, err := freeSeats(tx, event)
+	return !limited || free > 0, err
+}
+
+// hasSeatBeyondWaitlist проверяет, что свободное место останется после всего листа ожидания.
+// Новые регистрации и покупки не занимают места, освободившиеся для очереди: на платном
+// событии очередь не продвигается автоматически, и места ждут покупки участников из нее
+func hasSeatBeyondWaitlist(tx *gorm.DB, event *models.Event) (bool, error) {
+	free, limited, err := freeSeats(tx, event)
+	if err != nil || !limited {
+		return !limited, err
+	}
+
+	var waitlisted int64
+	err = tx.Model(&models.EventRegistration{}).
+		Where("event_id = ? AND status = ?", event.ID, "waitlisted").
+		Count(&waitlisted).Error
+	if err != nil {
+		return false, err
+	}
+
+	return free > waitlisted, nil
+}
+
+// waitlistTurnReached проверяет, что очередь участника из листа ожидания дошла:
+// перед ним в очереди меньше участников, чем свободных мест
+func waitlistTurnReached(tx *gorm.DB, event *models.Event, registration *models.EventRegistration) (bool, error) {
+	free, limited, err := freeSeats(tx, event)
+	if err != nil || !limited {
+		return !limited, err
+	}
+
+	if err := fillWaitlistPosition(tx, registration); err != nil {
+		return false, err
+	}
+	return int64(registration.WaitlistPosition) <= free, nil
+}
+
+// promoteWaitlist переводит регистрации из листа ожидания в "registered"
+// в порядке очереди, пока на событии есть свободные места, и выдает им тикеты.
+// Должна вызываться внутри транзакции.
+func promoteWaitlist(tx *gorm.DB, eventID uint) ([]models.EventRegistration, error) {
+	var event models.Event
+	if err := lockEvent(tx, eventID, &event); err != nil {
+		return nil, err
+	}
+
+	if containsString(closedEventStatuses, event.Status) {
+		return nil, nil
+	}
+
+	var promoted []models.EventRegistration
+	for {
+		free, err := hasFreeSeat(tx, &event)
+		if err != nil {
+			return nil, err
+		}
+		if !free {
+			break
+		}
+
+		var next models.EventRegistration
+		err = tx.Where("event_id = ? AND status = ?", eventID, "waitlisted").
+			Order("registered_at ASC, id ASC").
+			First(&next).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		// Без бесплатного тикета очередь стоит: место ждет покупки участника из очереди (checkBuyerRegistration)
+		_, err = issueRegistrationTicket(tx, next.EventID, next.ParticipantID)
+		if errors.Is(err, errTierSoldOut) || errors.Is(err, errPaymentRequired) {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		if err := tx.Model(&next).Update("status", "registered").Error; err != nil {
+			return nil, err
+		}
+
+		log.Printf("Waitlist: registration %d promoted for event %d", next.ID, eventID)
+		promoted = append(promoted, next)
+	}
+
+	return promoted, nil
+}
+
+// fillWaitlistPosition вычисляет позицию регистрации в листе ожидания (начиная с 1)
+func fillWaitlistPosition(db *gorm.DB, registration *models.EventRegistration) error {
+	if registration.Status != "waitlisted" {
+		registration.WaitlistPosition = 0
+		return nil
+	}
+
+	var ahead int64
+	err := db.Model(&models.EventRegistration{}).
+		Where("event_id = ? AND status = ?", registration.EventID, "waitlisted").
+		Where("registered_at < ? OR (registered_at = ? AND id < ?)",
+			registration.RegisteredAt, registration.RegisteredAt, registration.ID).
+		Count(&ahead).Error
+	if err != nil {
+		return err
+	}
+
+	registration.WaitlistPosition = int(ahead) + 1
+	return nil
+}
+
+// @Summary Лист ожидания события
+// @Description Возвращает регистрации в листе ожидания в порядке очереди
+// @Tags EventRegistrations
+// @Accept json
+// @Produce json
+// @Param id path int true "ID события"
+// @Success 200 {array} models.EventRegistration
+// @Failure 404 {object} map[string]string
+// @Router /events/{id}/waitlist [get]
+func GetEventWaitlist(c *gin.Context) {
+	id := c.Param("id")
+
+	var event models.Event
+	result := database.DB.First(&event, id)
+	if result.Error != nil {
+		if errors.Is(result.Error, gorm.ErrRecordNotFound) {
+			c.JSON(404, gin.H{"error": "Event not found"})
+		} else {
+			c.JSON(500, gin.H{"error": "Database error"})
+		}
+		return
+	}
+
+	var waitlist []models.EventRegistration
+	result = database.DB.
+		Where("event_id = ? AND status = ?", event.ID, "waitlisted").
+		Order("registered_at ASC, id ASC").
+		Find(&waitlist)
+	if result.Error != nil {
+		c.JSON(500, gin.H{"error": "Database error"})
+		return
+	}
+
+	for i := range waitlist {
+		waitlist[i].WaitlistPosition = i + 1
+	}
+
+	c.JSON(200, waitlist)
+}
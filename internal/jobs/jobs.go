@@ -0,0 +1,43 @@
+// Package jobs содержит фоновые задачи, которые выполняются внутри API-процесса.
+// Задачи рассчитаны на запуск в нескольких репликах одновременно: каждая
+// обрабатываемая строка захватывается через SELECT ... FOR UPDATE SKIP LOCKED.
+package jobs
+
+import (
+	"log"
+	"os"
+	"time"
+)
+
+// Every запускает fn сразу и затем каждые interval. Ошибки логируются,
+// задача продолжает работать.
+func Every(name string, interval time.Duration, fn func() error) {
+	go func() {
+		log.Printf("⏱️ Job %s started (every %s)", name, interval)
+
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			if err := fn(); err != nil {
+				log.Printf("❌ Job %s failed: %v", name, err)
+			}
+			<-ticker.C
+		}
+	}()
+}
+
+// IntervalFromEnv читает интервал из переменной окружения в формате time.ParseDuration
+func IntervalFromEnv(key string, fallback time.Duration) time.Duration {
+	value := os.Getenv(key)
+	if value == "" {
+		return fallback
+	}
+
+	interval, err := time.ParseDuration(value)
+	if err != nil || interval <= 0 {
+		log.Printf("Warning: invalid %s=%q, using %s", key, value, fallback)
+		return fallback
+	}
+	return interval
+}
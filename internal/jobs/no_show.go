@@ -0,0 +1,112 @@
+package jobs
+
+import (
+	"eventflow/internal/database"
+	"eventflow/internal/models"
+	"log"
+	"os"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Максимальное число событий, обрабатываемых за один проход
+const noShowBatchSize = 20
+
+// Статусы событий, по которым отмечаются неявки: отмененные и перенесенные пропускаются
+var noShowEventStatuses = []string{"scheduled", "live", "completed"}
+
+// StartNoShowMarking запускает отметку неявок после окончания событий.
+// Интервал задается переменной окружения NO_SHOW_INTERVAL (по умолчанию 5m),
+// время ожидания после окончания события - NO_SHOW_GRACE (по умолчанию 2h).
+func StartNoShowMarking() {
+	grace := IntervalFromEnv("NO_SHOW_GRACE", 2*time.Hour)
+	Every("no-show", IntervalFromEnv("NO_SHOW_INTERVAL", 5*time.Minute), func() error {
+		return MarkNoShows(grace)
+	})
+}
+
+// MarkNoShows закрывает посещаемость событий, закончившихся раньше чем grace назад:
+// регистрации "registered" переходят в "no-show", активные тикеты этих участников -
+// в "expired". Событие захватывается с SKIP LOCKED и помечается attendance_closed_at,
+// поэтому повторный запуск или другая реплика не обработают его еще раз.
+func MarkNoShows(grace time.Duration) error {
+	now := time.Now().UTC()
+	host, _ := os.Hostname()
+
+	var closed int
+	var marked, expired int64
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		var due []models.Event
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+			Where("attendance_closed_at IS NULL AND end_time <= ? AND status IN ?", now.Add(-grace), noShowEventStatuses).
+			Order("end_time").
+			Limit(noShowBatchSize).
+			Find(&due).Error
+		if err != nil {
+			return err
+		}
+
+		for _, event := range due {
+			run, err := closeEventAttendance(tx, event.ID, grace, host, now)
+			if err != nil {
+				return err
+			}
+			closed++
+			marked += run.RegistrationsMarked
+			expired += run.TicketsExpired
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	log.Printf("No-show marking: %d events closed, %d registrations marked no-show, %d tickets expired (grace %s)",
+		closed, marked, expired, grace)
+	return nil
+}
+
+// closeEventAttendance отмечает неявки на одном событии, заблокированном в tx,
+// и сохраняет запись о запуске
+func closeEventAttendance(tx *gorm.DB, eventID uint, grace time.Duration, host string, now time.Time) (*models.NoShowRun, error) {
+	registrations := tx.Model(&models.EventRegistration{}).
+		Where("event_id = ? AND status = ?", eventID, "registered").
+		Update("status", "no-show")
+	if registrations.Error != nil {
+		return nil, registrations.Error
+	}
+
+	// Остаток категорий не возвращается: событие уже прошло
+	tickets := tx.Model(&models.Ticket{}).
+		Where("event_id = ? AND status = ?", eventID, "active").
+		Where("participant_id IN (?)", tx.Model(&models.EventRegistration{}).
+			Select("participant_id").
+			Where("event_id = ? AND status = ?", eventID, "no-show")).
+		Update("status", "expired")
+	if tickets.Error != nil {
+		return nil, tickets.Error
+	}
+
+	if err := tx.Model(&models.Event{}).Where("id = ?", eventID).Update("attendance_closed_at", now).Error; err != nil {
+		return nil, err
+	}
+
+	run := models.NoShowRun{
+		EventID:             eventID,
+		RegistrationsMarked: registrations.RowsAffected,
+		TicketsExpired:      tickets.RowsAffected,
+		GraceSeconds:        int64(grace / time.Second),
+		Host:                host,
+	}
+	if err := tx.Create(&run).Error; err != nil {
+		return nil, err
+	}
+
+	if run.RegistrationsMarked > 0 || run.TicketsExpired > 0 {
+		log.Printf("No-show marking: event %d closed, %d registrations marked no-show, %d tickets expired",
+			eventID, run.RegistrationsMarked, run.TicketsExpired)
+	}
+	return &run, nil
+}
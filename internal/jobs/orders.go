@@ -0,0 +1,64 @@
+package jobs
+
+import (
+	"eventflow/internal/database"
+	"eventflow/internal/models"
+	"log"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Максимальное число заказов, обрабатываемых за один проход
+const orderExpiryBatchSize = 100
+
+// StartOrderExpiry запускает освобождение билетов по неоплаченным заказам.
+// Интервал задается переменной окружения ORDER_EXPIRY_INTERVAL (по умолчанию 1m).
+func StartOrderExpiry() {
+	Every("order-expiry", IntervalFromEnv("ORDER_EXPIRY_INTERVAL", time.Minute), ExpirePendingOrders)
+}
+
+// ExpirePendingOrders переводит в "expired" заказы, срок удержания которых истек,
+// и возвращает их билеты в продажу.
+func ExpirePendingOrders() error {
+	return database.DB.Transaction(func(tx *gorm.DB) error {
+		var due []models.Order
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+			Preload("Items").
+			Where("status = ? AND expires_at <= ?", "pending", time.Now().UTC()).
+			Order("expires_at").
+			Limit(orderExpiryBatchSize).
+			Find(&due).Error
+		if err != nil {
+			return err
+		}
+
+		for i := range due {
+			if err := ExpireOrder(tx, &due[i]); err != nil {
+				return err
+			}
+		}
+
+		if len(due) > 0 {
+			log.Printf("Order expiry: %d orders expired", len(due))
+		}
+		return nil
+	})
+}
+
+// ExpireOrder переводит заблокированный в tx заказ в "expired" и освобождает
+// удерживаемые им билеты категорий. Items заказа должны быть загружены.
+func ExpireOrder(tx *gorm.DB, order *models.Order) error {
+	for _, item := range order.Items {
+		err := tx.Model(&models.TicketTier{}).
+			Where("id = ?", item.TierID).
+			Update("sold", gorm.Expr("GREATEST(sold - ?, 0)", item.Quantity)).Error
+		if err != nil {
+			return err
+		}
+	}
+
+	order.Status = "expired"
+	return tx.Model(order).Update("status", "expired").Error
+}
@@ -0,0 +1,79 @@
+package jobs
+
+import (
+	"eventflow/internal/database"
+	"eventflow/internal/models"
+	"log"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Максимальное число событий, обрабатываемых за один проход
+const publishBatchSize = 100
+
+// StartPublishScheduler запускает плановую публикацию и снятие событий с публикации.
+// Интервал задается переменной окружения PUBLISH_SCHEDULER_INTERVAL (по умолчанию 1m).
+func StartPublishScheduler() {
+	Every("publish-scheduler", IntervalFromEnv("PUBLISH_SCHEDULER_INTERVAL", time.Minute), PublishDueEvents)
+}
+
+// PublishDueEvents публикует события, у которых наступило publish_at, и снимает
+// с публикации события с наступившим unpublish_at. Строки захватываются с SKIP LOCKED,
+// поэтому несколько реплик не обработают одно событие дважды.
+func PublishDueEvents() error {
+	return database.DB.Transaction(func(tx *gorm.DB) error {
+		now := time.Now().UTC()
+
+		published, err := applyScheduledPublication(tx, "publish_at", "draft", "published", now)
+		if err != nil {
+			return err
+		}
+
+		unpublished, err := applyScheduledPublication(tx, "unpublish_at", "published", "draft", now)
+		if err != nil {
+			return err
+		}
+
+		if published+unpublished > 0 {
+			log.Printf("Publish scheduler: %d published, %d unpublished", published, unpublished)
+		}
+		return nil
+	})
+}
+
+func applyScheduledPublication(tx *gorm.DB, column, from, to string, now time.Time) (int, error) {
+	var due []models.Event
+	err := tx.Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+		Where(column+" <= ? AND publish_status = ?", now, from).
+		Order(column).
+		Limit(publishBatchSize).
+		Find(&due).Error
+	if err != nil {
+		return 0, err
+	}
+
+	for _, event := range due {
+		// Плановая дата сбрасывается, чтобы ручное изменение статуса после нее не отменялось
+		err := tx.Model(&models.Event{}).Where("id = ?", event.ID).Updates(map[string]interface{}{
+			"publish_status": to,
+			column:           nil,
+		}).Error
+		if err != nil {
+			return 0, err
+		}
+
+		err = tx.Create(&models.EventPublication{
+			EventID:    event.ID,
+			FromStatus: from,
+			ToStatus:   to,
+			Source:     "scheduler",
+		}).Error
+		if err != nil {
+			return 0, err
+		}
+	}
+
+	return len(due), nil
+}
@@ -0,0 +1,85 @@
+package middleware
+
+import (
+	"math"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ipBucket - корзина токенов одного IP-адреса
+type ipBucket struct {
+	tokens float64
+	last   time.Time
+}
+
+// ipRateLimiter пополняет корзину каждого адреса со скоростью limit запросов за window
+type ipRateLimiter struct {
+	mu        sync.Mutex
+	limit     float64
+	window    time.Duration
+	buckets   map[string]*ipBucket
+	lastSweep time.Time
+}
+
+// allow списывает токен адреса ip. Если токенов нет, возвращает время до появления следующего.
+func (l *ipRateLimiter) allow(ip string, now time.Time) (bool, time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.sweep(now)
+
+	rate := l.limit / l.window.Seconds()
+	bucket, ok := l.buckets[ip]
+	if !ok {
+		bucket = &ipBucket{tokens: l.limit, last: now}
+		l.buckets[ip] = bucket
+	} else {
+		bucket.tokens = math.Min(l.limit, bucket.tokens+now.Sub(bucket.last).Seconds()*rate)
+		bucket.last = now
+	}
+
+	if bucket.tokens < 1 {
+		return false, time.Duration((1 - bucket.tokens) / rate * float64(time.Second))
+	}
+	bucket.tokens--
+	return true, 0
+}
+
+// sweep удаляет корзины, которые успели наполниться полностью: они не отличаются от новых
+func (l *ipRateLimiter) sweep(now time.Time) {
+	if now.Sub(l.lastSweep) < l.window {
+		return
+	}
+	l.lastSweep = now
+	for ip, bucket := range l.buckets {
+		if now.Sub(bucket.last) >= l.window {
+			delete(l.buckets, ip)
+		}
+	}
+}
+
+// RateLimit ограничивает число запросов с одного IP-адреса: не больше limit за window,
+// с равномерным пополнением. Превышение - 429 с заголовком Retry-After.
+// Корзины хранятся в памяти процесса: при N репликах за балансировщиком адрес
+// фактически получает до N*limit запросов. IP берется из c.ClientIP(), поэтому
+// за прокси должны быть настроены доверенные прокси (TRUSTED_PROXIES).
+func RateLimit(limit int, window time.Duration) gin.HandlerFunc {
+	limiter := &ipRateLimiter{
+		limit:   float64(limit),
+		window:  window,
+		buckets: make(map[string]*ipBucket),
+	}
+	return func(c *gin.Context) {
+		ok, retryAfter := limiter.allow(c.ClientIP(), time.Now())
+		if !ok {
+			c.Header("Retry-After", strconv.Itoa(int(math.Ceil(retryAfter.Seconds()))))
+			c.JSON(429, gin.H{"error": "Too many requests", "reason": "rate_limited"})
+			c.Abort()
+			return
+		}
+		c.Next()
+	}
+}
@@ -0,0 +1,92 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestIPRateLimiterAllow(t *testing.T) {
+	start := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	// 3 запроса в минуту: токен пополняется каждые 20 секунд
+	tests := []struct {
+		name      string
+		ip        string
+		at        time.Duration
+		wantOK    bool
+		wantRetry time.Duration
+	}{
+		{"first", "10.0.0.1", 0, true, 0},
+		{"second", "10.0.0.1", 0, true, 0},
+		{"third", "10.0.0.1", time.Second, true, 0},
+		{"limit reached", "10.0.0.1", 2 * time.Second, false, 18 * time.Second},
+		{"other address", "10.0.0.2", 2 * time.Second, true, 0},
+		{"partly refilled", "10.0.0.1", 12 * time.Second, false, 8 * time.Second},
+		{"refilled one token", "10.0.0.1", 22 * time.Second, true, 0},
+		{"empty again", "10.0.0.1", 22 * time.Second, false, 18 * time.Second},
+		{"refill is capped", "10.0.0.1", 10 * time.Minute, true, 0},
+		{"capped second", "10.0.0.1", 10 * time.Minute, true, 0},
+		{"capped third", "10.0.0.1", 10 * time.Minute, true, 0},
+		{"capped limit", "10.0.0.1", 10 * time.Minute, false, 20 * time.Second},
+	}
+
+	limiter := &ipRateLimiter{limit: 3, window: time.Minute, buckets: make(map[string]*ipBucket)}
+	for _, tt := range tests {
+		ok, retry := limiter.allow(tt.ip, start.Add(tt.at))
+		if ok != tt.wantOK || retry.Round(time.Millisecond) != tt.wantRetry {
+			t.Errorf("%s: allow = %v, %v, want %v, %v", tt.name, ok, retry, tt.wantOK, tt.wantRetry)
+		}
+	}
+}
+
+func TestIPRateLimiterSweep(t *testing.T) {
+	start := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	limiter := &ipRateLimiter{limit: 5, window: time.Minute, buckets: make(map[string]*ipBucket)}
+
+	limiter.allow("10.0.0.1", start)
+	limiter.allow("10.0.0.2", start.Add(50*time.Second))
+	limiter.allow("10.0.0.3", start.Add(70*time.Second))
+
+	// Корзина 10.0.0.1 простояла окно и удалена, 10.0.0.2 еще пополняется
+	if _, ok := limiter.buckets["10.0.0.1"]; ok {
+		t.Error("full bucket was not swept")
+	}
+	if len(limiter.buckets) != 2 {
+		t.Errorf("buckets = %d, want 2", len(limiter.buckets))
+	}
+}
+
+func TestRateLimit(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(RateLimit(2, time.Minute))
+	router.GET("/", func(c *gin.Context) { c.Status(http.StatusOK) })
+
+	tests := []struct {
+		remoteAddr string
+		wantStatus int
+	}{
+		{"192.0.2.1:1000", http.StatusOK},
+		{"192.0.2.1:1001", http.StatusOK},
+		{"192.0.2.1:1002", http.StatusTooManyRequests},
+		{"192.0.2.2:1000", http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.RemoteAddr = tt.remoteAddr
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		if w.Code != tt.wantStatus {
+			t.Errorf("%s: status = %d, want %d", tt.remoteAddr, w.Code, tt.wantStatus)
+		}
+		if tt.wantStatus == http.StatusTooManyRequests && w.Header().Get("Retry-After") != "30" {
+			t.Errorf("%s: Retry-After = %q, want 30", tt.remoteAddr, w.Header().Get("Retry-After"))
+		}
+	}
+}
@@ -0,0 +1,48 @@
+package models
+
+import "time"
+
+// CheckIn - сканирование тикета на входе: онлайн или выгруженное сканером после работы без сети
+type CheckIn struct {
+	ID             uint      `gorm:"primaryKey" json:"id"`
+	EventID        uint      `json:"event_id"`
+	TicketID       uint      `json:"ticket_id"`
+	ParticipantID  uint      `json:"participant_id"`
+	DeviceID       string    `json:"device_id"`
+	Gate           string    `json:"gate"`
+	OperatorID     *uint     `json:"operator_id"` // организатор, выполнивший сканирование
+	Direction      string    `json:"direction"`   // "in" или "out"
+	SessionID      *uint     `json:"session_id"`  // сканирование на входе в сессию, в политике прохода не учитывается
+	ScanID         string    `json:"scan_id"`     // идентификатор сканирования на устройстве, для повторной выгрузки
+	ScannedAt      time.Time `json:"scanned_at"`
+	Source         string    `json:"source"` // "online" или "offline"
+	Status         string    `json:"status"` // "accepted" или "conflict" (проход запрещен политикой события)
+	ConflictWithID *uint     `json:"conflict_with_id"`
+	CreatedAt      time.Time `json:"created_at"`
+}
+
+// OfflineScan - сканирование, сохраненное устройством без подключения
+type OfflineScan struct {
+	ScanID    string    `json:"scan_id" binding:"required,max=64"`
+	Code      string    `json:"code" binding:"required"` // содержимое QR-кода: токен или код тикета
+	ScannedAt time.Time `json:"scanned_at" binding:"required"`
+	Direction string    `json:"direction" binding:"omitempty,oneof=in out"` // по умолчанию "in"
+	Gate      string    `json:"gate" binding:"max=64"`
+}
+
+type CheckInSyncRequest struct {
+	DeviceID string        `json:"device_id" binding:"required,max=64"`
+	Scans    []OfflineScan `json:"scans" binding:"required,min=1,max=1000,dive"`
+}
+
+// CheckInSyncResult - итог сверки одного сканирования
+type CheckInSyncResult struct {
+	ScanID    string   `json:"scan_id"`
+	TicketID  uint     `json:"ticket_id,omitempty"`
+	Status    string   `json:"status"` // "accepted", "conflict" или "rejected"
+	Reason    string   `json:"reason,omitempty"`
+	CheckIn   *CheckIn `json:"check_in,omitempty"`
+	FirstScan *CheckIn `json:"first_scan,omitempty"` // при конфликте - сканирование, которое прошло первым
+	// Сканирование другого устройства, которое оказалось более поздним и стало конфликтом
+	Superseded *CheckIn `json:"superseded,omitempty"`
+}
@@ -1,29 +1,126 @@
 package models
 
-import "time"
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
 
 type Event struct {
 	ID            uint      `gorm:"primaryKey" json:"id"`
 	Title         string    `json:"title"`
 	Description   string    `gorm:"type:text" json:"description"`
-	StartTime     time.Time `json:"start_time"`
-	EndTime       time.Time `json:"end_time"`
+	StartTime     time.Time `json:"start_time"` // хранится в UTC
+	EndTime       time.Time `json:"end_time"`   // хранится в UTC
+	Timezone      string    `json:"timezone"`   // IANA, например "Europe/Moscow"
 	EventType     string    `json:"event_type"`
-	Status        string    `json:"status"`
+	Status        string    `json:"status"`         // "draft", "scheduled", "live", "completed", "postponed" или "canceled"
 	PublishStatus string    `json:"publish_status"` // "draft" или "published"
 	CategoryID    uint      `json:"category_id"`
+	Capacity      *int      `json:"capacity"` // nil - без ограничения мест
+	VenueID       *uint     `json:"venue_id"`
+
+	// Вхождение повторяющейся серии: RecurrenceID - исходное начало вхождения по правилу,
+	// IsException - вхождение изменено отдельно и не перезаписывается при изменении серии
+	SeriesID     *uint      `json:"series_id"`
+	RecurrenceID *time.Time `json:"recurrence_id"`
+	IsException  bool       `json:"is_exception"`
+
+	StatusReason    string     `json:"status_reason"`
+	StatusChangedAt *time.Time `json:"status_changed_at"`
+
+	// Плановая публикация и снятие с публикации, выполняются планировщиком
+	PublishAt   *time.Time `json:"publish_at"`
+	UnpublishAt *time.Time `json:"unpublish_at"`
+
+	// Политика возвратов: "allowed" или "none". RefundCutoffHours - за сколько часов
+	// до начала события возвраты закрываются (nil - до начала события)
+	RefundPolicy      string `gorm:"default:allowed" json:"refund_policy"`
+	RefundCutoffHours *int   `json:"refund_cutoff_hours"`
+
+	// Политика прохода: "single" - один вход, "reentry" - повторный вход после выхода,
+	// "limited" - не больше MaxEntries входов
+	EntryPolicy string `gorm:"default:single" json:"entry_policy"`
+	MaxEntries  *int   `json:"max_entries"`
+
+	// Передача тикетов другим участникам: "allowed" или "none". TransferCutoffHours -
+	// за сколько часов до начала события передача закрывается (nil - до начала события)
+	TransferPolicy      string `gorm:"default:allowed" json:"transfer_policy"`
+	TransferCutoffHours *int   `json:"transfer_cutoff_hours"`
+	// Режим регистрации: "open" - регистрация сразу получает тикет, "approval" - заявка
+	// ждет решения организатора в статусе "pending", тикет выдается после одобрения
+	RegistrationMode string `gorm:"default:open" json:"registration_mode"`
+	// Время, когда задача отметки неявок закрыла посещаемость события
+	AttendanceClosedAt *time.Time `json:"attendance_closed_at"`
 
 	CreatedAt time.Time `json:"created_at"`
 	UpdatedAt time.Time `json:"updated_at"`
+
+	// Местное время события в его часовом поясе (RFC 3339 со смещением)
+	LocalStartTime string `gorm:"-" json:"local_start_time"`
+	LocalEndTime   string `gorm:"-" json:"local_end_time"`
+}
+
+// locationOrUTC возвращает часовой пояс по имени IANA (UTC, если он не задан или неизвестен)
+func locationOrUTC(name string) *time.Location {
+	if loc, err := time.LoadLocation(name); err == nil && name != "" {
+		return loc
+	}
+	return time.UTC
+}
+
+func (e *Event) Location() *time.Location {
+	return locationOrUTC(e.Timezone)
+}
+
+func (e *Event) fillLocalTimes() {
+	e.StartTime = e.StartTime.UTC()
+	e.EndTime = e.EndTime.UTC()
+	if e.RecurrenceID != nil {
+		recurrenceID := e.RecurrenceID.UTC()
+		e.RecurrenceID = &recurrenceID
+	}
+
+	loc := e.Location()
+	e.LocalStartTime = e.StartTime.In(loc).Format(time.RFC3339)
+	e.LocalEndTime = e.EndTime.In(loc).Format(time.RFC3339)
+}
+
+func (e *Event) AfterFind(tx *gorm.DB) error {
+	e.fillLocalTimes()
+	return nil
+}
+
+func (e *Event) AfterCreate(tx *gorm.DB) error {
+	e.fillLocalTimes()
+	return nil
 }
 
 type CreateEventRequest struct {
-	Title         string    `json:"title" binding:"required"`
-	Description   string    `json:"description"`
-	StartTime     time.Time `json:"start_time" binding:"required"`
-	EndTime       time.Time `json:"end_time" binding:"required"`
-	EventType     string    `json:"event_type" binding:"required"`
-	Status        string    `json:"status"`
-	PublishStatus string    `json:"publish_status" binding:"required"`
-	CategoryID    uint      `json:"category_id" binding:"required"`
+	Title               string     `json:"title" binding:"required"`
+	Description         string     `json:"description"`
+	StartTime           time.Time  `json:"start_time" binding:"required"` // RFC 3339 со смещением
+	EndTime             time.Time  `json:"end_time" binding:"required"`
+	Timezone            string     `json:"timezone"` // по умолчанию - часовой пояс площадки или UTC
+	EventType           string     `json:"event_type" binding:"required"`
+	Status              string     `json:"status"` // новое событие - "draft" или "scheduled", далее - по жизненному циклу
+	PublishStatus       string     `json:"publish_status" binding:"required"`
+	CategoryID          uint       `json:"category_id" binding:"required"`
+	Capacity            *int       `json:"capacity" binding:"omitempty,min=0"` // по умолчанию - вместимость площадки
+	VenueID             *uint      `json:"venue_id"`
+	PublishAt           *time.Time `json:"publish_at"`
+	UnpublishAt         *time.Time `json:"unpublish_at"`
+	RefundPolicy        string     `json:"refund_policy" binding:"omitempty,oneof=allowed none"` // по умолчанию "allowed"
+	RefundCutoffHours   *int       `json:"refund_cutoff_hours" binding:"omitempty,min=0"`
+	EntryPolicy         string     `json:"entry_policy" binding:"omitempty,oneof=single reentry limited"` // по умолчанию "single"
+	MaxEntries          *int       `json:"max_entries" binding:"omitempty,min=1"`                         // обязательно для "limited"
+	TransferPolicy      string     `json:"transfer_policy" binding:"omitempty,oneof=allowed none"`        // по умолчанию "allowed"
+	TransferCutoffHours *int       `json:"transfer_cutoff_hours" binding:"omitempty,min=0"`
+	RegistrationMode    string     `json:"registration_mode" binding:"omitempty,oneof=open approval"` // по умолчанию "open"
+}
+
+type EventTransitionRequest struct {
+	Reason    string     `json:"reason"`
+	StartTime *time.Time `json:"start_time"` // новые даты при переносе или повторном назначении
+	EndTime   *time.Time `json:"end_time"`
 }
@@ -0,0 +1,14 @@
+package models
+
+import "time"
+
+// EventPublication - запись об изменении статуса публикации события
+type EventPublication struct {
+	ID          uint      `gorm:"primaryKey" json:"id"`
+	EventID     uint      `json:"event_id"`
+	FromStatus  string    `json:"from_status"`
+	ToStatus    string    `json:"to_status"`
+	Source      string    `json:"source"` // "manual" или "scheduler"
+	OrganizerID *uint     `json:"organizer_id"`
+	CreatedAt   time.Time `json:"created_at"`
+}
@@ -7,13 +7,51 @@ type EventRegistration struct {
 	EventID       uint      `gorm:"uniqueIndex:idx_participant_event" json:"event_id"`
 	ParticipantID uint      `gorm:"uniqueIndex:idx_participant_event" json:"participant_id"`
 	RegisteredAt  time.Time `json:"registered_at"`
-	Status        string    `json:"status"`
-	CreatedAt     time.Time `json:"created_at"`
-	UpdatedAt     time.Time `json:"updated_at"`
+	Status        string    `json:"status"`     // "pending", "registered", "attended", "no-show", "waitlisted", "rejected" или "canceled"
+	PromoCode     string    `json:"promo_code"` // промокод заявки, применяется к тикету при одобрении
+	// Решение организатора по заявке на событие с одобрением
+	ReviewedBy   *uint      `json:"reviewed_by"`
+	ReviewedAt   *time.Time `json:"reviewed_at"`
+	ReviewReason string     `json:"review_reason"`
+	// Ответы на вопросы анкеты события по ключам вопросов
+	Answers   map[string]interface{} `gorm:"serializer:json" json:"answers"`
+	CreatedAt time.Time              `json:"created_at"`
+	UpdatedAt time.Time              `json:"updated_at"`
+
+	WaitlistPosition int     `gorm:"-" json:"waitlist_position,omitempty"`
+	Ticket           *Ticket `gorm:"-" json:"ticket,omitempty"` // тикет, выданный при создании регистрации
 }
 
 type CreateEventRegistrationRequest struct {
-	EventID       uint   `json:"event_id" binding:"required"`
-	ParticipantID uint   `json:"participant_id" binding:"required"`
-	Status        string `json:"status" binding:"required"`
+	EventID       uint                   `json:"event_id" binding:"required"`
+	ParticipantID uint                   `json:"participant_id" binding:"required"`
+	Status        string                 `json:"status" binding:"required"`             // новая регистрация - "registered" или "pending"
+	PromoCode     string                 `json:"promo_code" binding:"omitempty,max=64"` // применяется к тикету; в листе ожидания не применяется
+	Answers       map[string]interface{} `json:"answers"`                               // ответы на анкету события
+}
+
+// ReviewRegistrationsRequest - пакетное одобрение или отклонение заявок
+type ReviewRegistrationsRequest struct {
+	RegistrationIDs []uint `json:"registration_ids" binding:"required,min=1"`
+	Reason          string `json:"reason"`
+}
+
+// RegistrationReviewResult - результат рассмотрения одной заявки из пакета
+type RegistrationReviewResult struct {
+	RegistrationID uint               `json:"registration_id"`
+	Registration   *EventRegistration `json:"registration,omitempty"`
+	Ticket         *Ticket            `json:"ticket,omitempty"`
+	Error          string             `json:"error,omitempty"`
+	Reason         string             `json:"reason,omitempty"`
+}
+
+// PublicRegistrationRequest - регистрация участника через публичный API
+type PublicRegistrationRequest struct {
+	FullName     string                 `json:"full_name" binding:"required"`
+	Email        string                 `json:"email" binding:"required,email"`
+	Phone        string                 `json:"phone" binding:"required"`
+	PromoCode    string                 `json:"promo_code" binding:"omitempty,max=64"`
+	Answers      map[string]interface{} `json:"answers"`       // ответы на анкету события
+	CaptchaToken string                 `json:"captcha_token"` // ответ виджета капчи, если она включена
+	Website      string                 `json:"website"`       // поле-ловушка для ботов, должно быть пустым
 }
@@ -0,0 +1,54 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type EventSeries struct {
+	ID            uint        `gorm:"primaryKey" json:"id"`
+	Title         string      `json:"title"`
+	Description   string      `gorm:"type:text" json:"description"`
+	StartTime     time.Time   `json:"start_time"` // DTSTART - начало первого вхождения
+	EndTime       time.Time   `json:"end_time"`   // окончание первого вхождения, задает длительность
+	Timezone      string      `json:"timezone"`   // правило разворачивается в местном времени этого пояса
+	RRule         string      `gorm:"column:rrule" json:"rrule"`
+	ExDates       []time.Time `gorm:"serializer:json" json:"exdates"`
+	EventType     string      `json:"event_type"`
+	Status        string      `json:"status"`
+	PublishStatus string      `json:"publish_status"`
+	CategoryID    uint        `json:"category_id"`
+	Capacity      *int        `json:"capacity"`
+	VenueID       *uint       `json:"venue_id"`
+	SyncedUntil   *time.Time  `json:"synced_until"` // горизонт развернутых вхождений бесконечной серии
+
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+func (s *EventSeries) Location() *time.Location {
+	return locationOrUTC(s.Timezone)
+}
+
+func (s *EventSeries) AfterFind(tx *gorm.DB) error {
+	s.StartTime = s.StartTime.UTC()
+	s.EndTime = s.EndTime.UTC()
+	return nil
+}
+
+type CreateEventSeriesRequest struct {
+	Title         string      `json:"title" binding:"required"`
+	Description   string      `json:"description"`
+	StartTime     time.Time   `json:"start_time" binding:"required"`
+	EndTime       time.Time   `json:"end_time" binding:"required"`
+	Timezone      string      `json:"timezone"`
+	RRule         string      `json:"rrule" binding:"required"`
+	ExDates       []time.Time `json:"exdates"`
+	EventType     string      `json:"event_type" binding:"required"`
+	Status        string      `json:"status"`
+	PublishStatus string      `json:"publish_status" binding:"required"`
+	CategoryID    uint        `json:"category_id" binding:"required"`
+	Capacity      *int        `json:"capacity" binding:"omitempty,min=0"`
+	VenueID       *uint       `json:"venue_id"`
+}
@@ -0,0 +1,45 @@
+package models
+
+import "time"
+
+// Import - импорт участников из файла CSV или XLSX. Строки файла сохраняются в импорте
+// и обрабатываются фоновой задачей: проверка (dry-run) ничего не меняет и только
+// собирает ошибки по строкам, применение создает или обновляет участников по email
+// и, если задано событие, регистрирует их.
+// Статусы: "queued", "running", "validated", "completed", "failed"
+type Import struct {
+	ID              uint              `gorm:"primaryKey" json:"id"`
+	FileName        string            `json:"file_name"`
+	Format          string            `json:"format"`                         // "csv" или "xlsx"
+	EventID         *uint             `json:"event_id"`                       // Событие, на которое регистрируются участники
+	Mapping         map[string]string `gorm:"serializer:json" json:"mapping"` // Поле участника -> заголовок столбца
+	Header          []string          `gorm:"serializer:json" json:"header"`
+	Rows            [][]string        `gorm:"serializer:json" json:"-"` // Строки файла без заголовка
+	DryRun          bool              `json:"dry_run"`
+	Status          string            `json:"status"`
+	TotalRows       int               `json:"total_rows"`
+	ProcessedRows   int               `json:"processed_rows"`
+	CreatedCount    int               `json:"created_count"` // При проверке - сколько будет создано
+	UpdatedCount    int               `json:"updated_count"`
+	RegisteredCount int               `json:"registered_count"` // Регистрации с тикетом
+	PendingCount    int               `json:"pending_count"`    // Заявки на событие с одобрением
+	WaitlistedCount int               `json:"waitlisted_count"` // Попали в лист ожидания
+	SkippedCount    int               `json:"skipped_count"`    // Пустые строки и уже зарегистрированные участники
+	ErrorCount      int               `json:"error_count"`
+	Errors          []ImportRowError  `gorm:"serializer:json" json:"-"`
+	FailureReason   string            `json:"failure_reason"`
+	CreatedBy       *uint             `json:"created_by"`
+	StartedAt       *time.Time        `json:"started_at"`
+	HeartbeatAt     *time.Time        `json:"-"` // Обновляется по ходу обработки; по нему подхватывается импорт упавшей реплики
+	FinishedAt      *time.Time        `json:"finished_at"`
+	CreatedAt       time.Time         `json:"created_at"`
+	UpdatedAt       time.Time         `json:"updated_at"`
+}
+
+// ImportRowError - ошибка в строке файла. Row - номер строки как в таблице (заголовок - 1)
+type ImportRowError struct {
+	Row     int    `json:"row"`
+	Field   string `json:"field,omitempty"`
+	Value   string `json:"value,omitempty"`
+	Message string `json:"message"`
+}
@@ -0,0 +1,14 @@
+package models
+
+import "time"
+
+// NoShowRun - запись о закрытии посещаемости события задачей отметки неявок
+type NoShowRun struct {
+	ID                  uint      `gorm:"primaryKey" json:"id"`
+	EventID             uint      `json:"event_id"`
+	RegistrationsMarked int64     `json:"registrations_marked"` // "registered" -> "no-show"
+	TicketsExpired      int64     `json:"tickets_expired"`
+	GraceSeconds        int64     `json:"grace_seconds"`
+	Host                string    `json:"host"` // реплика, выполнившая задачу
+	CreatedAt           time.Time `json:"created_at"`
+}
@@ -0,0 +1,51 @@
+package models
+
+import "time"
+
+// Order - заказ билетов участником.
+// Статусы: "pending", "processing" (идет списание), "paid", "failed", "refunded", "expired"
+type Order struct {
+	ID            uint        `gorm:"primaryKey" json:"id"`
+	EventID       uint        `json:"event_id"`
+	ParticipantID uint        `json:"participant_id"`
+	Status        string      `json:"status"`
+	TotalAmount   int64       `json:"total_amount"` // В минимальных единицах валюты
+	Currency      string      `json:"currency"`
+	ExpiresAt     time.Time   `json:"expires_at"` // До этого момента билеты удерживаются за заказом
+	PaidAt        *time.Time  `json:"paid_at"`
+	Provider      string      `json:"provider"`
+	PaymentID     string      `json:"payment_id"`
+	FailureReason string      `json:"failure_reason"`
+	PromoCodeID   *uint       `json:"promo_code_id"`
+	Discount      int64       `json:"discount"` // Скидка по промокоду на весь заказ
+	Items         []OrderItem `json:"items"`
+	Tickets       []Ticket    `json:"tickets,omitempty"`
+	CreatedAt     time.Time   `json:"created_at"`
+	UpdatedAt     time.Time   `json:"updated_at"`
+}
+
+type OrderItem struct {
+	ID        uint      `gorm:"primaryKey" json:"id"`
+	OrderID   uint      `json:"order_id"`
+	TierID    uint      `json:"tier_id"`
+	Quantity  int       `json:"quantity"`
+	UnitPrice int64     `json:"unit_price"` // Цена билета со скидкой
+	Discount  int64     `json:"discount"`   // Скидка по промокоду на один билет
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type CreateOrderItemRequest struct {
+	TierID   uint `json:"tier_id" binding:"required"`
+	Quantity int  `json:"quantity" binding:"required,min=1"`
+}
+
+type CreateOrderRequest struct {
+	EventID       uint                     `json:"event_id" binding:"required"`
+	ParticipantID uint                     `json:"participant_id" binding:"required"`
+	Items         []CreateOrderItemRequest `json:"items" binding:"required,min=1,dive"`
+	PromoCode     string                   `json:"promo_code" binding:"omitempty,max=64"` // скидка на билеты, к которым промокод применим
+}
+
+type PayOrderRequest struct {
+	PaymentToken string `json:"payment_token"`
+}
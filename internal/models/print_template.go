@@ -0,0 +1,40 @@
+package models
+
+import "time"
+
+// PrintTemplate - макет печатного тикета или бейджа. EventID == nil - макет по умолчанию
+// для всех событий; макет события имеет приоритет над ним.
+type PrintTemplate struct {
+	ID        uint           `gorm:"primaryKey" json:"id"`
+	EventID   *uint          `json:"event_id"`
+	Kind      string         `json:"kind"`   // "ticket" или "badge"
+	Width     float64        `json:"width"`  // мм
+	Height    float64        `json:"height"` // мм
+	Elements  []PrintElement `gorm:"serializer:json" json:"elements"`
+	CreatedAt time.Time      `json:"created_at"`
+	UpdatedAt time.Time      `json:"updated_at"`
+}
+
+// PrintElement - элемент макета. Координаты и размеры в мм от левого верхнего угла.
+// Text - шаблон text/template с полями .Event, .Participant, .Ticket, .StartTime, .EndTime
+type PrintElement struct {
+	Type      string  `json:"type" binding:"required,oneof=text qr box line"`
+	X         float64 `json:"x"`
+	Y         float64 `json:"y"`
+	Width     float64 `json:"width" binding:"min=0"` // для текста - ширина переноса строк (0 - без переноса)
+	Height    float64 `json:"height" binding:"min=0"`
+	Text      string  `json:"text,omitempty"`
+	FontSize  float64 `json:"font_size,omitempty" binding:"min=0"` // пт, по умолчанию 12
+	Bold      bool    `json:"bold,omitempty"`
+	Align     string  `json:"align,omitempty" binding:"omitempty,oneof=left center right"`
+	LineWidth float64 `json:"line_width,omitempty" binding:"min=0"` // пт, по умолчанию 0.5
+	Fill      bool    `json:"fill,omitempty"`
+}
+
+type CreatePrintTemplateRequest struct {
+	EventID  *uint          `json:"event_id"`
+	Kind     string         `json:"kind" binding:"required,oneof=ticket badge"`
+	Width    float64        `json:"width" binding:"required,gt=0,max=1000"`
+	Height   float64        `json:"height" binding:"required,gt=0,max=1000"`
+	Elements []PrintElement `json:"elements" binding:"required,dive"`
+}
@@ -0,0 +1,45 @@
+package models
+
+import "time"
+
+// PromoCode - промокод на скидку при выдаче тикета
+type PromoCode struct {
+	ID                uint       `gorm:"primaryKey" json:"id"`
+	Code              string     `json:"code"`                // Хранится в верхнем регистре
+	DiscountType      string     `json:"discount_type"`       // "percentage" или "fixed"
+	DiscountValue     int64      `json:"discount_value"`      // Процент (1-100) или сумма в минимальных единицах валюты
+	Currency          string     `json:"currency"`            // Валюта фиксированной скидки
+	MaxRedemptions    *int       `json:"max_redemptions"`     // nil - без ограничения
+	MaxPerParticipant *int       `json:"max_per_participant"` // nil - без ограничения
+	Redeemed          int        `json:"redeemed"`
+	ValidFrom         *time.Time `json:"valid_from"`
+	ValidUntil        *time.Time `json:"valid_until"`
+	EventIDs          []uint     `gorm:"serializer:json" json:"event_ids"`    // Пусто - любое событие
+	TicketTypes       []string   `gorm:"serializer:json" json:"ticket_types"` // Пусто - любой тип тикета
+	CreatedAt         time.Time  `json:"created_at"`
+	UpdatedAt         time.Time  `json:"updated_at"`
+}
+
+type CreatePromoCodeRequest struct {
+	Code              string     `json:"code" binding:"required,max=64"`
+	DiscountType      string     `json:"discount_type" binding:"required,oneof=percentage fixed"`
+	DiscountValue     int64      `json:"discount_value" binding:"required,min=1"`
+	Currency          string     `json:"currency"`
+	MaxRedemptions    *int       `json:"max_redemptions" binding:"omitempty,min=1"`
+	MaxPerParticipant *int       `json:"max_per_participant" binding:"omitempty,min=1"`
+	ValidFrom         *time.Time `json:"valid_from"`
+	ValidUntil        *time.Time `json:"valid_until"`
+	EventIDs          []uint     `json:"event_ids"`
+	TicketTypes       []string   `json:"ticket_types"`
+}
+
+// PromoRedemption - применение промокода к тикету
+type PromoRedemption struct {
+	ID             uint      `gorm:"primaryKey" json:"id"`
+	PromoCodeID    uint      `json:"promo_code_id"`
+	ParticipantID  uint      `json:"participant_id"`
+	EventID        uint      `json:"event_id"`
+	TicketID       uint      `json:"ticket_id"`
+	DiscountAmount int64     `json:"discount_amount"`
+	CreatedAt      time.Time `json:"created_at"`
+}
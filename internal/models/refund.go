@@ -0,0 +1,39 @@
+package models
+
+import "time"
+
+// Refund - возврат денег за тикет. Тикет отменяется, когда возвращена вся его цена
+type Refund struct {
+	ID               uint      `gorm:"primaryKey" json:"id"`
+	TicketID         *uint     `json:"ticket_id"` // nil, если тикет удален
+	OrderID          *uint     `json:"order_id"`
+	EventID          uint      `json:"event_id"`
+	Amount           int64     `json:"amount"` // В минимальных единицах валюты
+	Currency         string    `json:"currency"`
+	Reason           string    `json:"reason"`
+	Status           string    `json:"status"` // "processing", "succeeded" или "failed"
+	Provider         string    `json:"provider"`
+	ProviderRefundID string    `json:"provider_refund_id"`
+	FailureReason    string    `json:"failure_reason"`
+	IdempotencyKey   string    `json:"-"`            // Ключ запроса к обработчику: повтор прерванного возврата не вернет деньги дважды
+	OrganizerID      *uint     `json:"organizer_id"` // Организатор, оформивший возврат (nil - возврат по невыполненному заказу)
+	CreatedAt        time.Time `json:"created_at"`
+}
+
+type CreateRefundRequest struct {
+	Amount *int64 `json:"amount" binding:"omitempty,min=1"` // по умолчанию - оставшаяся к возврату сумма
+	Reason string `json:"reason" binding:"required"`
+}
+
+type BulkRefundRequest struct {
+	TicketIDs []uint `json:"ticket_ids" binding:"required,min=1"`
+	Reason    string `json:"reason" binding:"required"`
+}
+
+// BulkRefundResult - результат возврата по одному тикету из пакета
+type BulkRefundResult struct {
+	TicketID uint    `json:"ticket_id"`
+	Refund   *Refund `json:"refund,omitempty"`
+	Error    string  `json:"error,omitempty"`
+	Reason   string  `json:"reason,omitempty"`
+}
@@ -0,0 +1,29 @@
+package models
+
+import "time"
+
+// RegistrationForm - анкета регистрации события: вопросы, на которые участник отвечает
+// при регистрации. У события не больше одной анкеты.
+type RegistrationForm struct {
+	ID        uint        `gorm:"primaryKey" json:"id"`
+	EventID   uint        `json:"event_id"`
+	Fields    []FormField `gorm:"serializer:json" json:"fields"`
+	CreatedAt time.Time   `json:"created_at"`
+	UpdatedAt time.Time   `json:"updated_at"`
+}
+
+// FormField - вопрос анкеты. Key - имя ответа в answers регистрации
+type FormField struct {
+	Key       string   `json:"key" binding:"required,max=64"`
+	Label     string   `json:"label" binding:"required"`
+	Type      string   `json:"type" binding:"required,oneof=text single_choice multi_choice number date consent"`
+	Required  bool     `json:"required"`
+	Options   []string `json:"options,omitempty"`                              // варианты для single_choice и multi_choice
+	MaxLength *int     `json:"max_length,omitempty" binding:"omitempty,min=1"` // для text
+	Min       *float64 `json:"min,omitempty"`                                  // для number
+	Max       *float64 `json:"max,omitempty"`
+}
+
+type SaveRegistrationFormRequest struct {
+	Fields []FormField `json:"fields" binding:"required,dive"`
+}
@@ -0,0 +1,79 @@
+package models
+
+import "time"
+
+// SeatMap - схема зала с нумерованными местами. Схема площадки используется всеми ее
+// событиями; схема события (EventID) заменяет схему площадки для этого события
+type SeatMap struct {
+	ID        uint      `gorm:"primaryKey" json:"id"`
+	VenueID   *uint     `json:"venue_id"`
+	EventID   *uint     `json:"event_id"`
+	Name      string    `json:"name"`
+	Seats     []Seat    `json:"seats,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// Seat - место схемы: сектор, ряд и номер с признаками доступности
+type Seat struct {
+	ID          uint   `gorm:"primaryKey" json:"id"`
+	SeatMapID   uint   `json:"seat_map_id"`
+	Section     string `json:"section"`
+	Row         string `json:"row"`
+	Number      string `json:"number"`
+	Wheelchair  bool   `json:"wheelchair"`   // место для зрителя на коляске
+	Companion   bool   `json:"companion"`    // место сопровождающего
+	LimitedView bool   `json:"limited_view"` // ограниченный обзор
+}
+
+// SeatHold - временное удержание места покупателем до оформления тикета
+type SeatHold struct {
+	ID            uint      `gorm:"primaryKey" json:"id"`
+	EventID       uint      `json:"event_id"`
+	SeatID        uint      `json:"seat_id"`
+	ParticipantID *uint     `json:"participant_id"`
+	HoldToken     string    `json:"hold_token"` // общий для мест, удержанных одним запросом
+	ExpiresAt     time.Time `json:"expires_at"`
+	CreatedAt     time.Time `json:"created_at"`
+}
+
+// SeatAvailability - место на схеме события с состоянием: "available", "held" или "taken"
+type SeatAvailability struct {
+	Seat
+	State         string     `json:"state"`
+	HoldExpiresAt *time.Time `json:"hold_expires_at,omitempty"`
+}
+
+type CreateSeatRequest struct {
+	Number      string `json:"number" binding:"required"`
+	Wheelchair  bool   `json:"wheelchair"`
+	Companion   bool   `json:"companion"`
+	LimitedView bool   `json:"limited_view"`
+}
+
+type CreateSeatRowRequest struct {
+	Label string              `json:"label" binding:"required"`
+	Seats []CreateSeatRequest `json:"seats" binding:"required,min=1,dive"`
+}
+
+type CreateSeatSectionRequest struct {
+	Name string                 `json:"name" binding:"required"`
+	Rows []CreateSeatRowRequest `json:"rows" binding:"required,min=1,dive"`
+}
+
+// CreateSeatMapRequest - схема задается для площадки или для события (ровно одно из двух)
+type CreateSeatMapRequest struct {
+	VenueID  *uint                      `json:"venue_id" binding:"required_without=EventID,excluded_with=EventID"`
+	EventID  *uint                      `json:"event_id"`
+	Name     string                     `json:"name" binding:"required"`
+	Sections []CreateSeatSectionRequest `json:"sections" binding:"required,min=1,dive"`
+}
+
+type UpdateSeatMapRequest struct {
+	Name string `json:"name" binding:"required"`
+}
+
+type CreateSeatHoldRequest struct {
+	SeatIDs       []uint `json:"seat_ids" binding:"required,min=1,max=50"`
+	ParticipantID *uint  `json:"participant_id"`
+}
@@ -0,0 +1,53 @@
+package models
+
+import "time"
+
+// Session - доклад или секция внутри события
+type Session struct {
+	ID          uint      `gorm:"primaryKey" json:"id"`
+	EventID     uint      `json:"event_id"`
+	TrackID     *uint     `json:"track_id"`
+	Title       string    `json:"title"`
+	Description string    `gorm:"type:text" json:"description"`
+	StartTime   time.Time `json:"start_time"`
+	EndTime     time.Time `json:"end_time"`
+	Room        string    `json:"room"`
+	Capacity    *int      `json:"capacity"` // nil - без ограничения мест
+	Speakers    []Speaker `gorm:"many2many:session_speakers" json:"speakers"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+type CreateSessionRequest struct {
+	EventID     uint      `json:"event_id" binding:"required"`
+	TrackID     *uint     `json:"track_id"`
+	Title       string    `json:"title" binding:"required"`
+	Description string    `json:"description"`
+	StartTime   time.Time `json:"start_time" binding:"required"`
+	EndTime     time.Time `json:"end_time" binding:"required"`
+	Room        string    `json:"room"`
+	Capacity    *int      `json:"capacity" binding:"omitempty,min=0"`
+	SpeakerIDs  []uint    `json:"speaker_ids"`
+}
+
+type SessionRegistration struct {
+	ID            uint      `gorm:"primaryKey" json:"id"`
+	SessionID     uint      `gorm:"uniqueIndex:idx_session_participant" json:"session_id"`
+	ParticipantID uint      `gorm:"uniqueIndex:idx_session_participant" json:"participant_id"`
+	Status        string    `json:"status"` // "registered" или "canceled"
+	CreatedAt     time.Time `json:"created_at"`
+	UpdatedAt     time.Time `json:"updated_at"`
+}
+
+type CreateSessionRegistrationRequest struct {
+	ParticipantID uint `json:"participant_id" binding:"required"`
+}
+
+// SessionAttendance - отметка о посещении сессии при сканировании тикета
+type SessionAttendance struct {
+	ID            uint      `gorm:"primaryKey" json:"id"`
+	SessionID     uint      `json:"session_id"`
+	ParticipantID uint      `json:"participant_id"`
+	TicketID      uint      `json:"ticket_id"`
+	CheckedInAt   time.Time `json:"checked_in_at"`
+}
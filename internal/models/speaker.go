@@ -0,0 +1,22 @@
+package models
+
+import "time"
+
+type Speaker struct {
+	ID        uint      `gorm:"primaryKey" json:"id"`
+	FullName  string    `json:"full_name"`
+	Email     string    `json:"email"`
+	Company   string    `json:"company"`
+	Bio       string    `gorm:"type:text" json:"bio"`
+	PhotoURL  string    `json:"photo_url"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+type CreateSpeakerRequest struct {
+	FullName string `json:"full_name" binding:"required"`
+	Email    string `json:"email" binding:"omitempty,email"`
+	Company  string `json:"company"`
+	Bio      string `json:"bio"`
+	PhotoURL string `json:"photo_url" binding:"omitempty,url"`
+}
@@ -5,24 +5,35 @@ import (
 )
 
 type Ticket struct {
-	ID            uint      `gorm:"primaryKey" json:"id"`
-	EventID       uint      `json:"event_id"`
-	ParticipantID uint      `json:"participant_id"`
-	TicketType    string    `json:"ticket_type"` // "free" или "paid"
-	Status        string    `json:"status"`      // "active" или "canceled"
-	QRCode        string    `gorm:"unique" json:"qr_code"`
-	CreatedAt     time.Time `json:"created_at"`
-	UpdatedAt     time.Time `json:"updated_at"`
+	ID             uint      `gorm:"primaryKey" json:"id"`
+	EventID        uint      `json:"event_id"`
+	ParticipantID  uint      `json:"participant_id"`
+	TierID         *uint     `json:"tier_id"`
+	OrderID        *uint     `json:"order_id"`
+	SeatID         *uint     `json:"seat_id"`     // место по схеме зала; одно место - один активный тикет
+	TicketType     string    `json:"ticket_type"` // Название категории; для тикетов без категории "free" или "paid"
+	Price          int64     `json:"price"`       // Цена на момент выдачи, в минимальных единицах валюты
+	Currency       string    `json:"currency"`
+	PromoCodeID    *uint     `json:"promo_code_id"`
+	DiscountAmount int64     `json:"discount_amount"` // Скидка по промокоду, уже вычтенная из Price
+	Status         string    `json:"status"`          // "active", "canceled" или "expired" (не использован до конца события)
+	QRCode         string    `gorm:"unique" json:"qr_code"`
+	CreatedAt      time.Time `json:"created_at"`
+	UpdatedAt      time.Time `json:"updated_at"`
 }
 
 type CreateTicketRequest struct {
 	EventID       uint   `json:"event_id" binding:"required"`
 	ParticipantID uint   `json:"participant_id" binding:"required"`
-	TicketType    string `json:"ticket_type" binding:"required"`
+	TierID        *uint  `json:"tier_id"`
+	TicketType    string `json:"ticket_type" binding:"required_without=TierID"`
 	Status        string `json:"status" binding:"required"`
+	PromoCode     string `json:"promo_code"`
+	SeatID        *uint  `json:"seat_id"`
+	HoldToken     string `json:"hold_token"` // удержание места, если место удерживалось
 }
 
 type UpdateTicketRequest struct {
-	TicketType string `json:"ticket_type" binding:"required"`
+	TicketType string `json:"ticket_type"`
 	Status     string `json:"status" binding:"required"`
 }
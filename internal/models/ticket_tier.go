@@ -0,0 +1,63 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// TicketTier - категория билетов события с ценой и ограниченным количеством
+type TicketTier struct {
+	ID          uint       `gorm:"primaryKey" json:"id"`
+	EventID     uint       `json:"event_id"`
+	Name        string     `json:"name"`
+	Price       int64      `json:"price"`    // В минимальных единицах валюты (копейки, центы)
+	Currency    string     `json:"currency"` // Код ISO 4217
+	Quantity    int        `json:"quantity"`
+	Sold        int        `json:"sold"`
+	SalesStart  *time.Time `json:"sales_start"`
+	SalesEnd    *time.Time `json:"sales_end"`
+	MaxPerOrder *int       `json:"max_per_order"` // nil - без ограничения
+	CreatedAt   time.Time  `json:"created_at"`
+	UpdatedAt   time.Time  `json:"updated_at"`
+	Available   int        `gorm:"-" json:"available"`
+}
+
+func (t *TicketTier) fillAvailable() {
+	t.Available = t.Quantity - t.Sold
+	if t.Available < 0 {
+		t.Available = 0
+	}
+}
+
+func (t *TicketTier) AfterFind(tx *gorm.DB) error {
+	t.fillAvailable()
+	return nil
+}
+
+func (t *TicketTier) AfterSave(tx *gorm.DB) error {
+	t.fillAvailable()
+	return nil
+}
+
+// OnSale сообщает, открыты ли продажи категории в момент now
+func (t *TicketTier) OnSale(now time.Time) bool {
+	if t.SalesStart != nil && now.Before(*t.SalesStart) {
+		return false
+	}
+	if t.SalesEnd != nil && !now.Before(*t.SalesEnd) {
+		return false
+	}
+	return true
+}
+
+type CreateTicketTierRequest struct {
+	EventID     uint       `json:"event_id" binding:"required"`
+	Name        string     `json:"name" binding:"required"`
+	Price       int64      `json:"price" binding:"min=0"`
+	Currency    string     `json:"currency" binding:"required,iso4217"`
+	Quantity    int        `json:"quantity" binding:"min=0"`
+	SalesStart  *time.Time `json:"sales_start"`
+	SalesEnd    *time.Time `json:"sales_end"`
+	MaxPerOrder *int       `json:"max_per_order" binding:"omitempty,min=1"`
+}
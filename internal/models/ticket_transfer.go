@@ -0,0 +1,24 @@
+package models
+
+import "time"
+
+// TicketTransfer - передача тикета другому участнику. При передаче QR-код тикета меняется
+type TicketTransfer struct {
+	ID                uint      `gorm:"primaryKey" json:"id"`
+	TicketID          uint      `json:"ticket_id"`
+	EventID           uint      `json:"event_id"`
+	FromParticipantID uint      `json:"from_participant_id"`
+	ToParticipantID   uint      `json:"to_participant_id"`
+	OrganizerID       uint      `json:"organizer_id"` // Организатор, оформивший передачу
+	Reason            string    `json:"reason"`
+	CreatedAt         time.Time `json:"created_at"`
+}
+
+// TransferTicketRequest - получатель тикета. Участник ищется по email; если его нет,
+// он создается, и тогда full_name обязательно
+type TransferTicketRequest struct {
+	Email    string `json:"email" binding:"required,email"`
+	FullName string `json:"full_name"`
+	Phone    string `json:"phone"`
+	Reason   string `json:"reason"`
+}
@@ -0,0 +1,18 @@
+package models
+
+import "time"
+
+type Track struct {
+	ID        uint      `gorm:"primaryKey" json:"id"`
+	EventID   uint      `json:"event_id"`
+	Name      string    `json:"name"`
+	Color     string    `json:"color"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+type CreateTrackRequest struct {
+	EventID uint   `json:"event_id" binding:"required"`
+	Name    string `json:"name" binding:"required"`
+	Color   string `json:"color"`
+}
@@ -0,0 +1,29 @@
+package models
+
+import "time"
+
+type Venue struct {
+	ID        uint     `gorm:"primaryKey" json:"id"`
+	Name      string   `json:"name"`
+	Address   string   `json:"address"`
+	City      string   `json:"city"`
+	Country   string   `json:"country"`
+	Latitude  *float64 `json:"latitude"`
+	Longitude *float64 `json:"longitude"`
+	Timezone  string   `json:"timezone"` // IANA, например "Europe/Moscow"
+	Capacity  *int     `json:"capacity"` // вместимость зала, используется по умолчанию для событий
+
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+type CreateVenueRequest struct {
+	Name      string   `json:"name" binding:"required"`
+	Address   string   `json:"address" binding:"required"`
+	City      string   `json:"city"`
+	Country   string   `json:"country"`
+	Latitude  *float64 `json:"latitude" binding:"omitempty,min=-90,max=90"`
+	Longitude *float64 `json:"longitude" binding:"omitempty,min=-180,max=180"`
+	Timezone  string   `json:"timezone" binding:"required"`
+	Capacity  *int     `json:"capacity" binding:"omitempty,min=0"`
+}
@@ -0,0 +1,131 @@
+package payments
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"sync"
+)
+
+// Специальные токены фейкового провайдера
+const (
+	FakeTokenDeclined    = "fake_declined"    // Платеж отклонен
+	FakeTokenUnavailable = "fake_unavailable" // Провайдер недоступен
+)
+
+// Причина возврата, при которой фейковый провайдер отклоняет возврат
+const FakeRefundReasonDeclined = "fake_declined"
+
+// FakeProvider - локальный провайдер без сети для разработки и тестов.
+// Любой токен, кроме специальных, считается успешной оплатой.
+type FakeProvider struct {
+	mu      sync.Mutex
+	charges map[string]*ChargeResult
+	refunds map[string]*RefundResult
+}
+
+func NewFakeProvider() *FakeProvider {
+	return &FakeProvider{
+		charges: make(map[string]*ChargeResult),
+		refunds: make(map[string]*RefundResult),
+	}
+}
+
+func fakeID(prefix string) (string, error) {
+	id := make([]byte, 12)
+	if _, err := rand.Read(id); err != nil {
+		return "", err
+	}
+	return prefix + hex.EncodeToString(id), nil
+}
+
+func (p *FakeProvider) Name() string {
+	return "fake"
+}
+
+func (p *FakeProvider) Charge(ctx context.Context, req ChargeRequest) (*ChargeResult, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	if req.PaymentToken == FakeTokenUnavailable {
+		return nil, ErrProviderUnavailable
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if req.IdempotencyKey != "" {
+		if previous, ok := p.charges[req.IdempotencyKey]; ok {
+			result := *previous
+			return &result, nil
+		}
+	}
+
+	id, err := fakeID("fake_")
+	if err != nil {
+		return nil, err
+	}
+
+	result := &ChargeResult{PaymentID: id, Status: StatusSucceeded}
+	if req.PaymentToken == FakeTokenDeclined {
+		result.Status = StatusFailed
+		result.FailureReason = "card_declined"
+	}
+
+	if req.IdempotencyKey != "" {
+		p.charges[req.IdempotencyKey] = result
+	}
+
+	copied := *result
+	return &copied, nil
+}
+
+func (p *FakeProvider) ChargeStatus(ctx context.Context, idempotencyKey string) (*ChargeResult, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	previous, ok := p.charges[idempotencyKey]
+	if !ok {
+		return nil, ErrChargeNotFound
+	}
+	result := *previous
+	return &result, nil
+}
+
+func (p *FakeProvider) Refund(ctx context.Context, req RefundRequest) (*RefundResult, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if req.IdempotencyKey != "" {
+		if previous, ok := p.refunds[req.IdempotencyKey]; ok {
+			result := *previous
+			return &result, nil
+		}
+	}
+
+	id, err := fakeID("fake_re_")
+	if err != nil {
+		return nil, err
+	}
+
+	result := &RefundResult{RefundID: id, Status: StatusSucceeded}
+	if req.Reason == FakeRefundReasonDeclined {
+		result.Status = StatusFailed
+		result.FailureReason = "refund_declined"
+	}
+
+	if req.IdempotencyKey != "" {
+		p.refunds[req.IdempotencyKey] = result
+	}
+
+	copied := *result
+	return &copied, nil
+}
@@ -0,0 +1,90 @@
+// Package payments описывает интерфейс платежного провайдера, через который
+// оплачиваются заказы. Конкретный провайдер выбирается при старте (Setup).
+package payments
+
+import (
+	"context"
+	"errors"
+	"log"
+	"os"
+)
+
+// Статусы результата списания
+const (
+	StatusSucceeded = "succeeded"
+	StatusFailed    = "failed"
+)
+
+// ErrProviderUnavailable возвращается, если провайдер не смог обработать запрос
+// (сеть, таймаут). Заказ при этом остается в ожидании оплаты.
+var ErrProviderUnavailable = errors.New("payment provider unavailable")
+
+// ErrChargeNotFound возвращается ChargeStatus, если списания с таким ключом
+// идемпотентности у провайдера не было
+var ErrChargeNotFound = errors.New("charge not found")
+
+type ChargeRequest struct {
+	OrderID        uint
+	Amount         int64  // В минимальных единицах валюты
+	Currency       string // Код ISO 4217
+	PaymentToken   string // Токен платежного средства, полученный клиентом от провайдера
+	IdempotencyKey string // Повтор запроса с тем же ключом не приводит к повторному списанию
+	Description    string
+}
+
+type ChargeResult struct {
+	PaymentID     string
+	Status        string // StatusSucceeded или StatusFailed
+	FailureReason string
+}
+
+// PaymentProvider списывает оплату за заказ
+type PaymentProvider interface {
+	Name() string
+	Charge(ctx context.Context, req ChargeRequest) (*ChargeResult, error)
+	// ChargeStatus возвращает результат списания по ключу идемпотентности
+	// (ErrChargeNotFound, если списания не было)
+	ChargeStatus(ctx context.Context, idempotencyKey string) (*ChargeResult, error)
+}
+
+type RefundRequest struct {
+	PaymentID      string // Идентификатор исходного платежа у провайдера
+	Amount         int64  // В минимальных единицах валюты
+	Currency       string
+	Reason         string
+	IdempotencyKey string
+}
+
+type RefundResult struct {
+	RefundID      string
+	Status        string // StatusSucceeded или StatusFailed
+	FailureReason string
+}
+
+// RefundProcessor возвращает деньги по ранее проведенному платежу
+type RefundProcessor interface {
+	Name() string
+	Refund(ctx context.Context, req RefundRequest) (*RefundResult, error)
+}
+
+// Provider - провайдер, используемый API
+var Provider PaymentProvider = NewFakeProvider()
+
+// Refunds - обработчик возвратов, используемый API
+var Refunds RefundProcessor = NewFakeProvider()
+
+// Setup выбирает провайдер по переменной окружения PAYMENT_PROVIDER (по умолчанию "fake")
+func Setup() {
+	name := os.Getenv("PAYMENT_PROVIDER")
+
+	switch name {
+	case "", "fake":
+		fake := NewFakeProvider()
+		Provider = fake
+		Refunds = fake
+	default:
+		log.Fatalf("unknown PAYMENT_PROVIDER %q", name)
+	}
+
+	log.Printf("💳 Payment provider: %s", Provider.Name())
+}
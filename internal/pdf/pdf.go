@@ -0,0 +1,294 @@
+// Package pdf формирует простые PDF-документы (PDF 1.4): текст, прямоугольники и линии.
+// Координаты задаются в пунктах от левого верхнего угла страницы.
+package pdf
+
+import (
+	"bytes"
+	"compress/zlib"
+	"fmt"
+	"sort"
+	"strings"
+	"unicode/utf16"
+)
+
+const pointsPerMM = 72 / 25.4
+
+// MM переводит миллиметры в пункты
+func MM(v float64) float64 {
+	return v * pointsPerMM
+}
+
+// Размеры страницы A4 в пунктах
+var (
+	A4Width  = MM(210)
+	A4Height = MM(297)
+)
+
+type page struct {
+	width, height float64
+	content       bytes.Buffer
+}
+
+// Document - PDF-документ. Если шрифт не задан, используется встроенный Helvetica
+// (только латиница и Latin-1, остальные символы заменяются на "?").
+type Document struct {
+	font  *Font
+	pages []*page
+	cur   *page
+	used  map[uint16]rune // глифы, использованные в тексте: для ширин и ToUnicode
+}
+
+// New создает пустой документ
+func New(font *Font) *Document {
+	return &Document{font: font, used: map[uint16]rune{}}
+}
+
+// AddPage добавляет страницу указанного размера (в пунктах) и делает ее текущей
+func (d *Document) AddPage(width, height float64) {
+	d.cur = &page{width: width, height: height}
+	d.pages = append(d.pages, d.cur)
+}
+
+// PageCount возвращает число страниц документа
+func (d *Document) PageCount() int {
+	return len(d.pages)
+}
+
+// TextWidth возвращает ширину строки в пунктах при заданном кегле
+func (d *Document) TextWidth(s string, size float64, bold bool) float64 {
+	var width float64
+	if d.font != nil {
+		for _, r := range s {
+			width += d.font.advance(d.font.GlyphID(r))
+		}
+	} else {
+		// Средняя ширина символа Helvetica, этого достаточно для переноса строк
+		em := 556.0
+		if bold {
+			em = 611.0
+		}
+		width = em * float64(len([]rune(s)))
+	}
+	return width * size / 1000
+}
+
+// Text выводит строку; (x, y) - левый верхний угол строки
+func (d *Document) Text(x, y, size float64, bold bool, s string) {
+	if d.cur == nil || s == "" {
+		return
+	}
+
+	ascent := 0.718 * size
+	if d.font != nil {
+		ascent = d.font.scaled(d.font.ascent) * size / 1000
+	}
+	baseline := d.cur.height - y - ascent
+
+	w := &d.cur.content
+	fmt.Fprintf(w, "q 0 g BT /F1 %s Tf ", num(size))
+	if bold {
+		// Полужирное начертание имитируется обводкой глифов: встраивается один шрифт
+		fmt.Fprintf(w, "2 Tr 0 G %s w ", num(size*0.03))
+	}
+	fmt.Fprintf(w, "%s %s Td %s Tj ET Q\n", num(x), num(baseline), d.encode(s))
+}
+
+// Rect рисует прямоугольник: залитый (fill) или контур толщиной lineWidth
+func (d *Document) Rect(x, y, width, height float64, fill bool, lineWidth float64) {
+	if d.cur == nil {
+		return
+	}
+
+	bottom := d.cur.height - y - height
+	if fill {
+		fmt.Fprintf(&d.cur.content, "0 g %s %s %s %s re f\n", num(x), num(bottom), num(width), num(height))
+		return
+	}
+	fmt.Fprintf(&d.cur.content, "q 0 G %s w %s %s %s %s re S Q\n",
+		num(lineWidth), num(x), num(bottom), num(width), num(height))
+}
+
+// Line рисует отрезок толщиной lineWidth
+func (d *Document) Line(x1, y1, x2, y2, lineWidth float64) {
+	if d.cur == nil {
+		return
+	}
+
+	fmt.Fprintf(&d.cur.content, "q 0 G %s w %s %s m %s %s l S Q\n",
+		num(lineWidth), num(x1), num(d.cur.height-y1), num(x2), num(d.cur.height-y2))
+}
+
+// encode кодирует строку для оператора Tj: номера глифов для встроенного шрифта
+// или WinAnsi-строка для Helvetica
+func (d *Document) encode(s string) string {
+	if d.font != nil {
+		var b strings.Builder
+		b.WriteByte('<')
+		for _, r := range s {
+			gid := d.font.GlyphID(r)
+			if _, ok := d.used[gid]; !ok {
+				d.used[gid] = r
+			}
+			fmt.Fprintf(&b, "%04X", gid)
+		}
+		b.WriteByte('>')
+		return b.String()
+	}
+
+	var b strings.Builder
+	b.WriteByte('(')
+	for _, r := range s {
+		switch {
+		case r == '(' || r == ')' || r == '\\':
+			b.WriteByte('\\')
+			b.WriteRune(r)
+		case r >= 0x20 && r < 0x7F, r >= 0xA0 && r <= 0xFF:
+			b.WriteByte(byte(r))
+		default:
+			b.WriteByte('?')
+		}
+	}
+	b.WriteByte(')')
+	return b.String()
+}
+
+// Bytes собирает документ
+func (d *Document) Bytes() []byte {
+	w := &writer{}
+	w.buf.WriteString("%PDF-1.4\n%\xE2\xE3\xCF\xD3\n")
+
+	catalog := w.reserve()
+	pages := w.reserve()
+	font := w.reserve()
+
+	pageRefs := make([]int, len(d.pages))
+	for i := range d.pages {
+		pageRefs[i] = w.reserve()
+	}
+
+	w.object(catalog, fmt.Sprintf("<< /Type /Catalog /Pages %d 0 R >>", pages))
+
+	kids := make([]string, len(pageRefs))
+	for i, ref := range pageRefs {
+		kids[i] = fmt.Sprintf("%d 0 R", ref)
+	}
+	w.object(pages, fmt.Sprintf("<< /Type /Pages /Kids [%s] /Count %d >>", strings.Join(kids, " "), len(pageRefs)))
+
+	if d.font != nil {
+		d.writeFont(w, font)
+	} else {
+		w.object(font, "<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica /Encoding /WinAnsiEncoding >>")
+	}
+
+	for i, p := range d.pages {
+		contents := w.reserve()
+		w.object(pageRefs[i], fmt.Sprintf(
+			"<< /Type /Page /Parent %d 0 R /MediaBox [0 0 %s %s] /Resources << /Font << /F1 %d 0 R >> >> /Contents %d 0 R >>",
+			pages, num(p.width), num(p.height), font, contents))
+		w.stream(contents, "", deflate(p.content.Bytes()))
+	}
+
+	return w.finish(catalog)
+}
+
+func (d *Document) writeFont(w *writer, fontRef int) {
+	f := d.font
+	descendant := w.reserve()
+	descriptor := w.reserve()
+	file := w.reserve()
+	toUnicode := w.reserve()
+
+	gids := make([]int, 0, len(d.used))
+	for gid := range d.used {
+		gids = append(gids, int(gid))
+	}
+	sort.Ints(gids)
+
+	var widths strings.Builder
+	for _, gid := range gids {
+		fmt.Fprintf(&widths, "%d [%s] ", gid, num(f.advance(uint16(gid))))
+	}
+
+	w.object(fontRef, fmt.Sprintf(
+		"<< /Type /Font /Subtype /Type0 /BaseFont /%s /Encoding /Identity-H /DescendantFonts [%d 0 R] /ToUnicode %d 0 R >>",
+		f.Name, descendant, toUnicode))
+	w.object(descendant, fmt.Sprintf(
+		"<< /Type /Font /Subtype /CIDFontType2 /BaseFont /%s /CIDSystemInfo << /Registry (Adobe) /Ordering (Identity) /Supplement 0 >> /FontDescriptor %d 0 R /CIDToGIDMap /Identity /DW 500 /W [%s] >>",
+		f.Name, descriptor, strings.TrimSpace(widths.String())))
+	w.object(descriptor, fmt.Sprintf(
+		"<< /Type /FontDescriptor /FontName /%s /Flags 32 /FontBBox [%s %s %s %s] /ItalicAngle 0 /Ascent %s /Descent %s /CapHeight %s /StemV 80 /FontFile2 %d 0 R >>",
+		f.Name, num(f.scaled(f.bbox[0])), num(f.scaled(f.bbox[1])), num(f.scaled(f.bbox[2])), num(f.scaled(f.bbox[3])),
+		num(f.scaled(f.ascent)), num(f.scaled(f.descent)), num(f.scaled(f.capHeight)), file))
+	w.stream(file, fmt.Sprintf("/Length1 %d ", len(f.data)), f.compressedData())
+
+	var cmap bytes.Buffer
+	cmap.WriteString("/CIDInit /ProcSet findresource begin\n12 dict begin\nbegincmap\n")
+	cmap.WriteString("/CIDSystemInfo << /Registry (Adobe) /Ordering (UCS) /Supplement 0 >> def\n")
+	cmap.WriteString("/CMapName /Adobe-Identity-UCS def\n/CMapType 2 def\n")
+	cmap.WriteString("1 begincodespacerange\n<0000> <FFFF>\nendcodespacerange\n")
+	for start := 0; start < len(gids); start += 100 {
+		end := min(start+100, len(gids))
+		fmt.Fprintf(&cmap, "%d beginbfchar\n", end-start)
+		for _, gid := range gids[start:end] {
+			fmt.Fprintf(&cmap, "<%04X> <", gid)
+			for _, unit := range utf16.Encode([]rune{d.used[uint16(gid)]}) {
+				fmt.Fprintf(&cmap, "%04X", unit)
+			}
+			cmap.WriteString(">\n")
+		}
+		cmap.WriteString("endbfchar\n")
+	}
+	cmap.WriteString("endcmap\nCMapName currentdict /CMap defineresource pop\nend\nend\n")
+	w.stream(toUnicode, "", deflate(cmap.Bytes()))
+}
+
+// writer последовательно пишет объекты и запоминает их смещения для таблицы xref
+type writer struct {
+	buf     bytes.Buffer
+	offsets []int
+}
+
+func (w *writer) reserve() int {
+	w.offsets = append(w.offsets, 0)
+	return len(w.offsets)
+}
+
+func (w *writer) object(ref int, body string) {
+	w.offsets[ref-1] = w.buf.Len()
+	fmt.Fprintf(&w.buf, "%d 0 obj\n%s\nendobj\n", ref, body)
+}
+
+func (w *writer) stream(ref int, extra string, data []byte) {
+	w.offsets[ref-1] = w.buf.Len()
+	fmt.Fprintf(&w.buf, "%d 0 obj\n<< %s/Filter /FlateDecode /Length %d >>\nstream\n", ref, extra, len(data))
+	w.buf.Write(data)
+	w.buf.WriteString("\nendstream\nendobj\n")
+}
+
+func (w *writer) finish(root int) []byte {
+	xref := w.buf.Len()
+	fmt.Fprintf(&w.buf, "xref\n0 %d\n0000000000 65535 f \n", len(w.offsets)+1)
+	for _, offset := range w.offsets {
+		fmt.Fprintf(&w.buf, "%010d 00000 n \n", offset)
+	}
+	fmt.Fprintf(&w.buf, "trailer\n<< /Size %d /Root %d 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(w.offsets)+1, root, xref)
+	return w.buf.Bytes()
+}
+
+func deflate(data []byte) []byte {
+	var buf bytes.Buffer
+	zw := zlib.NewWriter(&buf)
+	zw.Write(data)
+	zw.Close()
+	return buf.Bytes()
+}
+
+// num форматирует число без лишних нулей
+func num(v float64) string {
+	s := fmt.Sprintf("%.3f", v)
+	s = strings.TrimRight(strings.TrimRight(s, "0"), ".")
+	if s == "-0" || s == "" {
+		return "0"
+	}
+	return s
+}
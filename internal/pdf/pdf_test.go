@@ -0,0 +1,330 @@
+package pdf
+
+import (
+	"bytes"
+	"compress/zlib"
+	"encoding/binary"
+	"errors"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// Глифы тестового шрифта: 0 .notdef, 1 "A", 2 "B", 3 "А", 4 "Б", 5 U+1F600
+const testUnitsPerEm = 2048
+
+var testAdvances = []uint16{1000, 1024, 2048, 512} // глифы 4 и 5 берут последнюю ширину
+
+func u16(v int) []byte { return binary.BigEndian.AppendUint16(nil, uint16(v)) }
+func u32(v int) []byte { return binary.BigEndian.AppendUint32(nil, uint32(v)) }
+
+func join(parts ...[]byte) []byte { return bytes.Join(parts, nil) }
+
+// cmapFormat4: A-B через idDelta, А-Б через glyphIdArray, завершающий сегмент 0xFFFF
+func cmapFormat4() []byte {
+	ends := join(u16(0x42), u16(0x411), u16(0xFFFF))
+	starts := join(u16(0x41), u16(0x410), u16(0xFFFF))
+	deltas := join(u16(1-0x41), u16(0), u16(1))
+	rangeOffsets := join(u16(0), u16(4), u16(0)) // от idRangeOffset[1] до glyphIdArray[0]
+	glyphs := join(u16(3), u16(4))
+	body := join(ends, u16(0), starts, deltas, rangeOffsets, glyphs)
+	return join(u16(4), u16(14+len(body)), u16(0), u16(6), u16(4), u16(1), u16(2), body)
+}
+
+// cmapFormat12: A-B и U+1F600 вне базовой плоскости
+func cmapFormat12() []byte {
+	groups := join(u32(0x41), u32(0x42), u32(1), u32(0x1F600), u32(0x1F600), u32(5))
+	return join(u16(12), u16(0), u32(16+len(groups)), u32(0), u32(2), groups)
+}
+
+type subtable struct {
+	platform, encoding int
+	data               []byte
+}
+
+// cmapTable собирает таблицу cmap из подтаблиц с их (platformID, encodingID)
+func cmapTable(subtables ...subtable) []byte {
+	header := join(u16(0), u16(len(subtables)))
+	offset := 4 + 8*len(subtables)
+	var records, data []byte
+	for _, s := range subtables {
+		records = join(records, u16(s.platform), u16(s.encoding), u32(offset+len(data)))
+		data = join(data, s.data)
+	}
+	return join(header, records, data)
+}
+
+// buildFont собирает шрифт TrueType из таблиц; таблицы с nil пропускаются
+func buildFont(tables map[string][]byte) []byte {
+	tags := []string{"OS/2", "cmap", "head", "hhea", "hmtx", "maxp"}
+	var present []string
+	for _, tag := range tags {
+		if tables[tag] != nil {
+			present = append(present, tag)
+		}
+	}
+
+	dir := join(u32(0x00010000), u16(len(present)), u16(0), u16(0), u16(0))
+	offset := 12 + 16*len(present)
+	var data []byte
+	for _, tag := range present {
+		dir = join(dir, []byte(tag), u32(0), u32(offset+len(data)), u32(len(tables[tag])))
+		data = join(data, tables[tag])
+	}
+	return join(dir, data)
+}
+
+func testFontTables() map[string][]byte {
+	head := make([]byte, 54)
+	copy(head[18:], u16(testUnitsPerEm))
+	copy(head[36:], join(u16(-100), u16(-400), u16(2000), u16(1800)))
+
+	hhea := make([]byte, 36)
+	copy(hhea[4:], u16(1600))
+	copy(hhea[6:], u16(-400))
+	copy(hhea[34:], u16(len(testAdvances)))
+
+	var hmtx []byte
+	for _, advance := range testAdvances {
+		hmtx = join(hmtx, u16(int(advance)), u16(0))
+	}
+
+	return map[string][]byte{
+		"head": head,
+		"hhea": hhea,
+		"hmtx": hmtx,
+		"maxp": join(u32(0x00005000), u16(6)),
+		"cmap": cmapTable(subtable{3, 1, cmapFormat4()}),
+	}
+}
+
+func testFont(t *testing.T, tables map[string][]byte) *Font {
+	t.Helper()
+	font, err := ParseFont("TestSans", buildFont(tables))
+	if err != nil {
+		t.Fatalf("ParseFont: %v", err)
+	}
+	return font
+}
+
+func TestParseFontCmap(t *testing.T) {
+	format4 := testFontTables()
+
+	format12 := testFontTables()
+	format12["cmap"] = cmapTable(subtable{3, 1, cmapFormat4()}, subtable{3, 10, cmapFormat12()})
+
+	tests := []struct {
+		name   string
+		tables map[string][]byte
+		want   map[rune]uint16
+	}{
+		{
+			name:   "format 4",
+			tables: format4,
+			want:   map[rune]uint16{'A': 1, 'B': 2, 'А': 3, 'Б': 4, 'C': 0, 'В': 0, 0x1F600: 0},
+		},
+		{
+			// Подтаблица формата 12 предпочтительнее: в ней есть символы вне базовой плоскости
+			name:   "format 12",
+			tables: format12,
+			want:   map[rune]uint16{'A': 1, 'B': 2, 'А': 0, 0x1F600: 5, 0x1F601: 0},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			font := testFont(t, tt.tables)
+			for r, want := range tt.want {
+				if got := font.GlyphID(r); got != want {
+					t.Errorf("GlyphID(%U) = %d, want %d", r, got, want)
+				}
+			}
+		})
+	}
+}
+
+func TestParseFontMetrics(t *testing.T) {
+	font := testFont(t, testFontTables())
+
+	tests := []struct {
+		gid  uint16
+		want float64
+	}{
+		{0, 1000 * 1000 / 2048.0},
+		{1, 500},
+		{2, 1000},
+		{3, 250},
+		{5, 250}, // после numberOfHMetrics - последняя ширина
+		{6, 0},   // вне шрифта
+	}
+	for _, tt := range tests {
+		if got := font.advance(tt.gid); got != tt.want {
+			t.Errorf("advance(%d) = %v, want %v", tt.gid, got, tt.want)
+		}
+	}
+
+	doc := New(font)
+	if got := doc.TextWidth("AB", 10, false); got != 15 {
+		t.Errorf("TextWidth(AB, 10) = %v, want 15", got)
+	}
+	if font.capHeight != font.ascent {
+		t.Errorf("capHeight without OS/2 = %d, want ascent %d", font.capHeight, font.ascent)
+	}
+}
+
+func TestParseFontInvalid(t *testing.T) {
+	tests := []struct {
+		name   string
+		modify func(map[string][]byte)
+	}{
+		{"missing cmap", func(tables map[string][]byte) { delete(tables, "cmap") }},
+		{"short head", func(tables map[string][]byte) { tables["head"] = tables["head"][:20] }},
+		{"zero unitsPerEm", func(tables map[string][]byte) { copy(tables["head"][18:], u16(0)) }},
+		{"short hmtx", func(tables map[string][]byte) { tables["hmtx"] = tables["hmtx"][:6] }},
+		{"no unicode cmap", func(tables map[string][]byte) { tables["cmap"] = cmapTable(subtable{1, 0, cmapFormat4()}) }},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tables := testFontTables()
+			tt.modify(tables)
+			if _, err := ParseFont("Broken", buildFont(tables)); !errors.Is(err, errInvalidFont) {
+				t.Errorf("ParseFont error = %v, want errInvalidFont", err)
+			}
+		})
+	}
+
+	data := buildFont(testFontTables())
+	if _, err := ParseFont("Truncated", data[:len(data)-10]); !errors.Is(err, errInvalidFont) {
+		t.Errorf("truncated font error = %v, want errInvalidFont", err)
+	}
+}
+
+// pdfStreams возвращает распакованные потоки документа по номеру объекта
+func pdfStreams(t *testing.T, doc []byte) map[int][]byte {
+	t.Helper()
+	streams := map[int][]byte{}
+	objects := regexp.MustCompile(`(?s)(\d+) 0 obj\n<< [^\n]*/Length (\d+) >>\nstream\n`)
+	for _, m := range objects.FindAllSubmatchIndex(doc, -1) {
+		ref, _ := strconv.Atoi(string(doc[m[2]:m[3]]))
+		length, _ := strconv.Atoi(string(doc[m[4]:m[5]]))
+		zr, err := zlib.NewReader(bytes.NewReader(doc[m[1] : m[1]+length]))
+		if err != nil {
+			t.Fatalf("stream %d: %v", ref, err)
+		}
+		streams[ref], err = io.ReadAll(zr)
+		if err != nil {
+			t.Fatalf("stream %d: %v", ref, err)
+		}
+	}
+	return streams
+}
+
+func TestDocumentEmbeddedFont(t *testing.T) {
+	font := testFont(t, testFontTables())
+	doc := New(font)
+	doc.AddPage(A4Width, A4Height)
+	doc.Text(10, 10, 12, false, "AБ")
+	doc.Text(10, 30, 12, true, "A")
+	data := doc.Bytes()
+
+	// В /W только использованные глифы, ширины в единицах 1/1000 кегля
+	if !bytes.Contains(data, []byte("/W [1 [500] 4 [250]]")) {
+		t.Errorf("widths array not found in %q", firstLine(data, "/W"))
+	}
+	if !bytes.Contains(data, []byte("/FontFile2 ")) || !bytes.Contains(data, []byte("/Length1 "+strconv.Itoa(len(font.data))+" ")) {
+		t.Error("font file is not embedded with its original length")
+	}
+
+	var content, toUnicode []byte
+	for _, stream := range pdfStreams(t, data) {
+		switch {
+		case bytes.Contains(stream, []byte("beginbfchar")):
+			toUnicode = stream
+		case bytes.Contains(stream, []byte(" Tj ")):
+			content = stream
+		}
+	}
+	if !bytes.Contains(content, []byte("<00010004> Tj")) || !bytes.Contains(content, []byte("2 Tr")) {
+		t.Errorf("page content = %q", content)
+	}
+	if !bytes.Contains(toUnicode, []byte("2 beginbfchar\n<0001> <0041>\n<0004> <0411>\nendbfchar")) {
+		t.Errorf("ToUnicode = %q", toUnicode)
+	}
+
+	checkXref(t, data)
+}
+
+func TestDocumentHelvetica(t *testing.T) {
+	doc := New(nil)
+	doc.AddPage(MM(100), MM(50))
+	doc.Text(0, 0, 10, false, `a(b)\é Ж`)
+	doc.Rect(1, 2, 3, 4, true, 0)
+	doc.Line(0, 0, 10, 10, 0.5)
+	data := doc.Bytes()
+
+	if doc.PageCount() != 1 || !bytes.Contains(data, []byte("/BaseFont /Helvetica")) {
+		t.Fatal("document has no Helvetica page")
+	}
+	var content []byte
+	for _, stream := range pdfStreams(t, data) {
+		content = stream
+	}
+	if !bytes.Contains(content, []byte("(a\\(b\\)\\\\\xe9 ?) Tj")) {
+		t.Errorf("text is not WinAnsi-encoded: %q", content)
+	}
+	if !bytes.Contains(content, []byte("0 g 1 "+num(MM(50)-2-4)+" 3 4 re f")) {
+		t.Errorf("rectangle is not flipped to PDF coordinates: %q", content)
+	}
+
+	checkXref(t, data)
+}
+
+// checkXref проверяет, что смещения таблицы xref указывают на начала объектов
+func checkXref(t *testing.T, data []byte) {
+	t.Helper()
+	start := bytes.LastIndex(data, []byte("startxref\n"))
+	xref, _ := strconv.Atoi(strings.Fields(string(data[start+len("startxref\n"):]))[0])
+	lines := strings.Split(string(data[xref:]), "\n")
+	for i, line := range lines[3:] {
+		if !strings.HasSuffix(line, " n ") {
+			break
+		}
+		offset, _ := strconv.Atoi(line[:10])
+		if want := strconv.Itoa(i+1) + " 0 obj"; !bytes.HasPrefix(data[offset:], []byte(want)) {
+			t.Errorf("xref entry %d points to %q", i+1, data[offset:offset+10])
+		}
+	}
+}
+
+func firstLine(data []byte, marker string) string {
+	i := bytes.Index(data, []byte(marker))
+	if i < 0 {
+		return ""
+	}
+	end := bytes.IndexByte(data[i:], '\n')
+	if end < 0 {
+		end = len(data) - i
+	}
+	return string(data[i : i+end])
+}
+
+func TestNum(t *testing.T) {
+	tests := []struct {
+		in   float64
+		want string
+	}{
+		{0, "0"},
+		{1.5, "1.5"},
+		{2.0004, "2"},
+		{-0.0001, "0"},
+		{595.2756, "595.276"},
+	}
+	for _, tt := range tests {
+		if got := num(tt.in); got != tt.want {
+			t.Errorf("num(%v) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
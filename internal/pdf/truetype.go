@@ -0,0 +1,285 @@
+package pdf
+
+import (
+	"bytes"
+	"compress/zlib"
+	"encoding/binary"
+	"errors"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sync"
+)
+
+// Font - шрифт TrueType, встраиваемый в документ целиком (CIDFontType2, Identity-H).
+// Поддерживает любые символы, которые есть в шрифте, в том числе кириллицу.
+type Font struct {
+	Name       string
+	unitsPerEm int
+	ascent     int
+	descent    int
+	capHeight  int
+	bbox       [4]int
+	advances   []uint16
+	lookup     func(r rune) uint16
+
+	data       []byte
+	compressed []byte
+	compress   sync.Once
+}
+
+var errInvalidFont = errors.New("pdf: invalid TrueType font")
+
+var nonNameChars = regexp.MustCompile(`[^A-Za-z0-9-]`)
+
+// LoadFont читает файл шрифта TrueType (.ttf)
+func LoadFont(path string) (*Font, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	name := filepath.Base(path)
+	name = nonNameChars.ReplaceAllString(name[:len(name)-len(filepath.Ext(name))], "")
+	if name == "" {
+		name = "EmbeddedFont"
+	}
+
+	return ParseFont(name, data)
+}
+
+// ParseFont разбирает таблицы шрифта TrueType, нужные для встраивания и измерения текста
+func ParseFont(name string, data []byte) (*Font, error) {
+	tables, err := readTableDirectory(data)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, tag := range []string{"head", "hhea", "hmtx", "maxp", "cmap"} {
+		if _, ok := tables[tag]; !ok {
+			return nil, errInvalidFont
+		}
+	}
+
+	f := &Font{Name: name, data: data}
+
+	head := tables["head"]
+	if len(head) < 54 {
+		return nil, errInvalidFont
+	}
+	f.unitsPerEm = int(binary.BigEndian.Uint16(head[18:]))
+	for i := range f.bbox {
+		f.bbox[i] = int(int16(binary.BigEndian.Uint16(head[36+2*i:])))
+	}
+	if f.unitsPerEm == 0 {
+		return nil, errInvalidFont
+	}
+
+	hhea := tables["hhea"]
+	if len(hhea) < 36 {
+		return nil, errInvalidFont
+	}
+	f.ascent = int(int16(binary.BigEndian.Uint16(hhea[4:])))
+	f.descent = int(int16(binary.BigEndian.Uint16(hhea[6:])))
+	numberOfHMetrics := int(binary.BigEndian.Uint16(hhea[34:]))
+
+	f.capHeight = f.ascent
+	if os2, ok := tables["OS/2"]; ok && len(os2) >= 90 && binary.BigEndian.Uint16(os2) >= 2 {
+		f.capHeight = int(int16(binary.BigEndian.Uint16(os2[88:])))
+	}
+
+	maxp := tables["maxp"]
+	if len(maxp) < 6 {
+		return nil, errInvalidFont
+	}
+	numGlyphs := int(binary.BigEndian.Uint16(maxp[4:]))
+
+	hmtx := tables["hmtx"]
+	if numberOfHMetrics == 0 || len(hmtx) < numberOfHMetrics*4 {
+		return nil, errInvalidFont
+	}
+	f.advances = make([]uint16, numGlyphs)
+	for i := range f.advances {
+		if i < numberOfHMetrics {
+			f.advances[i] = binary.BigEndian.Uint16(hmtx[i*4:])
+		} else {
+			f.advances[i] = f.advances[numberOfHMetrics-1]
+		}
+	}
+
+	f.lookup, err = parseCmap(tables["cmap"])
+	if err != nil {
+		return nil, err
+	}
+
+	return f, nil
+}
+
+func readTableDirectory(data []byte) (map[string][]byte, error) {
+	if len(data) < 12 {
+		return nil, errInvalidFont
+	}
+
+	numTables := int(binary.BigEndian.Uint16(data[4:]))
+	if len(data) < 12+numTables*16 {
+		return nil, errInvalidFont
+	}
+
+	tables := make(map[string][]byte, numTables)
+	for i := 0; i < numTables; i++ {
+		record := data[12+i*16:]
+		tag := string(record[:4])
+		offset := int(binary.BigEndian.Uint32(record[8:]))
+		length := int(binary.BigEndian.Uint32(record[12:]))
+		if offset < 0 || length < 0 || offset+length > len(data) {
+			return nil, errInvalidFont
+		}
+		tables[tag] = data[offset : offset+length]
+	}
+	return tables, nil
+}
+
+// parseCmap выбирает юникодную подтаблицу cmap (формат 12 или 4)
+func parseCmap(cmap []byte) (func(rune) uint16, error) {
+	if len(cmap) < 4 {
+		return nil, errInvalidFont
+	}
+
+	var format4, format12 []byte
+	numTables := int(binary.BigEndian.Uint16(cmap[2:]))
+	for i := 0; i < numTables; i++ {
+		if len(cmap) < 4+i*8+8 {
+			return nil, errInvalidFont
+		}
+		record := cmap[4+i*8:]
+		platformID := binary.BigEndian.Uint16(record)
+		encodingID := binary.BigEndian.Uint16(record[2:])
+		offset := int(binary.BigEndian.Uint32(record[4:]))
+		if offset+4 > len(cmap) {
+			continue
+		}
+		subtable := cmap[offset:]
+		unicode := platformID == 0 || (platformID == 3 && (encodingID == 1 || encodingID == 10))
+		if !unicode {
+			continue
+		}
+		switch binary.BigEndian.Uint16(subtable) {
+		case 4:
+			format4 = subtable
+		case 12:
+			format12 = subtable
+		}
+	}
+
+	if format12 != nil {
+		return parseCmapFormat12(format12)
+	}
+	if format4 != nil {
+		return parseCmapFormat4(format4)
+	}
+	return nil, errInvalidFont
+}
+
+func parseCmapFormat4(t []byte) (func(rune) uint16, error) {
+	if len(t) < 14 {
+		return nil, errInvalidFont
+	}
+	segCount := int(binary.BigEndian.Uint16(t[6:])) / 2
+	endCodes := 14
+	startCodes := endCodes + segCount*2 + 2
+	idDeltas := startCodes + segCount*2
+	idRangeOffsets := idDeltas + segCount*2
+	if len(t) < idRangeOffsets+segCount*2 {
+		return nil, errInvalidFont
+	}
+
+	u16 := func(pos int) uint16 {
+		if pos+2 > len(t) {
+			return 0
+		}
+		return binary.BigEndian.Uint16(t[pos:])
+	}
+
+	return func(r rune) uint16 {
+		if r > 0xFFFF {
+			return 0
+		}
+		c := uint16(r)
+		for i := 0; i < segCount; i++ {
+			if c > u16(endCodes+i*2) {
+				continue
+			}
+			start := u16(startCodes + i*2)
+			if c < start {
+				return 0
+			}
+			delta := u16(idDeltas + i*2)
+			rangeOffsetPos := idRangeOffsets + i*2
+			rangeOffset := u16(rangeOffsetPos)
+			if rangeOffset == 0 {
+				return c + delta
+			}
+			gid := u16(rangeOffsetPos + int(rangeOffset) + 2*int(c-start))
+			if gid == 0 {
+				return 0
+			}
+			return gid + delta
+		}
+		return 0
+	}, nil
+}
+
+func parseCmapFormat12(t []byte) (func(rune) uint16, error) {
+	if len(t) < 16 {
+		return nil, errInvalidFont
+	}
+	numGroups := int(binary.BigEndian.Uint32(t[12:]))
+	if len(t) < 16+numGroups*12 {
+		return nil, errInvalidFont
+	}
+
+	return func(r rune) uint16 {
+		c := uint32(r)
+		for i := 0; i < numGroups; i++ {
+			group := t[16+i*12:]
+			start := binary.BigEndian.Uint32(group)
+			end := binary.BigEndian.Uint32(group[4:])
+			if c < start {
+				return 0
+			}
+			if c <= end {
+				return uint16(binary.BigEndian.Uint32(group[8:]) + c - start)
+			}
+		}
+		return 0
+	}, nil
+}
+
+// GlyphID возвращает номер глифа для символа (0 - глиф отсутствует)
+func (f *Font) GlyphID(r rune) uint16 {
+	return f.lookup(r)
+}
+
+// advance возвращает ширину глифа в единицах 1/1000 кегля
+func (f *Font) advance(gid uint16) float64 {
+	if int(gid) >= len(f.advances) {
+		return 0
+	}
+	return float64(f.advances[gid]) * 1000 / float64(f.unitsPerEm)
+}
+
+func (f *Font) scaled(v int) float64 {
+	return float64(v) * 1000 / float64(f.unitsPerEm)
+}
+
+// compressedData возвращает сжатый файл шрифта; сжатие выполняется один раз
+func (f *Font) compressedData() []byte {
+	f.compress.Do(func() {
+		var buf bytes.Buffer
+		w, _ := zlib.NewWriterLevel(&buf, zlib.BestCompression)
+		w.Write(f.data)
+		w.Close()
+		f.compressed = buf.Bytes()
+	})
+	return f.compressed
+}
@@ -0,0 +1,299 @@
+package qrcode
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"image/png"
+	"strings"
+	"testing"
+)
+
+func TestReedSolomonRemainder(t *testing.T) {
+	tests := []struct {
+		name string
+		data []byte
+		want []byte
+	}{
+		{
+			// "HELLO WORLD", версия 1-M (буквенно-цифровой режим)
+			name: "hello world 1-M",
+			data: []byte{32, 91, 11, 120, 209, 114, 220, 77, 67, 64, 236, 17, 236, 17, 236, 17},
+			want: []byte{196, 35, 39, 119, 235, 215, 231, 226, 93, 23},
+		},
+		{
+			// "01234567", версия 1-M (цифровой режим), пример из ISO/IEC 18004
+			name: "numeric 1-M",
+			data: []byte{0x10, 0x20, 0x0C, 0x56, 0x61, 0x80, 0xEC, 0x11, 0xEC, 0x11, 0xEC, 0x11, 0xEC, 0x11, 0xEC, 0x11},
+			want: []byte{0xA5, 0x24, 0xD4, 0xC1, 0xED, 0x36, 0xC7, 0x87, 0x2C, 0x55},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := reedSolomonRemainder(tt.data, reedSolomonDivisor(len(tt.want)))
+			if !bytes.Equal(got, tt.want) {
+				t.Errorf("remainder = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestReedSolomonDivisor(t *testing.T) {
+	// Порождающий многочлен степени 7: x^7 + a^87 x^6 + a^229 x^5 + a^146 x^4 + a^149 x^3 + a^238 x^2 + a^102 x + a^21
+	exponents := []int{87, 229, 146, 149, 238, 102, 21}
+
+	got := reedSolomonDivisor(len(exponents))
+	for i, exponent := range exponents {
+		var want byte = 1
+		for j := 0; j < exponent; j++ {
+			want = reedSolomonMultiply(want, 2)
+		}
+		if got[i] != want {
+			t.Errorf("coefficient %d = %d, want a^%d = %d", i, got[i], exponent, want)
+		}
+	}
+}
+
+func TestEncodeData(t *testing.T) {
+	got := encodeData([]byte("A"), 1, Low)
+
+	// Режим 0100, длина 00000001, байт 01000001, терминатор 0000, затем 0xEC/0x11
+	want := []byte{0x40, 0x14, 0x10}
+	for len(want) < 19 {
+		want = append(want, 0xEC)
+		if len(want) < 19 {
+			want = append(want, 0x11)
+		}
+	}
+	if !bytes.Equal(got, want) {
+		t.Errorf("codewords = % X, want % X", got, want)
+	}
+}
+
+func TestEncodeVersion(t *testing.T) {
+	tests := []struct {
+		length  int
+		level   Level
+		version int
+	}{
+		{17, Low, 1},
+		{18, Low, 2},
+		{14, Medium, 1},
+		{15, Medium, 2},
+		{11, Quartile, 1},
+		{7, High, 1},
+		{8, High, 2},
+		{2953, Low, 40},
+		{1273, High, 40},
+	}
+
+	for _, tt := range tests {
+		code, err := Encode(bytes.Repeat([]byte("a"), tt.length), tt.level)
+		if err != nil {
+			t.Errorf("Encode(%d bytes, level %d): %v", tt.length, tt.level, err)
+			continue
+		}
+		if code.Version != tt.version || code.Size != tt.version*4+17 {
+			t.Errorf("Encode(%d bytes, level %d) = version %d size %d, want version %d", tt.length, tt.level, code.Version, code.Size, tt.version)
+		}
+	}
+
+	if _, err := Encode(bytes.Repeat([]byte("a"), 2954), Low); !errors.Is(err, ErrTooLong) {
+		t.Errorf("Encode(2954 bytes) error = %v, want ErrTooLong", err)
+	}
+}
+
+// formatBits читает вторую копию информации о формате (у правого и нижнего края)
+func formatBits(c *Code) int {
+	bits := 0
+	for i := 0; i < 8; i++ {
+		if c.Dark(c.Size-1-i, 8) {
+			bits |= 1 << uint(i)
+		}
+	}
+	for i := 8; i < 15; i++ {
+		if c.Dark(8, c.Size-15+i) {
+			bits |= 1 << uint(i)
+		}
+	}
+	return bits
+}
+
+func TestDrawFormatBits(t *testing.T) {
+	// Информация о формате для маски 0 из таблицы стандарта, старший бит первым
+	tests := []struct {
+		level Level
+		want  string
+	}{
+		{Low, "111011111000100"},
+		{Medium, "101010000010010"},
+		{Quartile, "011010101011111"},
+		{High, "001011010001001"},
+	}
+
+	for _, tt := range tests {
+		c := newCode(1, tt.level)
+		c.drawFormatBits(0)
+		if got := formatBits(c); got != parseBits(tt.want) {
+			t.Errorf("level %d: format bits = %015b, want %s", tt.level, got, tt.want)
+		}
+	}
+}
+
+func TestDrawVersion(t *testing.T) {
+	tests := []struct {
+		version int
+		want    int
+	}{
+		{7, 0x07C94},
+		{8, 0x085BC},
+		{40, 0x28C69},
+	}
+
+	for _, tt := range tests {
+		c := newCode(tt.version, Low)
+		c.drawVersion()
+		got := 0
+		for i := 0; i < 18; i++ {
+			a, b := c.Size-11+i%3, i/3
+			if c.Dark(a, b) {
+				got |= 1 << uint(i)
+			}
+			if c.Dark(a, b) != c.Dark(b, a) {
+				t.Errorf("version %d: copies of bit %d differ", tt.version, i)
+			}
+		}
+		if got != tt.want {
+			t.Errorf("version %d: version bits = %05X, want %05X", tt.version, got, tt.want)
+		}
+	}
+}
+
+func parseBits(s string) int {
+	v := 0
+	for _, r := range s {
+		v = v<<1 | int(r-'0')
+	}
+	return v
+}
+
+func TestEncodeFunctionPatterns(t *testing.T) {
+	code, err := Encode([]byte("EF1.eyJ0IjoxfQ.c2ln"), Medium)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Искатели в трех углах: темная рамка 7x7, светлое кольцо, темный центр 3x3
+	for _, corner := range [][2]int{{0, 0}, {code.Size - 7, 0}, {0, code.Size - 7}} {
+		for dy := 0; dy < 7; dy++ {
+			for dx := 0; dx < 7; dx++ {
+				ring := dx == 0 || dy == 0 || dx == 6 || dy == 6
+				center := dx >= 2 && dx <= 4 && dy >= 2 && dy <= 4
+				if want := ring || center; code.Dark(corner[0]+dx, corner[1]+dy) != want {
+					t.Fatalf("finder at %v: module (%d, %d) dark = %v", corner, dx, dy, !want)
+				}
+			}
+		}
+	}
+
+	// Синхронизирующие линии чередуются, начиная с темного модуля
+	for i := 8; i < code.Size-8; i++ {
+		if code.Dark(i, 6) != (i%2 == 0) || code.Dark(6, i) != (i%2 == 0) {
+			t.Fatalf("timing pattern broken at %d", i)
+		}
+	}
+
+	if !code.Dark(8, code.Size-8) {
+		t.Error("dark module is light")
+	}
+
+	// Обе копии информации о формате совпадают
+	first := 0
+	for i := 0; i <= 5; i++ {
+		if code.Dark(8, i) {
+			first |= 1 << uint(i)
+		}
+	}
+	for i, pos := range [][2]int{{8, 7}, {8, 8}, {7, 8}} {
+		if code.Dark(pos[0], pos[1]) {
+			first |= 1 << uint(6+i)
+		}
+	}
+	for i := 9; i < 15; i++ {
+		if code.Dark(14-i, 8) {
+			first |= 1 << uint(i)
+		}
+	}
+	if first != formatBits(code) {
+		t.Errorf("format copies differ: %015b and %015b", first, formatBits(code))
+	}
+}
+
+func TestParseLevel(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    Level
+		wantErr bool
+	}{
+		{"L", Low, false},
+		{"m", Medium, false},
+		{"Q", Quartile, false},
+		{"h", High, false},
+		{"X", 0, true},
+		{"", 0, true},
+	}
+
+	for _, tt := range tests {
+		got, err := ParseLevel(tt.in)
+		if (err != nil) != tt.wantErr || (!tt.wantErr && got != tt.want) {
+			t.Errorf("ParseLevel(%q) = %v, %v", tt.in, got, err)
+		}
+	}
+}
+
+func TestRender(t *testing.T) {
+	code, err := Encode([]byte("EF1.ticket"), Low)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		size, want int
+	}{
+		{290, 290},
+		{10, code.Size + 2*QuietZone}, // меньше символа - 1 пиксель на модуль
+	}
+	for _, tt := range tests {
+		data, err := code.PNG(tt.size)
+		if err != nil {
+			t.Fatal(err)
+		}
+		img, err := png.Decode(bytes.NewReader(data))
+		if err != nil {
+			t.Fatalf("PNG(%d) is not a PNG: %v", tt.size, err)
+		}
+		if b := img.Bounds(); b.Dx() != tt.want || b.Dy() != tt.want {
+			t.Errorf("PNG(%d) size = %v, want %d", tt.size, b.Size(), tt.want)
+		}
+	}
+
+	// Модуль (0, 0) - угол искателя; свободная зона светлая
+	data, _ := code.PNG(code.Size + 2*QuietZone)
+	img, _ := png.Decode(bytes.NewReader(data))
+	if r, _, _, _ := img.At(QuietZone, QuietZone).RGBA(); r != 0 {
+		t.Error("top-left finder module is not black")
+	}
+	if r, _, _, _ := img.At(0, 0).RGBA(); r == 0 {
+		t.Error("quiet zone is not white")
+	}
+
+	svg := code.SVG(200)
+	total := code.Size + 2*QuietZone
+	if !strings.Contains(svg, `width="200" height="200"`) || !strings.Contains(svg, fmt.Sprintf(`viewBox="0 0 %d %d"`, total, total)) {
+		t.Errorf("SVG header = %q", strings.SplitN(svg, "\n", 3)[1])
+	}
+	if !strings.Contains(svg, "M4 4h1v1h-1z") {
+		t.Error("SVG has no top-left finder module")
+	}
+}
@@ -0,0 +1,81 @@
+package qrcode
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+	"strings"
+)
+
+// Ширина свободной зоны вокруг символа в модулях, по стандарту
+const QuietZone = 4
+
+// scale подбирает размер модуля в пикселях так, чтобы символ со свободной зоной
+// поместился в size пикселей
+func (c *Code) scale(size int) (moduleSize, imageSize int) {
+	total := c.Size + 2*QuietZone
+	moduleSize = size / total
+	if moduleSize < 1 {
+		return 1, total
+	}
+	return moduleSize, size
+}
+
+// PNG рисует символ в черно-белое PNG размером size x size пикселей.
+// Если size меньше символа, изображение увеличивается до 1 пикселя на модуль.
+func (c *Code) PNG(size int) ([]byte, error) {
+	moduleSize, imageSize := c.scale(size)
+	offset := (imageSize - c.Size*moduleSize) / 2
+
+	palette := color.Palette{color.White, color.Black}
+	img := image.NewPaletted(image.Rect(0, 0, imageSize, imageSize), palette)
+
+	for y := 0; y < c.Size; y++ {
+		for x := 0; x < c.Size; x++ {
+			if !c.modules[y][x] {
+				continue
+			}
+			for py := 0; py < moduleSize; py++ {
+				row := (offset+y*moduleSize+py)*img.Stride + offset + x*moduleSize
+				for px := 0; px < moduleSize; px++ {
+					img.Pix[row+px] = 1
+				}
+			}
+		}
+	}
+
+	var buf bytes.Buffer
+	encoder := png.Encoder{CompressionLevel: png.BestCompression}
+	if err := encoder.Encode(&buf, img); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// SVG рисует символ векторно: один path с квадратом на каждый темный модуль.
+// size задает ширину и высоту изображения в пикселях.
+func (c *Code) SVG(size int) string {
+	total := c.Size + 2*QuietZone
+
+	var path strings.Builder
+	for y := 0; y < c.Size; y++ {
+		for x := 0; x < c.Size; x++ {
+			if c.modules[y][x] {
+				fmt.Fprintf(&path, "M%d %dh1v1h-1z", x+QuietZone, y+QuietZone)
+			}
+		}
+	}
+
+	var svg strings.Builder
+	svg.WriteString(`<?xml version="1.0" encoding="UTF-8"?>` + "\n")
+	fmt.Fprintf(&svg, `<svg xmlns="http://www.w3.org/2000/svg" version="1.1" width="%d" height="%d" viewBox="0 0 %d %d" shape-rendering="crispEdges">`,
+		size, size, total, total)
+	svg.WriteString("\n")
+	fmt.Fprintf(&svg, `<rect width="%d" height="%d" fill="#FFFFFF"/>`, total, total)
+	svg.WriteString("\n")
+	fmt.Fprintf(&svg, `<path d="%s" fill="#000000"/>`, path.String())
+	svg.WriteString("\n</svg>\n")
+	return svg.String()
+}
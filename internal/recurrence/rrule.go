@@ -0,0 +1,489 @@
+// Package recurrence реализует разбор и развертывание правил повторения
+// iCalendar (RFC 5545 RRULE) в конкретные даты вхождений.
+//
+// Поддерживается подмножество RFC, достаточное для расписаний событий:
+// FREQ (DAILY, WEEKLY, MONTHLY, YEARLY), INTERVAL, COUNT, UNTIL, BYDAY
+// (в том числе с порядковым номером для MONTHLY/YEARLY, например 1MO или -1FR),
+// BYMONTHDAY, BYMONTH и WKST.
+package recurrence
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type Frequency string
+
+const (
+	Daily   Frequency = "DAILY"
+	Weekly  Frequency = "WEEKLY"
+	Monthly Frequency = "MONTHLY"
+	Yearly  Frequency = "YEARLY"
+)
+
+// maxPeriods ограничивает число просматриваемых периодов, чтобы правило,
+// которое никогда не дает вхождений (например, 30 февраля), не зациклилось.
+const maxPeriods = 100000
+
+// WeekdayNum - день недели из BYDAY с необязательным порядковым номером
+// (0 - каждый такой день периода).
+type WeekdayNum struct {
+	Weekday time.Weekday
+	N       int
+}
+
+type Rule struct {
+	Freq       Frequency
+	Interval   int
+	Count      int
+	Until      time.Time
+	UntilLocal bool // UNTIL задан без "Z": местное время в часовом поясе DTSTART
+	ByDay      []WeekdayNum
+	ByMonthDay []int
+	ByMonth    []time.Month
+	WeekStart  time.Weekday
+}
+
+var weekdayCodes = map[string]time.Weekday{
+	"MO": time.Monday,
+	"TU": time.Tuesday,
+	"WE": time.Wednesday,
+	"TH": time.Thursday,
+	"FR": time.Friday,
+	"SA": time.Saturday,
+	"SU": time.Sunday,
+}
+
+// Parse разбирает строку RRULE (префикс "RRULE:" необязателен)
+func Parse(s string) (*Rule, error) {
+	s = strings.TrimSpace(s)
+	s = strings.TrimPrefix(strings.ToUpper(s), "RRULE:")
+	if s == "" {
+		return nil, errors.New("rrule is empty")
+	}
+
+	rule := &Rule{Interval: 1, WeekStart: time.Monday}
+
+	for _, part := range strings.Split(s, ";") {
+		if part == "" {
+			continue
+		}
+
+		key, value, ok := strings.Cut(part, "=")
+		if !ok || value == "" {
+			return nil, fmt.Errorf("invalid rrule part %q", part)
+		}
+
+		switch key {
+		case "FREQ":
+			switch Frequency(value) {
+			case Daily, Weekly, Monthly, Yearly:
+				rule.Freq = Frequency(value)
+			default:
+				return nil, fmt.Errorf("unsupported FREQ %q", value)
+			}
+		case "INTERVAL":
+			n, err := strconv.Atoi(value)
+			if err != nil || n < 1 {
+				return nil, fmt.Errorf("invalid INTERVAL %q", value)
+			}
+			rule.Interval = n
+		case "COUNT":
+			n, err := strconv.Atoi(value)
+			if err != nil || n < 1 {
+				return nil, fmt.Errorf("invalid COUNT %q", value)
+			}
+			rule.Count = n
+		case "UNTIL":
+			until, local, err := parseUntil(value)
+			if err != nil {
+				return nil, err
+			}
+			rule.Until = until
+			rule.UntilLocal = local
+		case "BYDAY":
+			for _, code := range strings.Split(value, ",") {
+				day, err := parseWeekdayNum(code)
+				if err != nil {
+					return nil, err
+				}
+				rule.ByDay = append(rule.ByDay, day)
+			}
+		case "BYMONTHDAY":
+			for _, v := range strings.Split(value, ",") {
+				n, err := strconv.Atoi(v)
+				if err != nil || n == 0 || n < -31 || n > 31 {
+					return nil, fmt.Errorf("invalid BYMONTHDAY %q", v)
+				}
+				rule.ByMonthDay = append(rule.ByMonthDay, n)
+			}
+		case "BYMONTH":
+			for _, v := range strings.Split(value, ",") {
+				n, err := strconv.Atoi(v)
+				if err != nil || n < 1 || n > 12 {
+					return nil, fmt.Errorf("invalid BYMONTH %q", v)
+				}
+				rule.ByMonth = append(rule.ByMonth, time.Month(n))
+			}
+		case "WKST":
+			day, ok := weekdayCodes[value]
+			if !ok {
+				return nil, fmt.Errorf("invalid WKST %q", value)
+			}
+			rule.WeekStart = day
+		default:
+			return nil, fmt.Errorf("unsupported rrule part %q", key)
+		}
+	}
+
+	if rule.Freq == "" {
+		return nil, errors.New("FREQ is required")
+	}
+
+	if rule.Count > 0 && !rule.Until.IsZero() {
+		return nil, errors.New("COUNT and UNTIL must not be used together")
+	}
+
+	for _, day := range rule.ByDay {
+		if day.N != 0 && rule.Freq != Monthly && rule.Freq != Yearly {
+			return nil, errors.New("BYDAY with ordinal is only allowed in MONTHLY and YEARLY rules")
+		}
+	}
+
+	if rule.Freq == Yearly && len(rule.ByDay) > 0 && len(rule.ByMonth) == 0 {
+		return nil, errors.New("BYDAY in YEARLY rules requires BYMONTH")
+	}
+
+	return rule, nil
+}
+
+// parseUntil разбирает UNTIL. Значение без "Z" - местное время: оно возвращается
+// как UTC с теми же цифрами и переводится в часовой пояс DTSTART при развертывании
+func parseUntil(value string) (time.Time, bool, error) {
+	layouts := []string{"20060102T150405Z", "20060102T150405", "20060102"}
+	for _, layout := range layouts {
+		if t, err := time.Parse(layout, value); err == nil {
+			if layout == "20060102" {
+				// Дата без времени включает весь день
+				t = t.Add(24*time.Hour - time.Second)
+			}
+			return t, layout != "20060102T150405Z", nil
+		}
+	}
+	return time.Time{}, false, fmt.Errorf("invalid UNTIL %q", value)
+}
+
+func parseWeekdayNum(code string) (WeekdayNum, error) {
+	if len(code) < 2 {
+		return WeekdayNum{}, fmt.Errorf("invalid BYDAY %q", code)
+	}
+
+	day, ok := weekdayCodes[code[len(code)-2:]]
+	if !ok {
+		return WeekdayNum{}, fmt.Errorf("invalid BYDAY %q", code)
+	}
+
+	n := 0
+	if prefix := code[:len(code)-2]; prefix != "" {
+		var err error
+		n, err = strconv.Atoi(prefix)
+		if err != nil || n == 0 || n < -53 || n > 53 {
+			return WeekdayNum{}, fmt.Errorf("invalid BYDAY %q", code)
+		}
+	}
+
+	return WeekdayNum{Weekday: day, N: n}, nil
+}
+
+// String возвращает правило в каноническом виде RRULE
+func (r *Rule) String() string {
+	parts := []string{"FREQ=" + string(r.Freq)}
+
+	if r.Interval > 1 {
+		parts = append(parts, "INTERVAL="+strconv.Itoa(r.Interval))
+	}
+	if r.Count > 0 {
+		parts = append(parts, "COUNT="+strconv.Itoa(r.Count))
+	}
+	if !r.Until.IsZero() && r.UntilLocal {
+		parts = append(parts, "UNTIL="+r.Until.Format("20060102T150405"))
+	} else if !r.Until.IsZero() {
+		parts = append(parts, "UNTIL="+r.Until.UTC().Format("20060102T150405Z"))
+	}
+	if len(r.ByDay) > 0 {
+		codes := make([]string, 0, len(r.ByDay))
+		for _, day := range r.ByDay {
+			code := weekdayCode(day.Weekday)
+			if day.N != 0 {
+				code = strconv.Itoa(day.N) + code
+			}
+			codes = append(codes, code)
+		}
+		parts = append(parts, "BYDAY="+strings.Join(codes, ","))
+	}
+	if len(r.ByMonthDay) > 0 {
+		days := make([]string, 0, len(r.ByMonthDay))
+		for _, d := range r.ByMonthDay {
+			days = append(days, strconv.Itoa(d))
+		}
+		parts = append(parts, "BYMONTHDAY="+strings.Join(days, ","))
+	}
+	if len(r.ByMonth) > 0 {
+		months := make([]string, 0, len(r.ByMonth))
+		for _, m := range r.ByMonth {
+			months = append(months, strconv.Itoa(int(m)))
+		}
+		parts = append(parts, "BYMONTH="+strings.Join(months, ","))
+	}
+	if r.WeekStart != time.Monday {
+		parts = append(parts, "WKST="+weekdayCode(r.WeekStart))
+	}
+
+	return strings.Join(parts, ";")
+}
+
+func weekdayCode(day time.Weekday) string {
+	for code, d := range weekdayCodes {
+		if d == day {
+			return code
+		}
+	}
+	return ""
+}
+
+// Expand возвращает вхождения правила, начиная с dtstart и не позже until
+// (нулевое until - без ограничения сверху), но не более limit штук.
+// Время суток и часовой пояс берутся из dtstart. Даты из exdates
+// исключаются из результата, но учитываются в COUNT, как того требует RFC 5545.
+func (r *Rule) Expand(dtstart, until time.Time, limit int, exdates []time.Time) []time.Time {
+	ruleUntil := r.Until
+	if r.UntilLocal {
+		y, m, d := r.Until.Date()
+		ruleUntil = time.Date(y, m, d, r.Until.Hour(), r.Until.Minute(), r.Until.Second(), 0, dtstart.Location())
+	}
+	if !ruleUntil.IsZero() && (until.IsZero() || ruleUntil.Before(until)) {
+		until = ruleUntil
+	}
+
+	excluded := make(map[int64]bool, len(exdates))
+	for _, ex := range exdates {
+		excluded[ex.Unix()] = true
+	}
+
+	var result []time.Time
+	emitted := 0
+
+	emit := func(t time.Time) bool {
+		if !until.IsZero() && t.After(until) {
+			return false
+		}
+		if r.Count > 0 && emitted >= r.Count {
+			return false
+		}
+		emitted++
+		if !excluded[t.Unix()] {
+			result = append(result, t)
+		}
+		return limit <= 0 || len(result) < limit
+	}
+
+	// DTSTART всегда является первым вхождением
+	if !emit(dtstart) {
+		return result
+	}
+
+	for period := 0; period < maxPeriods; period++ {
+		candidates := r.periodCandidates(dtstart, period)
+		for _, t := range candidates {
+			if !t.After(dtstart) {
+				continue
+			}
+			if !emit(t) {
+				return result
+			}
+		}
+
+		if r.periodStart(dtstart, period).After(until) && !until.IsZero() {
+			break
+		}
+	}
+
+	return result
+}
+
+// periodStart возвращает начало периода с номером period (с учетом INTERVAL)
+func (r *Rule) periodStart(dtstart time.Time, period int) time.Time {
+	step := period * r.Interval
+	y, m, d := dtstart.Date()
+	loc := dtstart.Location()
+
+	switch r.Freq {
+	case Daily:
+		return time.Date(y, m, d+step, 0, 0, 0, 0, loc)
+	case Weekly:
+		offset := (int(dtstart.Weekday()) - int(r.WeekStart) + 7) % 7
+		return time.Date(y, m, d-offset+7*step, 0, 0, 0, 0, loc)
+	case Monthly:
+		return time.Date(y, m+time.Month(step), 1, 0, 0, 0, 0, loc)
+	default:
+		return time.Date(y+step, 1, 1, 0, 0, 0, 0, loc)
+	}
+}
+
+// periodCandidates возвращает отсортированные вхождения внутри одного периода
+func (r *Rule) periodCandidates(dtstart time.Time, period int) []time.Time {
+	start := r.periodStart(dtstart, period)
+	var days []time.Time
+
+	switch r.Freq {
+	case Daily:
+		days = []time.Time{start}
+	case Weekly:
+		if len(r.ByDay) == 0 {
+			offset := (int(dtstart.Weekday()) - int(r.WeekStart) + 7) % 7
+			days = []time.Time{start.AddDate(0, 0, offset)}
+		} else {
+			for i := 0; i < 7; i++ {
+				days = append(days, start.AddDate(0, 0, i))
+			}
+		}
+	case Monthly:
+		days = r.monthDays(dtstart, start.Year(), start.Month())
+	case Yearly:
+		// Без BYMONTH правило с BYMONTHDAY применяется к каждому месяцу года (RFC 5545),
+		// а без BYMONTHDAY повторяет месяц DTSTART
+		months := r.ByMonth
+		if len(months) == 0 && len(r.ByMonthDay) > 0 {
+			for month := time.January; month <= time.December; month++ {
+				months = append(months, month)
+			}
+		} else if len(months) == 0 {
+			months = []time.Month{dtstart.Month()}
+		}
+		for _, month := range months {
+			days = append(days, r.monthDays(dtstart, start.Year(), month)...)
+		}
+	}
+
+	var result []time.Time
+	for _, day := range days {
+		if !r.matches(day) {
+			continue
+		}
+		result = append(result, time.Date(day.Year(), day.Month(), day.Day(),
+			dtstart.Hour(), dtstart.Minute(), dtstart.Second(), 0, dtstart.Location()))
+	}
+
+	sort.Slice(result, func(i, j int) bool { return result[i].Before(result[j]) })
+	return dedupe(result)
+}
+
+// monthDays разворачивает BYMONTHDAY/BYDAY внутри одного месяца
+func (r *Rule) monthDays(dtstart time.Time, year int, month time.Month) []time.Time {
+	loc := dtstart.Location()
+	first := time.Date(year, month, 1, 0, 0, 0, 0, loc)
+	daysInMonth := first.AddDate(0, 1, -1).Day()
+
+	if len(r.ByMonthDay) == 0 && len(r.ByDay) == 0 {
+		if dtstart.Day() > daysInMonth {
+			return nil
+		}
+		return []time.Time{time.Date(year, month, dtstart.Day(), 0, 0, 0, 0, loc)}
+	}
+
+	var days []time.Time
+	if len(r.ByMonthDay) > 0 {
+		for _, d := range r.ByMonthDay {
+			if d < 0 {
+				d = daysInMonth + d + 1
+			}
+			if d >= 1 && d <= daysInMonth {
+				days = append(days, time.Date(year, month, d, 0, 0, 0, 0, loc))
+			}
+		}
+		return days
+	}
+
+	for _, byDay := range r.ByDay {
+		var matching []time.Time
+		for d := 1; d <= daysInMonth; d++ {
+			day := time.Date(year, month, d, 0, 0, 0, 0, loc)
+			if day.Weekday() == byDay.Weekday {
+				matching = append(matching, day)
+			}
+		}
+
+		switch {
+		case byDay.N == 0:
+			days = append(days, matching...)
+		case byDay.N > 0 && byDay.N <= len(matching):
+			days = append(days, matching[byDay.N-1])
+		case byDay.N < 0 && -byDay.N <= len(matching):
+			days = append(days, matching[len(matching)+byDay.N])
+		}
+	}
+
+	return days
+}
+
+// matches применяет BYMONTH и BYDAY как фильтры к уже сгенерированному дню
+func (r *Rule) matches(day time.Time) bool {
+	if len(r.ByMonth) > 0 && !containsMonth(r.ByMonth, day.Month()) {
+		return false
+	}
+
+	if len(r.ByDay) > 0 {
+		matched := false
+		for _, byDay := range r.ByDay {
+			if byDay.Weekday == day.Weekday() {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			return false
+		}
+	}
+
+	if len(r.ByMonthDay) > 0 && r.Freq != Monthly && r.Freq != Yearly {
+		daysInMonth := time.Date(day.Year(), day.Month()+1, 0, 0, 0, 0, 0, day.Location()).Day()
+		matched := false
+		for _, d := range r.ByMonthDay {
+			if d == day.Day() || (d < 0 && daysInMonth+d+1 == day.Day()) {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			return false
+		}
+	}
+
+	return true
+}
+
+func containsMonth(months []time.Month, month time.Month) bool {
+	for _, m := range months {
+		if m == month {
+			return true
+		}
+	}
+	return false
+}
+
+func dedupe(times []time.Time) []time.Time {
+	if len(times) < 2 {
+		return times
+	}
+	result := times[:1]
+	for _, t := range times[1:] {
+		if !t.Equal(result[len(result)-1]) {
+			result = append(result, t)
+		}
+	}
+	return result
+}
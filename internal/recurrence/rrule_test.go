@@ -0,0 +1,282 @@
+package recurrence
+
+import (
+	"strings"
+	"testing"
+	"time"
+	_ "time/tzdata"
+)
+
+func mustParse(t *testing.T, s string) *Rule {
+	t.Helper()
+	rule, err := Parse(s)
+	if err != nil {
+		t.Fatalf("Parse(%q): %v", s, err)
+	}
+	return rule
+}
+
+func at(t *testing.T, loc *time.Location, s string) time.Time {
+	t.Helper()
+	v, err := time.ParseInLocation("2006-01-02 15:04", s, loc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return v
+}
+
+func TestExpand(t *testing.T) {
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	if err != nil {
+		t.Fatal(err)
+	}
+	newYork, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		rule    string
+		loc     *time.Location
+		dtstart string
+		until   string
+		limit   int
+		exdates []string
+		want    []string
+	}{
+		{
+			// Переход на летнее время 29 марта: время суток сохраняется
+			name:    "daily across DST",
+			rule:    "FREQ=DAILY;COUNT=5",
+			loc:     berlin,
+			dtstart: "2026-03-27 10:00",
+			want:    []string{"2026-03-27 10:00", "2026-03-28 10:00", "2026-03-29 10:00", "2026-03-30 10:00", "2026-03-31 10:00"},
+		},
+		{
+			name:    "weekly by day",
+			rule:    "FREQ=WEEKLY;BYDAY=TU,TH;COUNT=6",
+			dtstart: "2026-09-01 19:00",
+			want:    []string{"2026-09-01 19:00", "2026-09-03 19:00", "2026-09-08 19:00", "2026-09-10 19:00", "2026-09-15 19:00", "2026-09-17 19:00"},
+		},
+		{
+			// UNTIL без времени включает весь день
+			name:    "biweekly until date",
+			rule:    "FREQ=WEEKLY;INTERVAL=2;BYDAY=MO,WE,FR;UNTIL=20260925",
+			dtstart: "2026-09-07 10:00",
+			want:    []string{"2026-09-07 10:00", "2026-09-09 10:00", "2026-09-11 10:00", "2026-09-21 10:00", "2026-09-23 10:00", "2026-09-25 10:00"},
+		},
+		{
+			// RFC 5545: каждый второй вторник и воскресенье, неделя с понедельника
+			name:    "week start monday",
+			rule:    "FREQ=WEEKLY;INTERVAL=2;COUNT=4;BYDAY=TU,SU;WKST=MO",
+			dtstart: "1997-08-05 09:00",
+			want:    []string{"1997-08-05 09:00", "1997-08-10 09:00", "1997-08-19 09:00", "1997-08-24 09:00"},
+		},
+		{
+			// То же правило с неделей с воскресенья дает другие даты
+			name:    "week start sunday",
+			rule:    "FREQ=WEEKLY;INTERVAL=2;COUNT=4;BYDAY=TU,SU;WKST=SU",
+			dtstart: "1997-08-05 09:00",
+			want:    []string{"1997-08-05 09:00", "1997-08-17 09:00", "1997-08-19 09:00", "1997-08-31 09:00"},
+		},
+		{
+			name:    "last friday of month",
+			rule:    "FREQ=MONTHLY;BYDAY=-1FR;COUNT=4",
+			dtstart: "2026-01-30 18:30",
+			want:    []string{"2026-01-30 18:30", "2026-02-27 18:30", "2026-03-27 18:30", "2026-04-24 18:30"},
+		},
+		{
+			// Месяцы без 31-го числа пропускаются
+			name:    "monthly on the 31st",
+			rule:    "FREQ=MONTHLY;COUNT=4",
+			dtstart: "2026-01-31 12:00",
+			want:    []string{"2026-01-31 12:00", "2026-03-31 12:00", "2026-05-31 12:00", "2026-07-31 12:00"},
+		},
+		{
+			name:    "last day of month",
+			rule:    "FREQ=MONTHLY;BYMONTHDAY=-1;COUNT=3",
+			dtstart: "2026-01-31 12:00",
+			want:    []string{"2026-01-31 12:00", "2026-02-28 12:00", "2026-03-31 12:00"},
+		},
+		{
+			name:    "fourth thursday of november",
+			rule:    "FREQ=YEARLY;BYMONTH=11;BYDAY=4TH;COUNT=3",
+			dtstart: "2026-11-26 15:00",
+			want:    []string{"2026-11-26 15:00", "2027-11-25 15:00", "2028-11-23 15:00"},
+		},
+		{
+			name:    "leap day",
+			rule:    "FREQ=YEARLY;COUNT=2",
+			dtstart: "2028-02-29 10:00",
+			want:    []string{"2028-02-29 10:00", "2032-02-29 10:00"},
+		},
+		{
+			name:    "daily filtered by month day",
+			rule:    "FREQ=DAILY;BYMONTHDAY=1,15;COUNT=3",
+			dtstart: "2026-09-01 10:00",
+			want:    []string{"2026-09-01 10:00", "2026-09-15 10:00", "2026-10-01 10:00"},
+		},
+		{
+			// Исключенные даты расходуют COUNT
+			name:    "exdates count towards COUNT",
+			rule:    "FREQ=WEEKLY;COUNT=4",
+			dtstart: "2026-09-01 19:00",
+			exdates: []string{"2026-09-08 19:00"},
+			want:    []string{"2026-09-01 19:00", "2026-09-15 19:00", "2026-09-22 19:00"},
+		},
+		{
+			// UNTIL без "Z" - местное время DTSTART: 09:00 по Берлину раньше вхождения в 10:00
+			name:    "local UNTIL",
+			rule:    "FREQ=DAILY;UNTIL=20260903T090000",
+			loc:     berlin,
+			dtstart: "2026-09-01 10:00",
+			want:    []string{"2026-09-01 10:00", "2026-09-02 10:00"},
+		},
+		{
+			// Дата без времени включает весь местный день, хотя в UTC это уже следующие сутки
+			name:    "local UNTIL date",
+			rule:    "FREQ=DAILY;UNTIL=20260903",
+			loc:     newYork,
+			dtstart: "2026-09-01 21:00",
+			want:    []string{"2026-09-01 21:00", "2026-09-02 21:00", "2026-09-03 21:00"},
+		},
+		{
+			name:    "UTC UNTIL",
+			rule:    "FREQ=DAILY;UNTIL=20260903T010000Z",
+			loc:     newYork,
+			dtstart: "2026-09-01 21:00",
+			want:    []string{"2026-09-01 21:00", "2026-09-02 21:00"},
+		},
+		{
+			// Без BYMONTH BYMONTHDAY применяется к каждому месяцу года
+			name:    "yearly by month day",
+			rule:    "FREQ=YEARLY;BYMONTHDAY=1,-1;COUNT=5",
+			dtstart: "2026-01-01 09:00",
+			want:    []string{"2026-01-01 09:00", "2026-01-31 09:00", "2026-02-01 09:00", "2026-02-28 09:00", "2026-03-01 09:00"},
+		},
+		{
+			name:    "yearly by month day with BYMONTH",
+			rule:    "FREQ=YEARLY;BYMONTH=2;BYMONTHDAY=-1;COUNT=3",
+			dtstart: "2026-02-28 09:00",
+			want:    []string{"2026-02-28 09:00", "2027-02-28 09:00", "2028-02-29 09:00"},
+		},
+		{
+			name:    "until argument",
+			rule:    "FREQ=DAILY",
+			dtstart: "2026-09-01 10:00",
+			until:   "2026-09-03 10:00",
+			want:    []string{"2026-09-01 10:00", "2026-09-02 10:00", "2026-09-03 10:00"},
+		},
+		{
+			name:    "earlier rule UNTIL wins",
+			rule:    "FREQ=DAILY;UNTIL=20260902T100000Z",
+			dtstart: "2026-09-01 10:00",
+			until:   "2026-09-30 10:00",
+			want:    []string{"2026-09-01 10:00", "2026-09-02 10:00"},
+		},
+		{
+			name:    "limit",
+			rule:    "FREQ=DAILY;INTERVAL=3",
+			dtstart: "2026-09-01 10:00",
+			limit:   3,
+			want:    []string{"2026-09-01 10:00", "2026-09-04 10:00", "2026-09-07 10:00"},
+		},
+		{
+			// Правило без вхождений возвращает только DTSTART
+			name:    "impossible date",
+			rule:    "FREQ=YEARLY;BYMONTH=2;BYMONTHDAY=30",
+			dtstart: "2026-09-01 10:00",
+			until:   "2030-01-01 00:00",
+			want:    []string{"2026-09-01 10:00"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			loc := tt.loc
+			if loc == nil {
+				loc = time.UTC
+			}
+			var until time.Time
+			if tt.until != "" {
+				until = at(t, loc, tt.until)
+			}
+			var exdates []time.Time
+			for _, ex := range tt.exdates {
+				exdates = append(exdates, at(t, loc, ex))
+			}
+
+			got := mustParse(t, tt.rule).Expand(at(t, loc, tt.dtstart), until, tt.limit, exdates)
+
+			formatted := make([]string, len(got))
+			for i, v := range got {
+				if v.Location() != loc {
+					t.Errorf("occurrence %s is in %s, want %s", v, v.Location(), loc)
+				}
+				formatted[i] = v.Format("2006-01-02 15:04")
+			}
+			if strings.Join(formatted, ", ") != strings.Join(tt.want, ", ") {
+				t.Errorf("Expand = %v, want %v", formatted, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseString(t *testing.T) {
+	tests := []struct {
+		in   string
+		want string
+	}{
+		{"FREQ=DAILY", "FREQ=DAILY"},
+		{"RRULE:freq=weekly;byday=tu,th", "FREQ=WEEKLY;BYDAY=TU,TH"},
+		{" FREQ=WEEKLY;INTERVAL=1;WKST=MO ", "FREQ=WEEKLY"},
+		{"FREQ=MONTHLY;BYDAY=-1FR,2MO;COUNT=10", "FREQ=MONTHLY;COUNT=10;BYDAY=-1FR,2MO"},
+		{"FREQ=MONTHLY;BYMONTHDAY=1,-1;INTERVAL=2", "FREQ=MONTHLY;INTERVAL=2;BYMONTHDAY=1,-1"},
+		{"FREQ=YEARLY;BYMONTH=11;BYDAY=4TH;WKST=SU", "FREQ=YEARLY;BYDAY=4TH;BYMONTH=11;WKST=SU"},
+		{"FREQ=DAILY;UNTIL=20260925", "FREQ=DAILY;UNTIL=20260925T235959"},
+		{"FREQ=DAILY;UNTIL=20260925T100000", "FREQ=DAILY;UNTIL=20260925T100000"},
+		{"FREQ=DAILY;UNTIL=20260925T120000Z;", "FREQ=DAILY;UNTIL=20260925T120000Z"},
+	}
+
+	for _, tt := range tests {
+		rule := mustParse(t, tt.in)
+		if got := rule.String(); got != tt.want {
+			t.Errorf("Parse(%q).String() = %q, want %q", tt.in, got, tt.want)
+		}
+		// Каноническая форма разбирается в то же правило
+		if again := mustParse(t, rule.String()).String(); again != tt.want {
+			t.Errorf("round trip of %q = %q", tt.want, again)
+		}
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	tests := []string{
+		"",
+		"RRULE:",
+		"INTERVAL=2",
+		"FREQ=HOURLY",
+		"FREQ=DAILY;INTERVAL=0",
+		"FREQ=DAILY;COUNT=-1",
+		"FREQ=DAILY;COUNT=3;UNTIL=20260101",
+		"FREQ=DAILY;UNTIL=2026-01-01",
+		"FREQ=WEEKLY;BYDAY=XX",
+		"FREQ=WEEKLY;BYDAY=1MO",
+		"FREQ=MONTHLY;BYDAY=0MO",
+		"FREQ=MONTHLY;BYDAY=54MO",
+		"FREQ=MONTHLY;BYMONTHDAY=0",
+		"FREQ=MONTHLY;BYMONTHDAY=32",
+		"FREQ=YEARLY;BYMONTH=13",
+		"FREQ=YEARLY;BYDAY=MO",
+		"FREQ=WEEKLY;WKST=XX",
+		"FREQ=DAILY;BYHOUR=10",
+		"FREQ=DAILY;COUNT",
+	}
+
+	for _, s := range tests {
+		if rule, err := Parse(s); err == nil {
+			t.Errorf("Parse(%q) = %v, want error", s, rule)
+		}
+	}
+}
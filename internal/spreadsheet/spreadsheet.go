@@ -0,0 +1,90 @@
+// Package spreadsheet читает табличные файлы (CSV и XLSX) в строки ячеек.
+// Из XLSX читается только первый лист; формулы не вычисляются, берется
+// сохраненное в файле значение.
+package spreadsheet
+
+import (
+	"bytes"
+	"encoding/csv"
+	"errors"
+	"strings"
+)
+
+var ErrUnsupportedFormat = errors.New("spreadsheet: unsupported format, expected csv or xlsx")
+
+// Read читает файл в формате format ("csv" или "xlsx"). Пустые строки в конце
+// файла отбрасываются; строки могут быть разной длины
+func Read(format string, data []byte) ([][]string, error) {
+	var rows [][]string
+	var err error
+	switch format {
+	case "csv":
+		rows, err = ReadCSV(data)
+	case "xlsx":
+		rows, err = ReadXLSX(data)
+	default:
+		return nil, ErrUnsupportedFormat
+	}
+	if err != nil {
+		return nil, err
+	}
+	return trimTrailingEmpty(rows), nil
+}
+
+// FormatFromName определяет формат по расширению имени файла
+func FormatFromName(name string) string {
+	lower := strings.ToLower(name)
+	switch {
+	case strings.HasSuffix(lower, ".csv"):
+		return "csv"
+	case strings.HasSuffix(lower, ".xlsx"):
+		return "xlsx"
+	}
+	return ""
+}
+
+// ReadCSV читает CSV в UTF-8 (BOM допускается). Разделитель - запятая, точка
+// с запятой или табуляция - определяется по первой строке: Excel с русской
+// локалью сохраняет CSV через точку с запятой.
+func ReadCSV(data []byte) ([][]string, error) {
+	data = bytes.TrimPrefix(data, []byte("\xef\xbb\xbf"))
+
+	reader := csv.NewReader(bytes.NewReader(data))
+	reader.Comma = detectDelimiter(data)
+	reader.FieldsPerRecord = -1
+	reader.LazyQuotes = true
+
+	return reader.ReadAll()
+}
+
+func detectDelimiter(data []byte) rune {
+	firstLine := data
+	if i := bytes.IndexByte(data, '\n'); i >= 0 {
+		firstLine = data[:i]
+	}
+
+	delimiter, best := ',', bytes.Count(firstLine, []byte(","))
+	for _, candidate := range []rune{';', '\t'} {
+		if n := bytes.Count(firstLine, []byte(string(candidate))); n > best {
+			delimiter, best = candidate, n
+		}
+	}
+	return delimiter
+}
+
+func trimTrailingEmpty(rows [][]string) [][]string {
+	for len(rows) > 0 && IsEmptyRow(rows[len(rows)-1]) {
+		rows = rows[:len(rows)-1]
+	}
+	return rows
+}
+
+// IsEmptyRow сообщает, что все ячейки строки пустые
+func IsEmptyRow(row []string) bool {
+	for _, cell := range row {
+		if strings.TrimSpace(cell) != "" {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,219 @@
+package spreadsheet
+
+import (
+	"archive/zip"
+	"bytes"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestReadCSV(t *testing.T) {
+	tests := []struct {
+		name string
+		data string
+		want [][]string
+	}{
+		{
+			name: "comma",
+			data: "email,name\nanna@example.com,Анна\n",
+			want: [][]string{{"email", "name"}, {"anna@example.com", "Анна"}},
+		},
+		{
+			// Excel с русской локалью: точка с запятой, BOM, CRLF
+			name: "semicolon with BOM",
+			data: "\xef\xbb\xbfemail;name;note\r\nanna@example.com;Анна;\"1,5 часа\"\r\n",
+			want: [][]string{{"email", "name", "note"}, {"anna@example.com", "Анна", "1,5 часа"}},
+		},
+		{
+			name: "tab",
+			data: "email\tname\nanna@example.com\tAnna, Jr.\n",
+			want: [][]string{{"email", "name"}, {"anna@example.com", "Anna, Jr."}},
+		},
+		{
+			name: "ragged rows and trailing empty rows",
+			data: "email,name,phone\nanna@example.com\n,,\n , \n",
+			want: [][]string{{"email", "name", "phone"}, {"anna@example.com"}},
+		},
+		{
+			name: "lazy quotes",
+			data: "email,name\nanna@example.com,Anna \"Ann\" Smith\n",
+			want: [][]string{{"email", "name"}, {"anna@example.com", "Anna \"Ann\" Smith"}},
+		},
+		{
+			name: "single column",
+			data: "email\nanna@example.com",
+			want: [][]string{{"email"}, {"anna@example.com"}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Read("csv", []byte(tt.data))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Read = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFormatFromName(t *testing.T) {
+	tests := map[string]string{
+		"guests.csv":       "csv",
+		"Гости.XLSX":       "xlsx",
+		"export.final.CSV": "csv",
+		"guests.xls":       "",
+		"guests.csv.zip":   "",
+		"":                 "",
+	}
+	for name, want := range tests {
+		if got := FormatFromName(name); got != want {
+			t.Errorf("FormatFromName(%q) = %q, want %q", name, got, want)
+		}
+	}
+
+	if _, err := Read("xls", nil); !errors.Is(err, ErrUnsupportedFormat) {
+		t.Errorf("Read(xls) error = %v, want ErrUnsupportedFormat", err)
+	}
+}
+
+const (
+	testWorkbook = `<?xml version="1.0" encoding="UTF-8"?>
+<workbook xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main" xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships">
+<sheets><sheet name="Гости" sheetId="1" r:id="rId7"/><sheet name="Архив" sheetId="2" r:id="rId8"/></sheets>
+</workbook>`
+	testRels = `<?xml version="1.0" encoding="UTF-8"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">
+<Relationship Id="rId8" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/worksheet" Target="worksheets/sheet1.xml"/>
+<Relationship Id="rId7" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/worksheet" Target="worksheets/guests.xml"/>
+</Relationships>`
+	testSharedStrings = `<?xml version="1.0" encoding="UTF-8"?>
+<sst xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main" count="3" uniqueCount="3">
+<si><t>email</t></si>
+<si><t>name</t></si>
+<si><r><t>Анна </t></r><r><rPr><b/></rPr><t>Иванова</t></r></si>
+</sst>`
+	// Строка 3 пропущена, в строке 4 нет ячейки B; C4 - формула с сохраненным значением
+	testSheet = `<?xml version="1.0" encoding="UTF-8"?>
+<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main"><sheetData>
+<row r="1"><c r="A1" t="s"><v>0</v></c><c r="B1" t="s"><v>1</v></c></row>
+<row r="2"><c r="A2" t="inlineStr"><is><t>anna@example.com</t></is></c><c r="B2" t="s"><v>2</v></c><c r="C2" t="b"><v>1</v></c></row>
+<row r="4"><c r="A4" t="inlineStr"><is><t>petr@example.com</t></is></c><c r="C4"><f>1+1</f><v>2</v></c><c r="D4"><v>45292</v></c></row>
+<row r="5"><c r="A5"/></row>
+</sheetData></worksheet>`
+)
+
+// buildXLSX собирает книгу XLSX из частей: имя в архиве - содержимое
+func buildXLSX(t *testing.T, parts map[string]string) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for name, content := range parts {
+		w, err := zw.Create(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		w.Write([]byte(content))
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func testXLSXParts() map[string]string {
+	return map[string]string{
+		"xl/workbook.xml":            testWorkbook,
+		"xl/_rels/workbook.xml.rels": testRels,
+		"xl/sharedStrings.xml":       testSharedStrings,
+		"xl/worksheets/guests.xml":   testSheet,
+		"xl/worksheets/sheet1.xml":   `<worksheet><sheetData><row r="1"><c r="A1" t="inlineStr"><is><t>archive</t></is></c></row></sheetData></worksheet>`,
+	}
+}
+
+func TestReadXLSX(t *testing.T) {
+	got, err := Read("xlsx", buildXLSX(t, testXLSXParts()))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := [][]string{
+		{"email", "name"},
+		{"anna@example.com", "Анна Иванова", "TRUE"},
+		nil,
+		{"petr@example.com", "", "2", "45292"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Read = %q, want %q", got, want)
+	}
+}
+
+func TestReadXLSXInvalid(t *testing.T) {
+	tests := []struct {
+		name   string
+		modify func(map[string]string)
+	}{
+		{"no workbook", func(parts map[string]string) { delete(parts, "xl/workbook.xml") }},
+		{"no sheets", func(parts map[string]string) { parts["xl/workbook.xml"] = "<workbook><sheets/></workbook>" }},
+		{"missing sheet part", func(parts map[string]string) { delete(parts, "xl/worksheets/guests.xml") }},
+		{"missing shared string", func(parts map[string]string) { delete(parts, "xl/sharedStrings.xml") }},
+		{"bad cell reference", func(parts map[string]string) {
+			parts["xl/worksheets/guests.xml"] = `<worksheet><sheetData><row r="1"><c r="12"><v>1</v></c></row></sheetData></worksheet>`
+		}},
+		{"broken xml", func(parts map[string]string) { parts["xl/worksheets/guests.xml"] = "<worksheet><sheetData>" }},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			parts := testXLSXParts()
+			tt.modify(parts)
+			if rows, err := ReadXLSX(buildXLSX(t, parts)); err == nil {
+				t.Errorf("ReadXLSX = %q, want error", rows)
+			}
+		})
+	}
+
+	if _, err := ReadXLSX([]byte("email,name\n")); !errors.Is(err, ErrInvalidXLSX) {
+		t.Errorf("ReadXLSX(csv) error = %v, want ErrInvalidXLSX", err)
+	}
+}
+
+func TestReadXLSXWithoutRelationships(t *testing.T) {
+	// Без workbook.xml.rels читается лист по стандартному пути
+	parts := testXLSXParts()
+	delete(parts, "xl/_rels/workbook.xml.rels")
+
+	got, err := ReadXLSX(buildXLSX(t, parts))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := [][]string{{"archive"}}; !reflect.DeepEqual(got, want) {
+		t.Errorf("ReadXLSX = %q, want %q", got, want)
+	}
+}
+
+func TestColumnIndex(t *testing.T) {
+	tests := []struct {
+		ref     string
+		want    int
+		wantErr bool
+	}{
+		{"A1", 0, false},
+		{"B12", 1, false},
+		{"Z3", 25, false},
+		{"AA3", 26, false},
+		{"XFD1048576", 16383, false},
+		{"XFE1", 0, true},
+		{"12", 0, true},
+		{"", 0, true},
+	}
+	for _, tt := range tests {
+		got, err := columnIndex(tt.ref)
+		if (err != nil) != tt.wantErr || got != tt.want {
+			t.Errorf("columnIndex(%q) = %d, %v", tt.ref, got, err)
+		}
+	}
+}
@@ -0,0 +1,247 @@
+// Package tickettoken выпускает и проверяет подписанные токены тикетов для QR-кодов.
+// Токен содержит ID тикета, ID события и окно действия и подписан Ed25519, поэтому
+// сканер может проверить его без обращения к API - достаточно публичного ключа.
+//
+// Формат: "EF1." + base64url(payload || signature), где payload:
+//
+//	версия (1 байт) | ID ключа (4 байта) | ID тикета (uvarint) | ID события (uvarint) |
+//	начало действия, unix (varint) | конец действия, unix (varint) | метка кода (6 байт)
+//
+// Метка кода - первые байты SHA-256 от случайного QR-кода тикета: после смены кода
+// (например, при передаче тикета) ранее выпущенные токены перестают приниматься сервером.
+package tickettoken
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"log"
+	"os"
+	"strings"
+	"time"
+)
+
+// Prefix отличает подписанный токен от случайного QR-кода тикетов, выпущенных раньше
+const Prefix = "EF1."
+
+const (
+	version   = 1
+	keyIDSize = 4
+	tagSize   = 6
+)
+
+var (
+	ErrMalformed     = errors.New("ticket token is malformed")
+	ErrUnknownKey    = errors.New("ticket token is signed with an unknown key")
+	ErrSignature     = errors.New("ticket token signature is invalid")
+	ErrNotYetValid   = errors.New("ticket token is not valid yet")
+	ErrExpired       = errors.New("ticket token has expired")
+	errInvalidSecret = errors.New("TICKET_SIGNING_KEY must be a base64-encoded 32-byte Ed25519 seed")
+)
+
+// Claims - данные, которые несет токен
+type Claims struct {
+	TicketID  uint
+	EventID   uint
+	NotBefore time.Time
+	NotAfter  time.Time
+	CodeTag   []byte
+}
+
+// Key - ключ подписи токенов
+type Key struct {
+	ID      string // hex, первые 4 байта SHA-256 публичного ключа
+	Public  ed25519.PublicKey
+	private ed25519.PrivateKey
+}
+
+// NewKey создает ключ из 32-байтного seed Ed25519
+func NewKey(seed []byte) (*Key, error) {
+	if len(seed) != ed25519.SeedSize {
+		return nil, errInvalidSecret
+	}
+	private := ed25519.NewKeyFromSeed(seed)
+	public := private.Public().(ed25519.PublicKey)
+	sum := sha256.Sum256(public)
+	return &Key{ID: hex.EncodeToString(sum[:keyIDSize]), Public: public, private: private}, nil
+}
+
+// Signing - ключ, которым подписываются новые токены
+var Signing *Key
+
+// Verification - публичные ключи, которыми принимаются токены: текущий и предыдущие
+var Verification []*Key
+
+// Setup загружает ключ подписи из TICKET_SIGNING_KEY (base64 seed Ed25519) и
+// публичные ключи предыдущих ротаций из TICKET_PREVIOUS_KEYS (base64 через запятую).
+// Без TICKET_SIGNING_KEY сервер не запускается: ключ, выведенный из известного
+// значения, позволил бы подделывать тикеты. Только для разработки можно явно
+// включить TICKET_SIGNING_DEV_KEY=true - тогда ключ выводится из JWT_SECRET.
+func Setup() {
+	var key *Key
+	var err error
+
+	if encoded := os.Getenv("TICKET_SIGNING_KEY"); encoded != "" {
+		var seed []byte
+		seed, err = base64.StdEncoding.DecodeString(encoded)
+		if err == nil {
+			key, err = NewKey(seed)
+		} else {
+			err = errInvalidSecret
+		}
+		if err != nil {
+			log.Fatalf("Ticket tokens: %v", err)
+		}
+	} else if os.Getenv("TICKET_SIGNING_DEV_KEY") == "true" {
+		log.Printf("⚠️  TICKET_SIGNING_DEV_KEY is enabled, deriving the ticket signing key from JWT_SECRET. Never use this in production")
+		key = derivedKey(os.Getenv("JWT_SECRET"))
+	} else {
+		log.Fatalf("Ticket tokens: TICKET_SIGNING_KEY is not set (base64-encoded 32-byte Ed25519 seed; set TICKET_SIGNING_DEV_KEY=true for local development)")
+	}
+
+	verification := []*Key{key}
+	for _, encoded := range strings.Split(os.Getenv("TICKET_PREVIOUS_KEYS"), ",") {
+		encoded = strings.TrimSpace(encoded)
+		if encoded == "" {
+			continue
+		}
+		public, err := base64.StdEncoding.DecodeString(encoded)
+		if err != nil || len(public) != ed25519.PublicKeySize {
+			log.Fatalf("Ticket tokens: TICKET_PREVIOUS_KEYS must contain base64-encoded Ed25519 public keys")
+		}
+		sum := sha256.Sum256(public)
+		verification = append(verification, &Key{ID: hex.EncodeToString(sum[:keyIDSize]), Public: public})
+	}
+
+	Signing = key
+	Verification = verification
+	log.Printf("🔏 Ticket token signing key: %s", key.ID)
+}
+
+// derivedKey выводит ключ из секрета - только для разработки (TICKET_SIGNING_DEV_KEY)
+func derivedKey(secret string) *Key {
+	if secret == "" {
+		secret = "your-secret-key-change-in-production"
+	}
+	seed := sha256.Sum256([]byte("eventflow ticket signing key\x00" + secret))
+	key, _ := NewKey(seed[:])
+	return key
+}
+
+// CodeTag возвращает метку QR-кода тикета для токена
+func CodeTag(code string) []byte {
+	sum := sha256.Sum256([]byte(code))
+	return sum[:tagSize]
+}
+
+// IsToken сообщает, похожа ли строка на подписанный токен
+func IsToken(s string) bool {
+	return strings.HasPrefix(s, Prefix)
+}
+
+// Sign выпускает токен ключом Signing
+func Sign(claims Claims) string {
+	key := Signing
+	keyID, _ := hex.DecodeString(key.ID)
+
+	payload := []byte{version}
+	payload = append(payload, keyID...)
+	payload = binary.AppendUvarint(payload, uint64(claims.TicketID))
+	payload = binary.AppendUvarint(payload, uint64(claims.EventID))
+	payload = binary.AppendVarint(payload, claims.NotBefore.Unix())
+	payload = binary.AppendVarint(payload, claims.NotAfter.Unix())
+	tag := make([]byte, tagSize)
+	copy(tag, claims.CodeTag)
+	payload = append(payload, tag...)
+
+	signed := append(payload, ed25519.Sign(key.private, payload)...)
+	return Prefix + base64.RawURLEncoding.EncodeToString(signed)
+}
+
+// Verify проверяет подпись и окно действия токена на момент now
+func Verify(token string, now time.Time) (*Claims, error) {
+	if !IsToken(token) {
+		return nil, ErrMalformed
+	}
+	raw, err := base64.RawURLEncoding.DecodeString(token[len(Prefix):])
+	if err != nil || len(raw) < 1+keyIDSize+ed25519.SignatureSize {
+		return nil, ErrMalformed
+	}
+
+	payload := raw[:len(raw)-ed25519.SignatureSize]
+	signature := raw[len(payload):]
+	if payload[0] != version {
+		return nil, ErrMalformed
+	}
+
+	keyID := payload[1 : 1+keyIDSize]
+	var key *Key
+	for _, candidate := range Verification {
+		if id, _ := hex.DecodeString(candidate.ID); bytes.Equal(id, keyID) {
+			key = candidate
+			break
+		}
+	}
+	if key == nil {
+		return nil, ErrUnknownKey
+	}
+	if !ed25519.Verify(key.Public, payload, signature) {
+		return nil, ErrSignature
+	}
+
+	r := bytes.NewReader(payload[1+keyIDSize:])
+	ticketID, err1 := binary.ReadUvarint(r)
+	eventID, err2 := binary.ReadUvarint(r)
+	notBefore, err3 := binary.ReadVarint(r)
+	notAfter, err4 := binary.ReadVarint(r)
+	if err := errors.Join(err1, err2, err3, err4); err != nil || r.Len() != tagSize {
+		return nil, ErrMalformed
+	}
+	tag := make([]byte, tagSize)
+	r.Read(tag)
+
+	claims := &Claims{
+		TicketID:  uint(ticketID),
+		EventID:   uint(eventID),
+		NotBefore: time.Unix(notBefore, 0).UTC(),
+		NotAfter:  time.Unix(notAfter, 0).UTC(),
+		CodeTag:   tag,
+	}
+	if now.Before(claims.NotBefore) {
+		return claims, ErrNotYetValid
+	}
+	if now.After(claims.NotAfter) {
+		return claims, ErrExpired
+	}
+	return claims, nil
+}
+
+// JWK - публичный ключ в формате JSON Web Key (RFC 8037)
+type JWK struct {
+	KeyType   string `json:"kty"`
+	Curve     string `json:"crv"`
+	KeyID     string `json:"kid"`
+	X         string `json:"x"`
+	Use       string `json:"use"`
+	Algorithm string `json:"alg"`
+}
+
+// JWKs возвращает публичные ключи проверки для сканеров
+func JWKs() []JWK {
+	keys := make([]JWK, len(Verification))
+	for i, key := range Verification {
+		keys[i] = JWK{
+			KeyType:   "OKP",
+			Curve:     "Ed25519",
+			KeyID:     key.ID,
+			X:         base64.RawURLEncoding.EncodeToString(key.Public),
+			Use:       "sig",
+			Algorithm: "EdDSA",
+		}
+	}
+	return keys
+}
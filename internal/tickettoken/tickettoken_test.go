@@ -0,0 +1,212 @@
+package tickettoken
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"encoding/base64"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func testKey(t *testing.T, fill byte) *Key {
+	t.Helper()
+	key, err := NewKey(bytes.Repeat([]byte{fill}, ed25519.SeedSize))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return key
+}
+
+// useKeys подменяет ключи пакета на время теста
+func useKeys(t *testing.T, signing *Key, verification ...*Key) {
+	t.Helper()
+	prevSigning, prevVerification := Signing, Verification
+	t.Cleanup(func() { Signing, Verification = prevSigning, prevVerification })
+	Signing, Verification = signing, append([]*Key{signing}, verification...)
+}
+
+func testClaims() Claims {
+	return Claims{
+		TicketID:  12345,
+		EventID:   42,
+		NotBefore: time.Date(2026, 5, 1, 10, 0, 0, 0, time.UTC),
+		NotAfter:  time.Date(2026, 5, 1, 22, 0, 0, 0, time.UTC),
+		CodeTag:   CodeTag("b6d3c1e0-ticket-code"),
+	}
+}
+
+func TestSignVerify(t *testing.T) {
+	useKeys(t, testKey(t, 1))
+	claims := testClaims()
+	token := Sign(claims)
+
+	if !IsToken(token) || strings.ContainsAny(token[len(Prefix):], "+/=") {
+		t.Fatalf("token %q is not a URL-safe EF1 token", token)
+	}
+
+	tests := []struct {
+		name    string
+		now     time.Time
+		wantErr error
+	}{
+		{"inside window", claims.NotBefore.Add(time.Hour), nil},
+		{"at start", claims.NotBefore, nil},
+		{"at end", claims.NotAfter, nil},
+		{"before start", claims.NotBefore.Add(-time.Second), ErrNotYetValid},
+		{"after end", claims.NotAfter.Add(time.Second), ErrExpired},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Verify(token, tt.now)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Verify error = %v, want %v", err, tt.wantErr)
+			}
+			// Данные токена возвращаются и вне окна действия - для сообщения сканеру
+			if got.TicketID != claims.TicketID || got.EventID != claims.EventID ||
+				!got.NotBefore.Equal(claims.NotBefore) || !got.NotAfter.Equal(claims.NotAfter) ||
+				!bytes.Equal(got.CodeTag, claims.CodeTag) {
+				t.Errorf("claims = %+v, want %+v", got, claims)
+			}
+		})
+	}
+}
+
+func TestSignPadsCodeTag(t *testing.T) {
+	useKeys(t, testKey(t, 1))
+	claims := testClaims()
+	claims.CodeTag = []byte{0xAB}
+
+	got, err := Verify(Sign(claims), claims.NotBefore)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []byte{0xAB, 0, 0, 0, 0, 0}; !bytes.Equal(got.CodeTag, want) {
+		t.Errorf("code tag = %x, want %x", got.CodeTag, want)
+	}
+}
+
+func TestVerifyRejectsTampering(t *testing.T) {
+	useKeys(t, testKey(t, 1))
+	claims := testClaims()
+	token := Sign(claims)
+	raw, _ := base64.RawURLEncoding.DecodeString(token[len(Prefix):])
+
+	modified := func(i int, b byte) string {
+		changed := bytes.Clone(raw)
+		changed[i] = b
+		return Prefix + base64.RawURLEncoding.EncodeToString(changed)
+	}
+
+	tests := []struct {
+		name    string
+		token   string
+		wantErr error
+	}{
+		{"ticket id", modified(1+keyIDSize, raw[1+keyIDSize]+1), ErrSignature},
+		{"signature", modified(len(raw)-1, raw[len(raw)-1]^0xFF), ErrSignature},
+		{"key id", modified(1, raw[1]^0xFF), ErrUnknownKey},
+		{"version", modified(0, 2), ErrMalformed},
+		{"no prefix", token[len(Prefix):], ErrMalformed},
+		{"legacy code", "b6d3c1e0-ticket-code", ErrMalformed},
+		{"not base64", Prefix + "!!!", ErrMalformed},
+		{"truncated", Prefix + base64.RawURLEncoding.EncodeToString(raw[:40]), ErrMalformed},
+		{"empty", "", ErrMalformed},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := Verify(tt.token, claims.NotBefore); !errors.Is(err, tt.wantErr) {
+				t.Errorf("Verify error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestKeyRotation(t *testing.T) {
+	previous, current := testKey(t, 1), testKey(t, 2)
+	claims := testClaims()
+
+	useKeys(t, previous)
+	oldToken := Sign(claims)
+
+	// После ротации старые токены принимаются, пока публичный ключ в TICKET_PREVIOUS_KEYS
+	useKeys(t, current, &Key{ID: previous.ID, Public: previous.Public})
+	if _, err := Verify(oldToken, claims.NotBefore); err != nil {
+		t.Errorf("token of the previous key: %v", err)
+	}
+	if _, err := Verify(Sign(claims), claims.NotBefore); err != nil {
+		t.Errorf("token of the current key: %v", err)
+	}
+
+	useKeys(t, current)
+	if _, err := Verify(oldToken, claims.NotBefore); !errors.Is(err, ErrUnknownKey) {
+		t.Errorf("token of a dropped key: error = %v, want ErrUnknownKey", err)
+	}
+}
+
+func TestNewKey(t *testing.T) {
+	if _, err := NewKey(make([]byte, 16)); !errors.Is(err, errInvalidSecret) {
+		t.Errorf("NewKey(16 bytes) error = %v, want errInvalidSecret", err)
+	}
+
+	a, b := testKey(t, 1), testKey(t, 1)
+	if a.ID != b.ID || len(a.ID) != 2*keyIDSize {
+		t.Errorf("key IDs %q and %q of the same seed differ", a.ID, b.ID)
+	}
+	if testKey(t, 2).ID == a.ID {
+		t.Error("different seeds give the same key ID")
+	}
+}
+
+func TestSetup(t *testing.T) {
+	useKeys(t, nil)
+	current, previous := testKey(t, 2), testKey(t, 1)
+
+	t.Setenv("TICKET_SIGNING_KEY", base64.StdEncoding.EncodeToString(bytes.Repeat([]byte{2}, ed25519.SeedSize)))
+	t.Setenv("TICKET_PREVIOUS_KEYS", " "+base64.StdEncoding.EncodeToString(previous.Public)+", ")
+	Setup()
+
+	if Signing.ID != current.ID {
+		t.Errorf("signing key = %s, want %s", Signing.ID, current.ID)
+	}
+	if len(Verification) != 2 || Verification[0] != Signing || Verification[1].ID != previous.ID {
+		t.Fatalf("verification keys = %v", Verification)
+	}
+
+	jwks := JWKs()
+	for i, jwk := range jwks {
+		x, err := base64.RawURLEncoding.DecodeString(jwk.X)
+		if err != nil || !bytes.Equal(x, Verification[i].Public) || jwk.KeyID != Verification[i].ID {
+			t.Errorf("JWK %d = %+v", i, jwk)
+		}
+		if jwk.KeyType != "OKP" || jwk.Curve != "Ed25519" || jwk.Algorithm != "EdDSA" {
+			t.Errorf("JWK %d = %+v, want OKP Ed25519 EdDSA", i, jwk)
+		}
+	}
+}
+
+func TestSetupDevKey(t *testing.T) {
+	useKeys(t, nil)
+	t.Setenv("TICKET_SIGNING_KEY", "")
+	t.Setenv("TICKET_PREVIOUS_KEYS", "")
+	t.Setenv("TICKET_SIGNING_DEV_KEY", "true")
+
+	t.Setenv("JWT_SECRET", "first")
+	Setup()
+	first := Signing.ID
+
+	t.Setenv("JWT_SECRET", "second")
+	Setup()
+	if Signing.ID == first {
+		t.Error("dev key does not depend on JWT_SECRET")
+	}
+
+	t.Setenv("JWT_SECRET", "first")
+	Setup()
+	if Signing.ID != first {
+		t.Error("dev key is not stable for the same JWT_SECRET")
+	}
+}
@@ -0,0 +1,251 @@
+package wallet
+
+import (
+	"archive/zip"
+	"bytes"
+	"crypto"
+	"crypto/sha1"
+	"crypto/x509"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+	"os"
+	"sort"
+	"time"
+)
+
+// AppleConfig - сертификат Pass Type ID и данные команды для подписи .pkpass
+type AppleConfig struct {
+	PassTypeID       string
+	TeamID           string
+	OrganizationName string
+	Certificate      *x509.Certificate
+	Key              crypto.Signer
+	WWDR             *x509.Certificate // промежуточный сертификат Apple WWDR
+}
+
+// PassField - поле карточки пропуска
+type PassField struct {
+	Key   string `json:"key"`
+	Label string `json:"label,omitempty"`
+	Value string `json:"value"`
+}
+
+// PassStructure - поля карточки типа eventTicket
+type PassStructure struct {
+	HeaderFields    []PassField `json:"headerFields,omitempty"`
+	PrimaryFields   []PassField `json:"primaryFields,omitempty"`
+	SecondaryFields []PassField `json:"secondaryFields,omitempty"`
+	AuxiliaryFields []PassField `json:"auxiliaryFields,omitempty"`
+	BackFields      []PassField `json:"backFields,omitempty"`
+}
+
+type PassBarcode struct {
+	Format          string `json:"format"`
+	Message         string `json:"message"`
+	MessageEncoding string `json:"messageEncoding"`
+	AltText         string `json:"altText,omitempty"`
+}
+
+type PassLocation struct {
+	Latitude  float64 `json:"latitude"`
+	Longitude float64 `json:"longitude"`
+}
+
+// Pass - содержимое pass.json
+type Pass struct {
+	FormatVersion      int            `json:"formatVersion"`
+	PassTypeIdentifier string         `json:"passTypeIdentifier"`
+	SerialNumber       string         `json:"serialNumber"`
+	TeamIdentifier     string         `json:"teamIdentifier"`
+	OrganizationName   string         `json:"organizationName"`
+	Description        string         `json:"description"`
+	LogoText           string         `json:"logoText,omitempty"`
+	RelevantDate       string         `json:"relevantDate,omitempty"`
+	ExpirationDate     string         `json:"expirationDate,omitempty"`
+	Voided             bool           `json:"voided,omitempty"`
+	Locations          []PassLocation `json:"locations,omitempty"`
+	Barcodes           []PassBarcode  `json:"barcodes"`
+	EventTicket        PassStructure  `json:"eventTicket"`
+	ForegroundColor    string         `json:"foregroundColor,omitempty"`
+	BackgroundColor    string         `json:"backgroundColor,omitempty"`
+	LabelColor         string         `json:"labelColor,omitempty"`
+}
+
+// NewQRBarcode возвращает QR-код пропуска с сообщением message
+func NewQRBarcode(message, altText string) PassBarcode {
+	return PassBarcode{Format: "PKBarcodeFormatQR", Message: message, MessageEncoding: "iso-8859-1", AltText: altText}
+}
+
+// BuildPKPass собирает подписанный архив .pkpass: pass.json, изображения, manifest.json
+// с SHA-1 всех файлов и отсоединенную подпись PKCS #7 манифеста. Если images не
+// содержит icon.png, добавляется однотонная иконка.
+func (cfg *AppleConfig) BuildPKPass(pass Pass, images map[string][]byte, now time.Time) ([]byte, error) {
+	pass.FormatVersion = 1
+	pass.PassTypeIdentifier = cfg.PassTypeID
+	pass.TeamIdentifier = cfg.TeamID
+	if pass.OrganizationName == "" {
+		pass.OrganizationName = cfg.OrganizationName
+	}
+
+	passJSON, err := json.MarshalIndent(pass, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+
+	files := map[string][]byte{"pass.json": passJSON}
+	for name, data := range images {
+		files[name] = data
+	}
+	if _, ok := files["icon.png"]; !ok {
+		icon, err := solidPNG(29, color.RGBA{R: 0x1f, G: 0x2a, B: 0x44, A: 0xff})
+		if err != nil {
+			return nil, err
+		}
+		files["icon.png"] = icon
+		files["icon@2x.png"], _ = solidPNG(58, color.RGBA{R: 0x1f, G: 0x2a, B: 0x44, A: 0xff})
+	}
+
+	manifest := make(map[string]string, len(files))
+	for name, data := range files {
+		sum := sha1.Sum(data)
+		manifest[name] = hex.EncodeToString(sum[:])
+	}
+	manifestJSON, err := json.Marshal(manifest)
+	if err != nil {
+		return nil, err
+	}
+
+	var chain []*x509.Certificate
+	if cfg.WWDR != nil {
+		chain = append(chain, cfg.WWDR)
+	}
+	signature, err := signDetached(manifestJSON, cfg.Certificate, cfg.Key, chain, now)
+	if err != nil {
+		return nil, err
+	}
+	files["manifest.json"] = manifestJSON
+	files["signature"] = signature
+
+	names := make([]string, 0, len(files))
+	for name := range files {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for _, name := range names {
+		w, err := zw.Create(name)
+		if err != nil {
+			return nil, err
+		}
+		if _, err := w.Write(files[name]); err != nil {
+			return nil, err
+		}
+	}
+	if err := zw.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func solidPNG(size int, c color.Color) ([]byte, error) {
+	img := image.NewRGBA(image.Rect(0, 0, size, size))
+	for y := 0; y < size; y++ {
+		for x := 0; x < size; x++ {
+			img.Set(x, y, c)
+		}
+	}
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// loadAppleConfig читает сертификаты из файлов PEM, указанных в переменных окружения.
+// Возвращает nil без ошибки, если Apple Wallet не настроен.
+func loadAppleConfig() (*AppleConfig, error) {
+	certPath := os.Getenv("APPLE_PASS_CERT")
+	keyPath := os.Getenv("APPLE_PASS_KEY")
+	if certPath == "" && keyPath == "" {
+		return nil, nil
+	}
+
+	cfg := &AppleConfig{
+		PassTypeID:       os.Getenv("APPLE_PASS_TYPE_ID"),
+		TeamID:           os.Getenv("APPLE_TEAM_ID"),
+		OrganizationName: os.Getenv("APPLE_ORGANIZATION_NAME"),
+	}
+	if cfg.PassTypeID == "" || cfg.TeamID == "" {
+		return nil, errors.New("APPLE_PASS_TYPE_ID and APPLE_TEAM_ID are required")
+	}
+	if cfg.OrganizationName == "" {
+		cfg.OrganizationName = "EventFlow"
+	}
+
+	var err error
+	if cfg.Certificate, err = readCertificate(certPath); err != nil {
+		return nil, fmt.Errorf("APPLE_PASS_CERT: %w", err)
+	}
+	if cfg.Key, err = readPrivateKey(keyPath); err != nil {
+		return nil, fmt.Errorf("APPLE_PASS_KEY: %w", err)
+	}
+	if wwdrPath := os.Getenv("APPLE_WWDR_CERT"); wwdrPath != "" {
+		if cfg.WWDR, err = readCertificate(wwdrPath); err != nil {
+			return nil, fmt.Errorf("APPLE_WWDR_CERT: %w", err)
+		}
+	}
+	return cfg, nil
+}
+
+func readPEMBlock(path string) (*pem.Block, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, errors.New("no PEM data found")
+	}
+	return block, nil
+}
+
+func readCertificate(path string) (*x509.Certificate, error) {
+	block, err := readPEMBlock(path)
+	if err != nil {
+		return nil, err
+	}
+	return x509.ParseCertificate(block.Bytes)
+}
+
+func readPrivateKey(path string) (crypto.Signer, error) {
+	block, err := readPEMBlock(path)
+	if err != nil {
+		return nil, err
+	}
+	return parsePrivateKey(block.Bytes)
+}
+
+// parsePrivateKey разбирает ключ в форматах PKCS #8, PKCS #1 (RSA) или SEC 1 (EC)
+func parsePrivateKey(der []byte) (crypto.Signer, error) {
+	if key, err := x509.ParsePKCS8PrivateKey(der); err == nil {
+		if signer, ok := key.(crypto.Signer); ok {
+			return signer, nil
+		}
+		return nil, errUnsupportedKey
+	}
+	if key, err := x509.ParsePKCS1PrivateKey(der); err == nil {
+		return key, nil
+	}
+	if key, err := x509.ParseECPrivateKey(der); err == nil {
+		return key, nil
+	}
+	return nil, errors.New("unsupported private key format")
+}
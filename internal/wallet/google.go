@@ -0,0 +1,179 @@
+package wallet
+
+import (
+	"crypto/rsa"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// SaveURLPrefix - адрес, по которому открывается ссылка "Добавить в Google Кошелек"
+const SaveURLPrefix = "https://pay.google.com/gp/v/save/"
+
+// GoogleConfig - сервисный аккаунт эмитента Google Wallet
+type GoogleConfig struct {
+	IssuerID    string
+	ClientEmail string
+	Key         *rsa.PrivateKey
+	Origins     []string // домены, с которых разрешено встраивать кнопку сохранения
+}
+
+type LocalizedString struct {
+	DefaultValue TranslatedString `json:"defaultValue"`
+}
+
+type TranslatedString struct {
+	Language string `json:"language"`
+	Value    string `json:"value"`
+}
+
+// NewLocalizedString возвращает строку на языке по умолчанию
+func NewLocalizedString(value string) LocalizedString {
+	return LocalizedString{DefaultValue: TranslatedString{Language: "ru", Value: value}}
+}
+
+type EventDateTime struct {
+	Start string `json:"start,omitempty"`
+	End   string `json:"end,omitempty"`
+}
+
+type EventVenue struct {
+	Name    LocalizedString `json:"name"`
+	Address LocalizedString `json:"address"`
+}
+
+// EventTicketClass - класс билетов Google Wallet, общий для всех билетов события
+type EventTicketClass struct {
+	ID                 string          `json:"id"`
+	IssuerName         string          `json:"issuerName"`
+	EventName          LocalizedString `json:"eventName"`
+	EventID            string          `json:"eventId,omitempty"`
+	DateTime           *EventDateTime  `json:"dateTime,omitempty"`
+	Venue              *EventVenue     `json:"venue,omitempty"`
+	ReviewStatus       string          `json:"reviewStatus"`
+	HexBackgroundColor string          `json:"hexBackgroundColor,omitempty"`
+}
+
+type WalletBarcode struct {
+	Type          string `json:"type"`
+	Value         string `json:"value"`
+	AlternateText string `json:"alternateText,omitempty"`
+}
+
+type TimeInterval struct {
+	Start *WalletDateTime `json:"start,omitempty"`
+	End   *WalletDateTime `json:"end,omitempty"`
+}
+
+type WalletDateTime struct {
+	Date string `json:"date"`
+}
+
+type SeatInfo struct {
+	Section *LocalizedString `json:"section,omitempty"`
+	Row     *LocalizedString `json:"row,omitempty"`
+	Seat    *LocalizedString `json:"seat,omitempty"`
+}
+
+// EventTicketObject - билет конкретного участника
+type EventTicketObject struct {
+	ID                string        `json:"id"`
+	ClassID           string        `json:"classId"`
+	State             string        `json:"state"` // "ACTIVE", "COMPLETED", "EXPIRED" или "INACTIVE"
+	TicketHolderName  string        `json:"ticketHolderName,omitempty"`
+	TicketNumber      string        `json:"ticketNumber,omitempty"`
+	Barcode           WalletBarcode `json:"barcode"`
+	ValidTimeInterval *TimeInterval `json:"validTimeInterval,omitempty"`
+	SeatInfo          *SeatInfo     `json:"seatInfo,omitempty"`
+}
+
+// NewQRCodeBarcode возвращает QR-код билета Google Wallet
+func NewQRCodeBarcode(value, alternateText string) WalletBarcode {
+	return WalletBarcode{Type: "QR_CODE", Value: value, AlternateText: alternateText}
+}
+
+// ObjectID формирует идентификатор класса или объекта в пространстве эмитента.
+// Google допускает в суффиксе только буквы, цифры, ".", "_" и "-".
+func (cfg *GoogleConfig) ObjectID(suffix string) string {
+	return cfg.IssuerID + "." + suffix
+}
+
+// SaveJWT подписывает JWT "savetowallet" с классом и объектом билета. Класс создается
+// Google при первом сохранении, если его еще нет.
+func (cfg *GoogleConfig) SaveJWT(class EventTicketClass, object EventTicketObject, now time.Time) (string, error) {
+	claims := jwt.MapClaims{
+		"iss": cfg.ClientEmail,
+		"aud": "google",
+		"typ": "savetowallet",
+		"iat": now.Unix(),
+		"payload": map[string]interface{}{
+			"eventTicketClasses": []EventTicketClass{class},
+			"eventTicketObjects": []EventTicketObject{object},
+		},
+	}
+	if len(cfg.Origins) > 0 {
+		claims["origins"] = cfg.Origins
+	}
+
+	token := jwt.NewWithClaims(jwt.SigningMethodRS256, claims)
+	return token.SignedString(cfg.Key)
+}
+
+// serviceAccount - поля JSON-ключа сервисного аккаунта Google, которые нужны для подписи
+type serviceAccount struct {
+	ClientEmail string `json:"client_email"`
+	PrivateKey  string `json:"private_key"`
+}
+
+// loadGoogleConfig читает ключ сервисного аккаунта из файла, указанного в переменной
+// окружения. Возвращает nil без ошибки, если Google Wallet не настроен.
+func loadGoogleConfig() (*GoogleConfig, error) {
+	credentialsPath := os.Getenv("GOOGLE_WALLET_CREDENTIALS")
+	if credentialsPath == "" {
+		return nil, nil
+	}
+
+	cfg := &GoogleConfig{IssuerID: os.Getenv("GOOGLE_WALLET_ISSUER_ID")}
+	if cfg.IssuerID == "" {
+		return nil, errors.New("GOOGLE_WALLET_ISSUER_ID is required")
+	}
+	for _, origin := range strings.Split(os.Getenv("GOOGLE_WALLET_ORIGINS"), ",") {
+		if origin = strings.TrimSpace(origin); origin != "" {
+			cfg.Origins = append(cfg.Origins, origin)
+		}
+	}
+
+	data, err := os.ReadFile(credentialsPath)
+	if err != nil {
+		return nil, fmt.Errorf("GOOGLE_WALLET_CREDENTIALS: %w", err)
+	}
+	var account serviceAccount
+	if err := json.Unmarshal(data, &account); err != nil {
+		return nil, fmt.Errorf("GOOGLE_WALLET_CREDENTIALS: %w", err)
+	}
+	if account.ClientEmail == "" || account.PrivateKey == "" {
+		return nil, errors.New("GOOGLE_WALLET_CREDENTIALS: client_email and private_key are required")
+	}
+	cfg.ClientEmail = account.ClientEmail
+
+	block, _ := pem.Decode([]byte(account.PrivateKey))
+	if block == nil {
+		return nil, errors.New("GOOGLE_WALLET_CREDENTIALS: private_key is not PEM-encoded")
+	}
+	key, err := parsePrivateKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("GOOGLE_WALLET_CREDENTIALS: %w", err)
+	}
+	rsaKey, ok := key.(*rsa.PrivateKey)
+	if !ok {
+		return nil, errors.New("GOOGLE_WALLET_CREDENTIALS: private_key must be an RSA key")
+	}
+	cfg.Key = rsaKey
+	return cfg, nil
+}
@@ -0,0 +1,30 @@
+// Package wallet выпускает билеты для мобильных кошельков: подписанные архивы .pkpass
+// для Apple Wallet и JWT-ссылки "Добавить в Google Кошелек". Оба провайдера необязательны
+// и включаются переменными окружения с сертификатами и ключами.
+package wallet
+
+import "log"
+
+var (
+	Apple  *AppleConfig  // nil, если Apple Wallet не настроен
+	Google *GoogleConfig // nil, если Google Wallet не настроен
+)
+
+// Setup загружает настройки провайдеров из переменных окружения. Ошибка в настройках
+// провайдера останавливает запуск, отсутствие настроек - нет.
+func Setup() {
+	var err error
+	if Apple, err = loadAppleConfig(); err != nil {
+		log.Fatalf("Apple Wallet: %v", err)
+	}
+	if Google, err = loadGoogleConfig(); err != nil {
+		log.Fatalf("Google Wallet: %v", err)
+	}
+
+	if Apple == nil {
+		log.Printf("⚠️  APPLE_PASS_CERT is not set, Apple Wallet passes are disabled")
+	}
+	if Google == nil {
+		log.Printf("⚠️  GOOGLE_WALLET_CREDENTIALS is not set, Google Wallet passes are disabled")
+	}
+}
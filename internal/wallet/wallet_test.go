@@ -0,0 +1,389 @@
+package wallet
+
+import (
+	"archive/zip"
+	"bytes"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+var testNow = time.Date(2026, 5, 1, 12, 0, 0, 0, time.UTC)
+
+// testPKI - одноразовый удостоверяющий центр (в роли Apple WWDR) и выпущенный им
+// сертификат Pass Type ID
+type testPKI struct {
+	ca      *x509.Certificate
+	leaf    *x509.Certificate
+	leafKey crypto.Signer
+}
+
+func newTestPKI(t *testing.T, leafKey crypto.Signer) *testPKI {
+	t.Helper()
+
+	caKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	caTemplate := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "Test WWDR"},
+		NotBefore:             testNow.Add(-time.Hour),
+		NotAfter:              testNow.Add(time.Hour),
+		KeyUsage:              x509.KeyUsageCertSign,
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+	caDER, err := x509.CreateCertificate(rand.Reader, caTemplate, caTemplate, caKey.Public(), caKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ca, err := x509.ParseCertificate(caDER)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	leafTemplate := &x509.Certificate{
+		SerialNumber: big.NewInt(42),
+		Subject:      pkix.Name{CommonName: "Pass Type ID: pass.test.eventflow"},
+		NotBefore:    testNow.Add(-time.Hour),
+		NotAfter:     testNow.Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageAny},
+	}
+	leafDER, err := x509.CreateCertificate(rand.Reader, leafTemplate, ca, leafKey.Public(), caKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	leaf, err := x509.ParseCertificate(leafDER)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return &testPKI{ca: ca, leaf: leaf, leafKey: leafKey}
+}
+
+func testRSAKey(t *testing.T) *rsa.PrivateKey {
+	t.Helper()
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return key
+}
+
+func unzip(t *testing.T, data []byte) map[string][]byte {
+	t.Helper()
+	zr, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		t.Fatalf("pkpass is not a zip archive: %v", err)
+	}
+	files := make(map[string][]byte, len(zr.File))
+	for _, f := range zr.File {
+		rc, err := f.Open()
+		if err != nil {
+			t.Fatal(err)
+		}
+		files[f.Name], err = io.ReadAll(rc)
+		rc.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	return files
+}
+
+// verifyDetached проверяет отсоединенную подпись PKCS #7 содержимого content:
+// цепочку сертификатов до roots, дайджест в подписанных атрибутах и саму подпись
+func verifyDetached(signature, content []byte, roots *x509.CertPool) error {
+	var info contentInfo
+	if rest, err := asn1.Unmarshal(signature, &info); err != nil || len(rest) > 0 {
+		return fmt.Errorf("signature is not a ContentInfo: %v", err)
+	}
+	if !info.ContentType.Equal(oidSignedData) {
+		return fmt.Errorf("content type = %v, want signedData", info.ContentType)
+	}
+	var data signedData
+	if _, err := asn1.Unmarshal(info.Content.Bytes, &data); err != nil {
+		return fmt.Errorf("signature has no SignedData: %v", err)
+	}
+	if len(data.SignerInfos) != 1 {
+		return fmt.Errorf("signer infos = %d, want 1", len(data.SignerInfos))
+	}
+
+	certs, err := x509.ParseCertificates(data.Certificates.Bytes)
+	if err != nil || len(certs) == 0 {
+		return fmt.Errorf("signature certificates: %v", err)
+	}
+	signer := data.SignerInfos[0]
+	if !bytes.Equal(signer.SID.Issuer.FullBytes, certs[0].RawIssuer) || signer.SID.SerialNumber.Cmp(certs[0].SerialNumber) != 0 {
+		return errors.New("signer identifier does not match the first certificate")
+	}
+	intermediates := x509.NewCertPool()
+	for _, cert := range certs[1:] {
+		intermediates.AddCert(cert)
+	}
+	_, err = certs[0].Verify(x509.VerifyOptions{
+		Roots:         roots,
+		Intermediates: intermediates,
+		CurrentTime:   testNow,
+		KeyUsages:     []x509.ExtKeyUsage{x509.ExtKeyUsageAny},
+	})
+	if err != nil {
+		return fmt.Errorf("certificate chain: %v", err)
+	}
+
+	var digest []byte
+	rest := signer.SignedAttributes.Bytes
+	for len(rest) > 0 {
+		var attr attribute
+		if rest, err = asn1.Unmarshal(rest, &attr); err != nil {
+			return fmt.Errorf("signed attribute: %v", err)
+		}
+		if attr.Type.Equal(oidAttributeMessageDigest) {
+			if _, err := asn1.Unmarshal(attr.Values.Bytes, &digest); err != nil {
+				return fmt.Errorf("message digest attribute: %v", err)
+			}
+		}
+	}
+	contentDigest := sha256.Sum256(content)
+	if !bytes.Equal(digest, contentDigest[:]) {
+		return errors.New("message digest attribute does not match the content")
+	}
+
+	signedSet, err := asn1.Marshal(asn1.RawValue{Class: asn1.ClassUniversal, Tag: asn1.TagSet, IsCompound: true, Bytes: signer.SignedAttributes.Bytes})
+	if err != nil {
+		return err
+	}
+	attributesDigest := sha256.Sum256(signedSet)
+	switch pub := certs[0].PublicKey.(type) {
+	case *rsa.PublicKey:
+		return rsa.VerifyPKCS1v15(pub, crypto.SHA256, attributesDigest[:], signer.Signature)
+	case *ecdsa.PublicKey:
+		if !ecdsa.VerifyASN1(pub, attributesDigest[:], signer.Signature) {
+			return errors.New("ecdsa signature does not verify")
+		}
+		return nil
+	}
+	return fmt.Errorf("unexpected public key %T", certs[0].PublicKey)
+}
+
+func TestBuildPKPass(t *testing.T) {
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name   string
+		key    crypto.Signer
+		images map[string][]byte
+	}{
+		{name: "rsa", key: testRSAKey(t)},
+		{name: "ecdsa", key: ecKey},
+		{name: "custom icon", key: ecKey, images: map[string][]byte{"icon.png": []byte("icon"), "logo.png": []byte("logo")}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pki := newTestPKI(t, tt.key)
+			cfg := &AppleConfig{
+				PassTypeID:       "pass.test.eventflow",
+				TeamID:           "TEAM123456",
+				OrganizationName: "EventFlow",
+				Certificate:      pki.leaf,
+				Key:              pki.leafKey,
+				WWDR:             pki.ca,
+			}
+			pass := Pass{
+				SerialNumber: "ticket-1",
+				Description:  "Ticket",
+				Barcodes:     []PassBarcode{NewQRBarcode("EF1.token", "1")},
+			}
+
+			data, err := cfg.BuildPKPass(pass, tt.images, testNow)
+			if err != nil {
+				t.Fatalf("BuildPKPass: %v", err)
+			}
+			files := unzip(t, data)
+
+			var manifest map[string]string
+			if err := json.Unmarshal(files["manifest.json"], &manifest); err != nil {
+				t.Fatalf("manifest.json: %v", err)
+			}
+			for name, content := range files {
+				if name == "manifest.json" || name == "signature" {
+					continue
+				}
+				sum := sha1.Sum(content)
+				if manifest[name] != hex.EncodeToString(sum[:]) {
+					t.Errorf("manifest SHA-1 of %s = %q, want %x", name, manifest[name], sum)
+				}
+			}
+			if len(manifest) != len(files)-2 {
+				t.Errorf("manifest lists %d files, archive has %d", len(manifest), len(files)-2)
+			}
+			for name, content := range tt.images {
+				if !bytes.Equal(files[name], content) {
+					t.Errorf("image %s was not stored as is", name)
+				}
+			}
+			if _, ok := files["icon.png"]; !ok {
+				t.Error("pkpass has no icon.png")
+			}
+
+			var stored Pass
+			if err := json.Unmarshal(files["pass.json"], &stored); err != nil {
+				t.Fatalf("pass.json: %v", err)
+			}
+			if stored.FormatVersion != 1 || stored.PassTypeIdentifier != cfg.PassTypeID || stored.TeamIdentifier != cfg.TeamID {
+				t.Errorf("pass.json identifiers = %d %q %q", stored.FormatVersion, stored.PassTypeIdentifier, stored.TeamIdentifier)
+			}
+
+			roots := x509.NewCertPool()
+			roots.AddCert(pki.ca)
+			if err := verifyDetached(files["signature"], files["manifest.json"], roots); err != nil {
+				t.Fatalf("signature: %v", err)
+			}
+
+			tampered := bytes.Replace(files["manifest.json"], []byte(manifest["pass.json"]), bytes.Repeat([]byte("0"), 40), 1)
+			if err := verifyDetached(files["signature"], tampered, roots); err == nil {
+				t.Error("signature verifies a tampered manifest")
+			}
+			if err := verifyDetached(files["signature"], files["manifest.json"], x509.NewCertPool()); err == nil {
+				t.Error("signature verifies without the issuing CA")
+			}
+		})
+	}
+}
+
+func TestSaveJWT(t *testing.T) {
+	key := testRSAKey(t)
+	cfg := &GoogleConfig{
+		IssuerID:    "3388000000012345678",
+		ClientEmail: "wallet@test.iam.gserviceaccount.com",
+		Key:         key,
+		Origins:     []string{"https://tickets.example.com"},
+	}
+	class := EventTicketClass{ID: cfg.ObjectID("event-1"), IssuerName: "EventFlow", EventName: NewLocalizedString("Concert"), ReviewStatus: "UNDER_REVIEW"}
+	object := EventTicketObject{ID: cfg.ObjectID("ticket-1"), ClassID: class.ID, State: "ACTIVE", Barcode: NewQRCodeBarcode("EF1.token", "1")}
+
+	signed, err := cfg.SaveJWT(class, object, testNow)
+	if err != nil {
+		t.Fatalf("SaveJWT: %v", err)
+	}
+
+	claims := jwt.MapClaims{}
+	_, err = jwt.ParseWithClaims(signed, claims, func(token *jwt.Token) (interface{}, error) {
+		return &key.PublicKey, nil
+	}, jwt.WithValidMethods([]string{"RS256"}), jwt.WithAudience("google"))
+	if err != nil {
+		t.Fatalf("JWT does not verify with the service account key: %v", err)
+	}
+
+	if claims["iss"] != cfg.ClientEmail || claims["typ"] != "savetowallet" {
+		t.Errorf("claims iss=%v typ=%v", claims["iss"], claims["typ"])
+	}
+	if origins, _ := claims["origins"].([]interface{}); len(origins) != 1 || origins[0] != "https://tickets.example.com" {
+		t.Errorf("origins = %v", claims["origins"])
+	}
+
+	payload, _ := claims["payload"].(map[string]interface{})
+	objects, _ := payload["eventTicketObjects"].([]interface{})
+	if len(objects) != 1 {
+		t.Fatalf("payload objects = %v", payload["eventTicketObjects"])
+	}
+	stored, _ := objects[0].(map[string]interface{})
+	if stored["id"] != "3388000000012345678.ticket-1" || stored["classId"] != "3388000000012345678.event-1" {
+		t.Errorf("object ids = %v %v", stored["id"], stored["classId"])
+	}
+
+	other := testRSAKey(t)
+	_, err = jwt.Parse(signed, func(token *jwt.Token) (interface{}, error) {
+		return &other.PublicKey, nil
+	})
+	if err == nil {
+		t.Error("JWT verifies with a foreign key")
+	}
+}
+
+func TestLoadConfigs(t *testing.T) {
+	dir := t.TempDir()
+	key := testRSAKey(t)
+	pki := newTestPKI(t, key)
+
+	writePEM := func(name, blockType string, der []byte) string {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, pem.EncodeToMemory(&pem.Block{Type: blockType, Bytes: der}), 0o600); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+	pkcs8, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Setenv("APPLE_PASS_CERT", writePEM("pass.pem", "CERTIFICATE", pki.leaf.Raw))
+	t.Setenv("APPLE_PASS_KEY", writePEM("pass.key", "RSA PRIVATE KEY", x509.MarshalPKCS1PrivateKey(key)))
+	t.Setenv("APPLE_WWDR_CERT", writePEM("wwdr.pem", "CERTIFICATE", pki.ca.Raw))
+	t.Setenv("APPLE_PASS_TYPE_ID", "pass.test.eventflow")
+	t.Setenv("APPLE_TEAM_ID", "TEAM123456")
+	t.Setenv("APPLE_ORGANIZATION_NAME", "")
+
+	apple, err := loadAppleConfig()
+	if err != nil {
+		t.Fatalf("loadAppleConfig: %v", err)
+	}
+	if apple == nil || !apple.Certificate.Equal(pki.leaf) || !apple.WWDR.Equal(pki.ca) || apple.OrganizationName != "EventFlow" {
+		t.Fatalf("apple config = %+v", apple)
+	}
+
+	credentials, err := json.Marshal(serviceAccount{
+		ClientEmail: "wallet@test.iam.gserviceaccount.com",
+		PrivateKey:  string(pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: pkcs8})),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	credentialsPath := filepath.Join(dir, "credentials.json")
+	if err := os.WriteFile(credentialsPath, credentials, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("GOOGLE_WALLET_CREDENTIALS", credentialsPath)
+	t.Setenv("GOOGLE_WALLET_ISSUER_ID", "3388000000012345678")
+	t.Setenv("GOOGLE_WALLET_ORIGINS", " https://a.example.com, ,https://b.example.com")
+
+	google, err := loadGoogleConfig()
+	if err != nil {
+		t.Fatalf("loadGoogleConfig: %v", err)
+	}
+	if google == nil || !google.Key.Equal(key) || len(google.Origins) != 2 {
+		t.Fatalf("google config = %+v", google)
+	}
+
+	t.Setenv("GOOGLE_WALLET_ISSUER_ID", "")
+	if _, err := loadGoogleConfig(); err == nil {
+		t.Error("loadGoogleConfig without an issuer id succeeded")
+	}
+}
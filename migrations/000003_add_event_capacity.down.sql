@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS idx_event_registrations_waitlist;
+ALTER TABLE events DROP COLUMN IF EXISTS capacity;
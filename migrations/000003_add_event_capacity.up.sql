@@ -0,0 +1,6 @@
+ALTER TABLE events ADD COLUMN IF NOT EXISTS capacity INTEGER CHECK (capacity IS NULL OR capacity >= 0);
+
+-- Лист ожидания обрабатывается в порядке регистрации (FIFO)
+CREATE INDEX IF NOT EXISTS idx_event_registrations_waitlist
+    ON event_registrations(event_id, registered_at, id)
+    WHERE status = 'waitlisted';
@@ -0,0 +1,5 @@
+DROP INDEX IF EXISTS idx_events_series_recurrence;
+ALTER TABLE events DROP COLUMN IF EXISTS is_exception;
+ALTER TABLE events DROP COLUMN IF EXISTS recurrence_id;
+ALTER TABLE events DROP COLUMN IF EXISTS series_id;
+DROP TABLE IF EXISTS event_series;
@@ -0,0 +1,5 @@
+DROP INDEX IF EXISTS idx_venues_coordinates;
+DROP INDEX IF EXISTS idx_events_venue_id;
+ALTER TABLE event_series DROP COLUMN IF EXISTS venue_id;
+ALTER TABLE events DROP COLUMN IF EXISTS venue_id;
+DROP TABLE IF EXISTS venues;
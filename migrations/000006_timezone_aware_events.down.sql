@@ -0,0 +1,13 @@
+ALTER TABLE event_series DROP CONSTRAINT IF EXISTS chk_event_series_time_range;
+ALTER TABLE events DROP CONSTRAINT IF EXISTS chk_events_time_range;
+
+ALTER TABLE event_series
+    ALTER COLUMN start_time TYPE TIMESTAMP USING start_time AT TIME ZONE 'UTC',
+    ALTER COLUMN end_time TYPE TIMESTAMP USING end_time AT TIME ZONE 'UTC';
+ALTER TABLE event_series DROP COLUMN IF EXISTS timezone;
+
+ALTER TABLE events
+    ALTER COLUMN start_time TYPE TIMESTAMP USING start_time AT TIME ZONE 'UTC',
+    ALTER COLUMN end_time TYPE TIMESTAMP USING end_time AT TIME ZONE 'UTC',
+    ALTER COLUMN recurrence_id TYPE TIMESTAMP USING recurrence_id AT TIME ZONE 'UTC';
+ALTER TABLE events DROP COLUMN IF EXISTS timezone;
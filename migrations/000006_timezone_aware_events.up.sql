@@ -0,0 +1,19 @@
+-- Время событий хранится как момент времени (UTC), местное время вычисляется по часовому поясу
+ALTER TABLE events ADD COLUMN IF NOT EXISTS timezone VARCHAR(64) NOT NULL DEFAULT 'UTC';
+ALTER TABLE events
+    ALTER COLUMN start_time TYPE TIMESTAMPTZ USING start_time AT TIME ZONE 'UTC',
+    ALTER COLUMN end_time TYPE TIMESTAMPTZ USING end_time AT TIME ZONE 'UTC',
+    ALTER COLUMN recurrence_id TYPE TIMESTAMPTZ USING recurrence_id AT TIME ZONE 'UTC';
+
+UPDATE events SET timezone = venues.timezone
+FROM venues
+WHERE venues.id = events.venue_id AND events.timezone = 'UTC';
+
+ALTER TABLE event_series ADD COLUMN IF NOT EXISTS timezone VARCHAR(64) NOT NULL DEFAULT 'UTC';
+ALTER TABLE event_series
+    ALTER COLUMN start_time TYPE TIMESTAMPTZ USING start_time AT TIME ZONE 'UTC',
+    ALTER COLUMN end_time TYPE TIMESTAMPTZ USING end_time AT TIME ZONE 'UTC';
+
+-- Существующие записи не проверяются, ограничение действует для новых и изменяемых строк
+ALTER TABLE events ADD CONSTRAINT chk_events_time_range CHECK (end_time >= start_time) NOT VALID;
+ALTER TABLE event_series ADD CONSTRAINT chk_event_series_time_range CHECK (end_time >= start_time) NOT VALID;
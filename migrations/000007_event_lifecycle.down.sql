@@ -0,0 +1,5 @@
+DROP INDEX IF EXISTS idx_events_status;
+ALTER TABLE events DROP COLUMN IF EXISTS status_changed_at;
+ALTER TABLE events DROP COLUMN IF EXISTS status_reason;
+ALTER TABLE event_series DROP CONSTRAINT IF EXISTS chk_event_series_status;
+ALTER TABLE events DROP CONSTRAINT IF EXISTS chk_events_status;
@@ -0,0 +1,15 @@
+-- Приводим произвольные статусы к жизненному циклу события
+UPDATE events SET status = 'canceled' WHERE status IN ('cancelled', 'cancel');
+UPDATE events SET status = 'scheduled'
+WHERE status NOT IN ('draft', 'scheduled', 'live', 'completed', 'postponed', 'canceled');
+UPDATE event_series SET status = 'scheduled' WHERE status NOT IN ('draft', 'scheduled');
+
+ALTER TABLE events ADD CONSTRAINT chk_events_status
+    CHECK (status IN ('draft', 'scheduled', 'live', 'completed', 'postponed', 'canceled'));
+ALTER TABLE event_series ADD CONSTRAINT chk_event_series_status
+    CHECK (status IN ('draft', 'scheduled'));
+
+ALTER TABLE events ADD COLUMN IF NOT EXISTS status_reason TEXT NOT NULL DEFAULT '';
+ALTER TABLE events ADD COLUMN IF NOT EXISTS status_changed_at TIMESTAMPTZ;
+
+CREATE INDEX IF NOT EXISTS idx_events_status ON events(status);
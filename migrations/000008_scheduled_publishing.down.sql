@@ -0,0 +1,5 @@
+DROP TABLE IF EXISTS event_publications;
+DROP INDEX IF EXISTS idx_events_unpublish_at;
+DROP INDEX IF EXISTS idx_events_publish_at;
+ALTER TABLE events DROP COLUMN IF EXISTS unpublish_at;
+ALTER TABLE events DROP COLUMN IF EXISTS publish_at;
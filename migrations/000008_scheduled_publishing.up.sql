@@ -0,0 +1,18 @@
+ALTER TABLE events ADD COLUMN IF NOT EXISTS publish_at TIMESTAMPTZ;
+ALTER TABLE events ADD COLUMN IF NOT EXISTS unpublish_at TIMESTAMPTZ;
+
+-- Частичные индексы для выборки планировщиком
+CREATE INDEX IF NOT EXISTS idx_events_publish_at ON events(publish_at) WHERE publish_at IS NOT NULL;
+CREATE INDEX IF NOT EXISTS idx_events_unpublish_at ON events(unpublish_at) WHERE unpublish_at IS NOT NULL;
+
+CREATE TABLE IF NOT EXISTS event_publications (
+    id SERIAL PRIMARY KEY,
+    event_id INTEGER NOT NULL REFERENCES events(id) ON DELETE CASCADE,
+    from_status VARCHAR(50) NOT NULL,
+    to_status VARCHAR(50) NOT NULL,
+    source VARCHAR(50) NOT NULL CHECK (source IN ('manual', 'scheduler')),
+    organizer_id INTEGER REFERENCES organizers(id) ON DELETE SET NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_event_publications_event_id ON event_publications(event_id);
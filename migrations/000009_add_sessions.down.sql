@@ -0,0 +1,6 @@
+DROP TABLE IF EXISTS session_attendances;
+DROP TABLE IF EXISTS session_registrations;
+DROP TABLE IF EXISTS session_speakers;
+DROP TABLE IF EXISTS sessions;
+DROP TABLE IF EXISTS speakers;
+DROP TABLE IF EXISTS tracks;
@@ -0,0 +1,16 @@
+DROP INDEX IF EXISTS idx_tickets_tier_id;
+
+ALTER TABLE tickets
+    DROP COLUMN IF EXISTS currency,
+    DROP COLUMN IF EXISTS tier_id;
+
+UPDATE tickets
+SET ticket_type = CASE WHEN price > 0 THEN 'paid' ELSE 'free' END
+WHERE ticket_type NOT IN ('free', 'paid');
+
+ALTER TABLE tickets DROP COLUMN IF EXISTS price;
+
+ALTER TABLE tickets DROP CONSTRAINT IF EXISTS tickets_ticket_type_check;
+ALTER TABLE tickets ADD CONSTRAINT tickets_ticket_type_check CHECK (ticket_type IN ('free', 'paid'));
+
+DROP TABLE IF EXISTS ticket_tiers;
@@ -0,0 +1,30 @@
+CREATE TABLE IF NOT EXISTS ticket_tiers (
+    id SERIAL PRIMARY KEY,
+    event_id INTEGER NOT NULL REFERENCES events(id) ON DELETE CASCADE,
+    name VARCHAR(255) NOT NULL,
+    price BIGINT NOT NULL DEFAULT 0 CHECK (price >= 0),
+    currency CHAR(3) NOT NULL,
+    quantity INTEGER NOT NULL CHECK (quantity >= 0),
+    sold INTEGER NOT NULL DEFAULT 0,
+    sales_start TIMESTAMPTZ,
+    sales_end TIMESTAMPTZ,
+    max_per_order INTEGER CHECK (max_per_order IS NULL OR max_per_order > 0),
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    -- Последний рубеж против перепродажи: остаток не может уйти в минус
+    CONSTRAINT ticket_tiers_inventory_check CHECK (sold >= 0 AND sold <= quantity),
+    CONSTRAINT ticket_tiers_sales_window_check CHECK (sales_end IS NULL OR sales_start IS NULL OR sales_end > sales_start)
+);
+
+CREATE INDEX IF NOT EXISTS idx_ticket_tiers_event_id ON ticket_tiers(event_id);
+
+-- Тип тикета теперь берется из названия категории
+ALTER TABLE tickets DROP CONSTRAINT IF EXISTS tickets_ticket_type_check;
+ALTER TABLE tickets ADD CONSTRAINT tickets_ticket_type_check CHECK (ticket_type <> '');
+
+ALTER TABLE tickets
+    ADD COLUMN IF NOT EXISTS tier_id INTEGER REFERENCES ticket_tiers(id) ON DELETE RESTRICT,
+    ADD COLUMN IF NOT EXISTS price BIGINT NOT NULL DEFAULT 0,
+    ADD COLUMN IF NOT EXISTS currency CHAR(3);
+
+CREATE INDEX IF NOT EXISTS idx_tickets_tier_id ON tickets(tier_id);
@@ -0,0 +1,5 @@
+DROP INDEX IF EXISTS idx_tickets_order_id;
+ALTER TABLE tickets DROP COLUMN IF EXISTS order_id;
+
+DROP TABLE IF EXISTS order_items;
+DROP TABLE IF EXISTS orders;
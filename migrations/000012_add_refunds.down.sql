@@ -0,0 +1,5 @@
+DROP TABLE IF EXISTS refunds;
+
+ALTER TABLE events
+    DROP COLUMN IF EXISTS refund_cutoff_hours,
+    DROP COLUMN IF EXISTS refund_policy;
@@ -0,0 +1,26 @@
+ALTER TABLE events
+    ADD COLUMN IF NOT EXISTS refund_policy VARCHAR(20) NOT NULL DEFAULT 'allowed'
+        CHECK (refund_policy IN ('allowed', 'none')),
+    ADD COLUMN IF NOT EXISTS refund_cutoff_hours INTEGER
+        CHECK (refund_cutoff_hours IS NULL OR refund_cutoff_hours >= 0);
+
+CREATE TABLE IF NOT EXISTS refunds (
+    id SERIAL PRIMARY KEY,
+    ticket_id INTEGER NOT NULL REFERENCES tickets(id) ON DELETE RESTRICT,
+    order_id INTEGER REFERENCES orders(id) ON DELETE SET NULL,
+    event_id INTEGER NOT NULL REFERENCES events(id) ON DELETE CASCADE,
+    amount BIGINT NOT NULL CHECK (amount > 0),
+    currency CHAR(3) NOT NULL,
+    reason TEXT NOT NULL,
+    status VARCHAR(20) NOT NULL CHECK (status IN ('succeeded', 'failed')),
+    provider VARCHAR(50) NOT NULL,
+    provider_refund_id VARCHAR(255),
+    failure_reason VARCHAR(255),
+    organizer_id INTEGER NOT NULL REFERENCES organizers(id) ON DELETE RESTRICT,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Не более одного успешного возврата на тикет
+CREATE UNIQUE INDEX IF NOT EXISTS idx_refunds_ticket_succeeded ON refunds(ticket_id) WHERE status = 'succeeded';
+CREATE INDEX IF NOT EXISTS idx_refunds_ticket_id ON refunds(ticket_id);
+CREATE INDEX IF NOT EXISTS idx_refunds_event_id ON refunds(event_id);
@@ -0,0 +1,37 @@
+CREATE TABLE IF NOT EXISTS promo_codes (
+    id SERIAL PRIMARY KEY,
+    code VARCHAR(64) NOT NULL UNIQUE,
+    discount_type VARCHAR(20) NOT NULL CHECK (discount_type IN ('percentage', 'fixed')),
+    discount_value BIGINT NOT NULL CHECK (discount_value > 0),
+    currency CHAR(3),
+    max_redemptions INTEGER CHECK (max_redemptions IS NULL OR max_redemptions > 0),
+    max_per_participant INTEGER CHECK (max_per_participant IS NULL OR max_per_participant > 0),
+    redeemed INTEGER NOT NULL DEFAULT 0,
+    valid_from TIMESTAMPTZ,
+    valid_until TIMESTAMPTZ,
+    event_ids JSONB,
+    ticket_types JSONB,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    CONSTRAINT promo_codes_percentage_check CHECK (discount_type <> 'percentage' OR discount_value <= 100),
+    CONSTRAINT promo_codes_fixed_currency_check CHECK (discount_type <> 'fixed' OR currency IS NOT NULL),
+    -- Счетчик погашений не может превысить лимит
+    CONSTRAINT promo_codes_redeemed_check CHECK (redeemed >= 0 AND (max_redemptions IS NULL OR redeemed <= max_redemptions))
+);
+
+CREATE TABLE IF NOT EXISTS promo_redemptions (
+    id SERIAL PRIMARY KEY,
+    promo_code_id INTEGER NOT NULL REFERENCES promo_codes(id) ON DELETE CASCADE,
+    participant_id INTEGER NOT NULL REFERENCES participants(id) ON DELETE CASCADE,
+    event_id INTEGER NOT NULL REFERENCES events(id) ON DELETE CASCADE,
+    ticket_id INTEGER NOT NULL REFERENCES tickets(id) ON DELETE CASCADE,
+    discount_amount BIGINT NOT NULL DEFAULT 0 CHECK (discount_amount >= 0),
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+ALTER TABLE tickets
+    ADD COLUMN IF NOT EXISTS promo_code_id INTEGER REFERENCES promo_codes(id) ON DELETE SET NULL,
+    ADD COLUMN IF NOT EXISTS discount_amount BIGINT NOT NULL DEFAULT 0;
+
+CREATE INDEX IF NOT EXISTS idx_promo_redemptions_code_participant ON promo_redemptions(promo_code_id, participant_id);
+CREATE INDEX IF NOT EXISTS idx_promo_redemptions_event_id ON promo_redemptions(event_id);
@@ -0,0 +1,14 @@
+CREATE TABLE IF NOT EXISTS print_templates (
+    id SERIAL PRIMARY KEY,
+    event_id INTEGER REFERENCES events(id) ON DELETE CASCADE,
+    kind VARCHAR(20) NOT NULL CHECK (kind IN ('ticket', 'badge')),
+    width NUMERIC(7, 2) NOT NULL CHECK (width > 0),
+    height NUMERIC(7, 2) NOT NULL CHECK (height > 0),
+    elements JSONB NOT NULL DEFAULT '[]',
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Не больше одного макета каждого вида на событие и одного макета по умолчанию
+CREATE UNIQUE INDEX IF NOT EXISTS idx_print_templates_event_kind ON print_templates(event_id, kind) WHERE event_id IS NOT NULL;
+CREATE UNIQUE INDEX IF NOT EXISTS idx_print_templates_default_kind ON print_templates(kind) WHERE event_id IS NULL;
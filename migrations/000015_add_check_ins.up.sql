@@ -0,0 +1,18 @@
+CREATE TABLE IF NOT EXISTS check_ins (
+    id SERIAL PRIMARY KEY,
+    event_id INTEGER NOT NULL REFERENCES events(id) ON DELETE CASCADE,
+    ticket_id INTEGER NOT NULL REFERENCES tickets(id) ON DELETE CASCADE,
+    participant_id INTEGER NOT NULL REFERENCES participants(id) ON DELETE CASCADE,
+    device_id VARCHAR(64) NOT NULL DEFAULT '',
+    scan_id VARCHAR(64) NOT NULL DEFAULT '',
+    scanned_at TIMESTAMPTZ NOT NULL,
+    source VARCHAR(20) NOT NULL CHECK (source IN ('online', 'offline')),
+    status VARCHAR(20) NOT NULL CHECK (status IN ('accepted', 'conflict')),
+    conflict_with_id INTEGER REFERENCES check_ins(id) ON DELETE SET NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Повторная выгрузка того же сканирования с устройства не создает новую запись
+CREATE UNIQUE INDEX IF NOT EXISTS idx_check_ins_device_scan ON check_ins(device_id, scan_id) WHERE scan_id <> '';
+CREATE INDEX IF NOT EXISTS idx_check_ins_ticket_id ON check_ins(ticket_id, scanned_at);
+CREATE INDEX IF NOT EXISTS idx_check_ins_event_id ON check_ins(event_id, scanned_at);
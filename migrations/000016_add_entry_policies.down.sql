@@ -0,0 +1,10 @@
+ALTER TABLE check_ins
+    DROP COLUMN IF EXISTS direction,
+    DROP COLUMN IF EXISTS operator_id,
+    DROP COLUMN IF EXISTS gate;
+
+ALTER TABLE events
+    DROP CONSTRAINT IF EXISTS events_max_entries_check,
+    DROP CONSTRAINT IF EXISTS events_entry_policy_check,
+    DROP COLUMN IF EXISTS max_entries,
+    DROP COLUMN IF EXISTS entry_policy;
@@ -0,0 +1,14 @@
+ALTER TABLE events
+    ADD COLUMN IF NOT EXISTS entry_policy VARCHAR(20) NOT NULL DEFAULT 'single',
+    ADD COLUMN IF NOT EXISTS max_entries INTEGER;
+
+ALTER TABLE events
+    ADD CONSTRAINT events_entry_policy_check CHECK (entry_policy IN ('single', 'reentry', 'limited')),
+    ADD CONSTRAINT events_max_entries_check CHECK (
+        (max_entries IS NULL OR max_entries > 0) AND (entry_policy <> 'limited' OR max_entries IS NOT NULL)
+    );
+
+ALTER TABLE check_ins
+    ADD COLUMN IF NOT EXISTS gate VARCHAR(64) NOT NULL DEFAULT '',
+    ADD COLUMN IF NOT EXISTS operator_id INTEGER REFERENCES organizers(id) ON DELETE SET NULL,
+    ADD COLUMN IF NOT EXISTS direction VARCHAR(3) NOT NULL DEFAULT 'in' CHECK (direction IN ('in', 'out'));
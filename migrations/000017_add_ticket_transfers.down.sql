@@ -0,0 +1,6 @@
+DROP TABLE IF EXISTS ticket_transfers;
+
+ALTER TABLE events
+    DROP CONSTRAINT IF EXISTS events_transfer_policy_check,
+    DROP COLUMN IF EXISTS transfer_cutoff_hours,
+    DROP COLUMN IF EXISTS transfer_policy;
@@ -0,0 +1,19 @@
+ALTER TABLE events
+    ADD COLUMN IF NOT EXISTS transfer_policy VARCHAR(20) NOT NULL DEFAULT 'allowed',
+    ADD COLUMN IF NOT EXISTS transfer_cutoff_hours INTEGER CHECK (transfer_cutoff_hours IS NULL OR transfer_cutoff_hours >= 0);
+
+ALTER TABLE events
+    ADD CONSTRAINT events_transfer_policy_check CHECK (transfer_policy IN ('allowed', 'none'));
+
+CREATE TABLE IF NOT EXISTS ticket_transfers (
+    id SERIAL PRIMARY KEY,
+    ticket_id INTEGER NOT NULL REFERENCES tickets(id) ON DELETE CASCADE,
+    event_id INTEGER NOT NULL REFERENCES events(id) ON DELETE CASCADE,
+    from_participant_id INTEGER NOT NULL REFERENCES participants(id) ON DELETE CASCADE,
+    to_participant_id INTEGER NOT NULL REFERENCES participants(id) ON DELETE CASCADE,
+    organizer_id INTEGER NOT NULL REFERENCES organizers(id) ON DELETE RESTRICT,
+    reason TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_ticket_transfers_ticket_id ON ticket_transfers(ticket_id);
@@ -0,0 +1,8 @@
+DROP INDEX IF EXISTS idx_tickets_event_seat;
+
+ALTER TABLE tickets
+    DROP COLUMN IF EXISTS seat_id;
+
+DROP TABLE IF EXISTS seat_holds;
+DROP TABLE IF EXISTS seats;
+DROP TABLE IF EXISTS seat_maps;
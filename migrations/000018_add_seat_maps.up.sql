@@ -0,0 +1,46 @@
+CREATE TABLE IF NOT EXISTS seat_maps (
+    id SERIAL PRIMARY KEY,
+    venue_id INTEGER REFERENCES venues(id) ON DELETE CASCADE,
+    event_id INTEGER REFERENCES events(id) ON DELETE CASCADE,
+    name VARCHAR(255) NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    -- Схема принадлежит либо площадке, либо событию
+    CONSTRAINT seat_maps_owner_check CHECK ((venue_id IS NULL) <> (event_id IS NULL))
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_seat_maps_venue_id ON seat_maps(venue_id) WHERE venue_id IS NOT NULL;
+CREATE UNIQUE INDEX IF NOT EXISTS idx_seat_maps_event_id ON seat_maps(event_id) WHERE event_id IS NOT NULL;
+
+CREATE TABLE IF NOT EXISTS seats (
+    id SERIAL PRIMARY KEY,
+    seat_map_id INTEGER NOT NULL REFERENCES seat_maps(id) ON DELETE CASCADE,
+    section VARCHAR(100) NOT NULL,
+    row VARCHAR(20) NOT NULL,
+    number VARCHAR(20) NOT NULL,
+    wheelchair BOOLEAN NOT NULL DEFAULT FALSE,
+    companion BOOLEAN NOT NULL DEFAULT FALSE,
+    limited_view BOOLEAN NOT NULL DEFAULT FALSE,
+    CONSTRAINT seats_position_unique UNIQUE (seat_map_id, section, row, number)
+);
+
+CREATE TABLE IF NOT EXISTS seat_holds (
+    id SERIAL PRIMARY KEY,
+    event_id INTEGER NOT NULL REFERENCES events(id) ON DELETE CASCADE,
+    seat_id INTEGER NOT NULL REFERENCES seats(id) ON DELETE CASCADE,
+    participant_id INTEGER REFERENCES participants(id) ON DELETE SET NULL,
+    hold_token VARCHAR(64) NOT NULL,
+    expires_at TIMESTAMPTZ NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    -- Место удерживается не больше чем одним покупателем
+    CONSTRAINT seat_holds_seat_unique UNIQUE (event_id, seat_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_seat_holds_hold_token ON seat_holds(hold_token);
+
+ALTER TABLE tickets
+    ADD COLUMN IF NOT EXISTS seat_id INTEGER REFERENCES seats(id) ON DELETE RESTRICT;
+
+-- Одно место - не больше одного активного тикета на событие
+CREATE UNIQUE INDEX IF NOT EXISTS idx_tickets_event_seat ON tickets(event_id, seat_id)
+    WHERE seat_id IS NOT NULL AND status = 'active';
@@ -0,0 +1,13 @@
+DROP INDEX IF EXISTS idx_event_registrations_pending;
+
+ALTER TABLE event_registrations
+    DROP COLUMN IF EXISTS review_reason,
+    DROP COLUMN IF EXISTS reviewed_at,
+    DROP COLUMN IF EXISTS reviewed_by,
+    DROP COLUMN IF EXISTS promo_code;
+
+ALTER TABLE event_registrations DROP CONSTRAINT IF EXISTS chk_event_registrations_status;
+
+ALTER TABLE events
+    DROP CONSTRAINT IF EXISTS events_registration_mode_check,
+    DROP COLUMN IF EXISTS registration_mode;
@@ -0,0 +1,36 @@
+ALTER TABLE events
+    ADD COLUMN IF NOT EXISTS registration_mode VARCHAR(20) NOT NULL DEFAULT 'open';
+
+ALTER TABLE events
+    ADD CONSTRAINT events_registration_mode_check CHECK (registration_mode IN ('open', 'approval'));
+
+-- Известные варианты написания приводятся к принятым, остальные статусы
+-- останавливают миграцию: угадывать их значение нельзя
+UPDATE event_registrations SET status = 'canceled' WHERE status IN ('cancelled', 'cancel');
+UPDATE event_registrations SET status = 'no-show' WHERE status IN ('no_show', 'noshow');
+
+DO $$
+DECLARE
+    unknown TEXT;
+BEGIN
+    SELECT string_agg(DISTINCT status, ', ') INTO unknown
+    FROM event_registrations
+    WHERE status NOT IN ('pending', 'registered', 'attended', 'no-show', 'waitlisted', 'rejected', 'canceled');
+
+    IF unknown IS NOT NULL THEN
+        RAISE EXCEPTION 'event_registrations contains unknown statuses: %. Map them to known statuses before migrating', unknown;
+    END IF;
+END $$;
+
+ALTER TABLE event_registrations ADD CONSTRAINT chk_event_registrations_status
+    CHECK (status IN ('pending', 'registered', 'attended', 'no-show', 'waitlisted', 'rejected', 'canceled'));
+
+ALTER TABLE event_registrations
+    ADD COLUMN IF NOT EXISTS promo_code VARCHAR(64) NOT NULL DEFAULT '',
+    ADD COLUMN IF NOT EXISTS reviewed_by INTEGER REFERENCES organizers(id) ON DELETE SET NULL,
+    ADD COLUMN IF NOT EXISTS reviewed_at TIMESTAMP,
+    ADD COLUMN IF NOT EXISTS review_reason TEXT NOT NULL DEFAULT '';
+
+CREATE INDEX IF NOT EXISTS idx_event_registrations_pending
+    ON event_registrations(event_id, registered_at)
+    WHERE status = 'pending';
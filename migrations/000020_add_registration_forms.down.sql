@@ -0,0 +1,3 @@
+ALTER TABLE event_registrations DROP COLUMN IF EXISTS answers;
+
+DROP TABLE IF EXISTS registration_forms;
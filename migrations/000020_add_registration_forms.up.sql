@@ -0,0 +1,10 @@
+CREATE TABLE IF NOT EXISTS registration_forms (
+    id SERIAL PRIMARY KEY,
+    event_id INTEGER NOT NULL UNIQUE REFERENCES events(id) ON DELETE CASCADE,
+    fields JSONB NOT NULL DEFAULT '[]',
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+ALTER TABLE event_registrations
+    ADD COLUMN IF NOT EXISTS answers JSONB NOT NULL DEFAULT '{}';
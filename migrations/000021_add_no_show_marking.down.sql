@@ -0,0 +1,9 @@
+DROP TABLE IF EXISTS no_show_runs;
+
+DROP INDEX IF EXISTS idx_events_attendance_open;
+ALTER TABLE events DROP COLUMN IF EXISTS attendance_closed_at;
+
+UPDATE tickets SET status = 'canceled' WHERE status = 'expired';
+ALTER TABLE tickets DROP CONSTRAINT IF EXISTS tickets_status_check;
+ALTER TABLE tickets
+    ADD CONSTRAINT tickets_status_check CHECK (status IN ('active', 'canceled'));
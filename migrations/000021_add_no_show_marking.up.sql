@@ -0,0 +1,20 @@
+ALTER TABLE tickets DROP CONSTRAINT IF EXISTS tickets_status_check;
+ALTER TABLE tickets
+    ADD CONSTRAINT tickets_status_check CHECK (status IN ('active', 'canceled', 'expired'));
+
+ALTER TABLE events ADD COLUMN IF NOT EXISTS attendance_closed_at TIMESTAMPTZ;
+
+-- Частичный индекс для выборки завершившихся событий задачей отметки неявок
+CREATE INDEX IF NOT EXISTS idx_events_attendance_open ON events(end_time) WHERE attendance_closed_at IS NULL;
+
+CREATE TABLE IF NOT EXISTS no_show_runs (
+    id SERIAL PRIMARY KEY,
+    event_id INTEGER NOT NULL REFERENCES events(id) ON DELETE CASCADE,
+    registrations_marked INTEGER NOT NULL DEFAULT 0,
+    tickets_expired INTEGER NOT NULL DEFAULT 0,
+    grace_seconds INTEGER NOT NULL,
+    host VARCHAR(255) NOT NULL DEFAULT '',
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_no_show_runs_event_id ON no_show_runs(event_id);
@@ -0,0 +1,30 @@
+CREATE TABLE IF NOT EXISTS imports (
+    id SERIAL PRIMARY KEY,
+    file_name VARCHAR(255) NOT NULL DEFAULT '',
+    format VARCHAR(10) NOT NULL CHECK (format IN ('csv', 'xlsx')),
+    event_id INTEGER REFERENCES events(id) ON DELETE SET NULL,
+    mapping JSONB NOT NULL DEFAULT '{}',
+    header JSONB NOT NULL DEFAULT '[]',
+    rows JSONB NOT NULL DEFAULT '[]',
+    dry_run BOOLEAN NOT NULL DEFAULT TRUE,
+    status VARCHAR(20) NOT NULL CHECK (status IN ('queued', 'running', 'validated', 'completed', 'failed')),
+    total_rows INTEGER NOT NULL DEFAULT 0,
+    processed_rows INTEGER NOT NULL DEFAULT 0,
+    created_count INTEGER NOT NULL DEFAULT 0,
+    updated_count INTEGER NOT NULL DEFAULT 0,
+    registered_count INTEGER NOT NULL DEFAULT 0,
+    skipped_count INTEGER NOT NULL DEFAULT 0,
+    error_count INTEGER NOT NULL DEFAULT 0,
+    errors JSONB NOT NULL DEFAULT '[]',
+    failure_reason VARCHAR(255) NOT NULL DEFAULT '',
+    created_by INTEGER REFERENCES organizers(id) ON DELETE SET NULL,
+    started_at TIMESTAMPTZ,
+    heartbeat_at TIMESTAMPTZ,
+    finished_at TIMESTAMPTZ,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Для фоновой задачи: очередь и зависшие импорты
+CREATE INDEX IF NOT EXISTS idx_imports_pending ON imports(id) WHERE status IN ('queued', 'running');
+CREATE INDEX IF NOT EXISTS idx_imports_event_id ON imports(event_id);
@@ -0,0 +1,4 @@
+UPDATE orders SET status = 'pending' WHERE status = 'processing';
+ALTER TABLE orders DROP CONSTRAINT IF EXISTS orders_status_check;
+ALTER TABLE orders
+    ADD CONSTRAINT orders_status_check CHECK (status IN ('pending', 'paid', 'failed', 'refunded', 'expired'));
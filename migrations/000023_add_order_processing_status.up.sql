@@ -0,0 +1,4 @@
+-- "processing": списание у провайдера идет вне транзакции, заказ на это время не истекает
+ALTER TABLE orders DROP CONSTRAINT IF EXISTS orders_status_check;
+ALTER TABLE orders
+    ADD CONSTRAINT orders_status_check CHECK (status IN ('pending', 'processing', 'paid', 'failed', 'refunded', 'expired'));
@@ -0,0 +1 @@
+ALTER TABLE tickets ALTER COLUMN ticket_type TYPE VARCHAR(50) USING LEFT(ticket_type, 50);
@@ -0,0 +1,2 @@
+-- Тикет категории получает ее название (VARCHAR(255)) в ticket_type
+ALTER TABLE tickets ALTER COLUMN ticket_type TYPE VARCHAR(255);
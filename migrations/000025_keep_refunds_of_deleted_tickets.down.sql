@@ -0,0 +1,5 @@
+DELETE FROM refunds WHERE ticket_id IS NULL;
+ALTER TABLE refunds DROP CONSTRAINT IF EXISTS refunds_ticket_id_fkey;
+ALTER TABLE refunds ALTER COLUMN ticket_id SET NOT NULL;
+ALTER TABLE refunds
+    ADD CONSTRAINT refunds_ticket_id_fkey FOREIGN KEY (ticket_id) REFERENCES tickets(id) ON DELETE RESTRICT;
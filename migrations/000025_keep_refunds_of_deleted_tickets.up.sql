@@ -0,0 +1,6 @@
+-- Возврат - бухгалтерская запись: сумма и валюта хранятся в нем самом, поэтому
+-- удаление тикета (или участника, события) не блокируется, а только отвязывает возврат
+ALTER TABLE refunds DROP CONSTRAINT IF EXISTS refunds_ticket_id_fkey;
+ALTER TABLE refunds ALTER COLUMN ticket_id DROP NOT NULL;
+ALTER TABLE refunds
+    ADD CONSTRAINT refunds_ticket_id_fkey FOREIGN KEY (ticket_id) REFERENCES tickets(id) ON DELETE SET NULL;
@@ -0,0 +1,5 @@
+DROP INDEX IF EXISTS idx_refunds_ticket_processing;
+UPDATE refunds SET status = 'failed', failure_reason = 'interrupted' WHERE status = 'processing';
+ALTER TABLE refunds DROP CONSTRAINT IF EXISTS refunds_status_check;
+ALTER TABLE refunds
+    ADD CONSTRAINT refunds_status_check CHECK (status IN ('succeeded', 'failed'));
@@ -0,0 +1,7 @@
+-- "processing": обращение к обработчику возвратов идет вне транзакции
+ALTER TABLE refunds DROP CONSTRAINT IF EXISTS refunds_status_check;
+ALTER TABLE refunds
+    ADD CONSTRAINT refunds_status_check CHECK (status IN ('processing', 'succeeded', 'failed'));
+
+-- Не более одного незавершенного возврата на тикет
+CREATE UNIQUE INDEX IF NOT EXISTS idx_refunds_ticket_processing ON refunds(ticket_id) WHERE status = 'processing';
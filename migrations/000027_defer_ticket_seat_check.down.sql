@@ -0,0 +1,3 @@
+ALTER TABLE tickets DROP CONSTRAINT IF EXISTS tickets_seat_id_fkey;
+ALTER TABLE tickets
+    ADD CONSTRAINT tickets_seat_id_fkey FOREIGN KEY (seat_id) REFERENCES seats(id) ON DELETE RESTRICT;
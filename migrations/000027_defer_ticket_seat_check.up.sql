@@ -0,0 +1,6 @@
+-- RESTRICT проверяется сразу при каскадном удалении мест, еще до удаления тикетов
+-- того же события, и удаление события со своей схемой зала падало. NO ACTION
+-- проверяется в конце команды: места с тикетами других событий по-прежнему не удалить.
+ALTER TABLE tickets DROP CONSTRAINT IF EXISTS tickets_seat_id_fkey;
+ALTER TABLE tickets
+    ADD CONSTRAINT tickets_seat_id_fkey FOREIGN KEY (seat_id) REFERENCES seats(id) ON DELETE NO ACTION;
@@ -0,0 +1,3 @@
+ALTER TABLE imports
+    DROP COLUMN IF EXISTS pending_count,
+    DROP COLUMN IF EXISTS waitlisted_count;
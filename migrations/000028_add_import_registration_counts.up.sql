@@ -0,0 +1,4 @@
+-- Заявки на одобрение и лист ожидания считаются отдельно от регистраций с тикетом
+ALTER TABLE imports
+    ADD COLUMN IF NOT EXISTS pending_count INTEGER NOT NULL DEFAULT 0,
+    ADD COLUMN IF NOT EXISTS waitlisted_count INTEGER NOT NULL DEFAULT 0;
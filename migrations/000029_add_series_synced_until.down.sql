@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS idx_event_series_synced_until;
+ALTER TABLE event_series DROP COLUMN IF EXISTS synced_until;
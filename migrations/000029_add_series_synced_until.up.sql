@@ -0,0 +1,8 @@
+-- Горизонт, до которого развернуты вхождения бесконечной серии; NULL - серия конечна
+ALTER TABLE event_series ADD COLUMN IF NOT EXISTS synced_until TIMESTAMP;
+
+-- Существующие бесконечные серии продлеваются при первом запуске синхронизации
+UPDATE event_series SET synced_until = CURRENT_TIMESTAMP
+WHERE rrule NOT ILIKE '%COUNT=%' AND rrule NOT ILIKE '%UNTIL=%';
+
+CREATE INDEX IF NOT EXISTS idx_event_series_synced_until ON event_series(synced_until) WHERE synced_until IS NOT NULL;
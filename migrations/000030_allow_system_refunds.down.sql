@@ -0,0 +1,2 @@
+DELETE FROM refunds WHERE organizer_id IS NULL;
+ALTER TABLE refunds ALTER COLUMN organizer_id SET NOT NULL;
@@ -0,0 +1,2 @@
+-- Возврат оплаты по заказу, который не поместился на событие, оформляет система без организатора
+ALTER TABLE refunds ALTER COLUMN organizer_id DROP NOT NULL;
@@ -0,0 +1,8 @@
+-- Промокод заказа: скидка входит в цену позиций и сумму заказа до списания,
+-- погашения записываются по тикетам при выполнении заказа
+ALTER TABLE orders
+    ADD COLUMN IF NOT EXISTS promo_code_id INTEGER REFERENCES promo_codes(id) ON DELETE SET NULL,
+    ADD COLUMN IF NOT EXISTS discount BIGINT NOT NULL DEFAULT 0 CHECK (discount >= 0);
+
+ALTER TABLE order_items
+    ADD COLUMN IF NOT EXISTS discount BIGINT NOT NULL DEFAULT 0 CHECK (discount >= 0);
@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS idx_check_ins_session_id;
+DELETE FROM check_ins WHERE session_id IS NOT NULL;
+ALTER TABLE check_ins DROP COLUMN IF EXISTS session_id;
@@ -0,0 +1,5 @@
+-- Сканирование на входе в сессию записывается в журнал проходов, но в политике прохода
+-- события не учитывается
+ALTER TABLE check_ins ADD COLUMN IF NOT EXISTS session_id INTEGER REFERENCES sessions(id) ON DELETE CASCADE;
+
+CREATE INDEX IF NOT EXISTS idx_check_ins_session_id ON check_ins(session_id) WHERE session_id IS NOT NULL;
@@ -0,0 +1,5 @@
+DROP INDEX IF EXISTS idx_refunds_processing;
+ALTER TABLE refunds DROP COLUMN IF EXISTS idempotency_key;
+
+-- Восстанавливается, только если по каждому тикету не больше одного успешного возврата
+CREATE UNIQUE INDEX IF NOT EXISTS idx_refunds_ticket_succeeded ON refunds(ticket_id) WHERE status = 'succeeded';
@@ -0,0 +1,18 @@
+-- По тикету возможно несколько частичных возвратов, пока их сумма не достигнет цены тикета
+DROP INDEX IF EXISTS idx_refunds_ticket_succeeded;
+
+-- Ключ идемпотентности хранится с возвратом: задача восстановления повторяет прерванный
+-- возврат с тем же ключом
+ALTER TABLE refunds ADD COLUMN IF NOT EXISTS idempotency_key VARCHAR(255) NOT NULL DEFAULT '';
+
+UPDATE refunds r
+SET idempotency_key = 'ticket-' || r.ticket_id || '-refund-' || (
+    SELECT COUNT(*) + 1 FROM refunds x WHERE x.ticket_id = r.ticket_id AND x.status <> 'processing'
+)
+WHERE r.status = 'processing' AND r.ticket_id IS NOT NULL;
+
+UPDATE refunds
+SET idempotency_key = 'order-' || order_id || '-refund'
+WHERE status = 'processing' AND ticket_id IS NULL AND organizer_id IS NULL AND order_id IS NOT NULL;
+
+CREATE INDEX IF NOT EXISTS idx_refunds_processing ON refunds(created_at) WHERE status = 'processing';